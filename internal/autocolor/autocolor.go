@@ -2,7 +2,9 @@ package autocolor
 
 import (
 	"cmp"
+	"hash/fnv"
 	"slices"
+	"strings"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
 	"github.com/theunrepentantgeek/task-graph/internal/graph"
@@ -36,9 +38,10 @@ var ColorblindPalette = []string{
 }
 
 // GenerateRules generates a NodeStyleRule for each distinct namespace found in the graph.
-// Colors are assigned from the Palette in order: shallower namespaces first, then
-// alphabetically within the same depth. When there are more namespaces than palette entries,
-// colors wrap around.
+// The color of each namespace is derived from a hash of its name, rather than its position
+// among the others, moving on only past colors taken by namespaces sorting before it; adding or
+// removing a namespace recolors at most some of those sorting after it. Rules are ordered with
+// shallower namespaces first, then alphabetically within the same depth.
 //
 // The generated rules should be prepended to any existing NodeStyleRules so that
 // user-defined rules take precedence over the auto-generated ones.
@@ -54,18 +57,54 @@ func GenerateRulesWithPalette(gr *graph.Graph, p []string) []config.NodeStyleRul
 		return nil
 	}
 
+	return GenerateRulesWithOptions(gr, Options{Palette: p})
+}
+
+// Options customise the colors GenerateRulesWithOptions gives to namespaces.
+type Options struct {
+	// Palette is the list of colors to choose from. The built-in palette is used if it is empty.
+	Palette []string
+
+	// Pins maps namespaces to the color they must have. No other namespace is given a pinned
+	// color, unless every color of the palette is pinned.
+	Pins map[string]string
+
+	// Hierarchy colors only top-level namespaces from the palette. Every other namespace gets
+	// a tint or shade of its parent's color, so related namespaces have related colors.
+	Hierarchy bool
+}
+
+// GenerateRulesWithOptions is like GenerateRules, with the colors chosen as set by opts. The
+// text of each namespace is colored black or white, whichever contrasts more with its fill.
+func GenerateRulesWithOptions(gr *graph.Graph, opts Options) []config.NodeStyleRule {
+	p := opts.Palette
+	if len(p) == 0 {
+		p = palette
+	}
+
 	namespaces := collectAllNamespaces(gr)
 	sortNamespaces(namespaces)
 
+	var colors map[string]string
+	if opts.Hierarchy {
+		colors = hierarchyColors(namespaces, p, opts.Pins)
+	} else {
+		colors = assignColors(namespaces, p, opts.Pins)
+	}
+
 	rules := make([]config.NodeStyleRule, 0, len(namespaces)*2)
-	for i, ns := range namespaces {
-		color := p[i%len(p)]
+	for _, ns := range namespaces {
+		color := colors[ns]
+		fontColor := readableColor(color)
 
-		// Exact match for the namespace task itself (e.g. "tidy")
+		// Exact match for the namespace task itself (e.g. "tidy"), named so that the
+		// namespace colour is shown in any legend
 		rules = append(rules, config.NodeStyleRule{
 			Match:     ns,
+			Name:      ns,
 			FillColor: color,
 			Style:     "filled",
+			FontColor: fontColor,
 		})
 
 		// Children match for subtasks (e.g. "tidy:gofumpt", "tidy:lint")
@@ -73,12 +112,85 @@ func GenerateRulesWithPalette(gr *graph.Graph, p []string) []config.NodeStyleRul
 			Match:     namespace.MatchPattern(ns),
 			FillColor: color,
 			Style:     "filled",
+			FontColor: fontColor,
 		})
 	}
 
 	return rules
 }
 
+// assignColors returns the color of each namespace, either its pin or one taken from the
+// palette by assignIndexes. Pinned colors are left out of the palette, unless every color of
+// it is pinned.
+func assignColors(namespaces []string, p []string, pins map[string]string) map[string]string {
+	pinned := make(map[string]bool, len(pins))
+	for _, color := range pins {
+		pinned[strings.ToLower(color)] = true
+	}
+
+	available := slices.DeleteFunc(slices.Clone(p), func(color string) bool {
+		return pinned[strings.ToLower(color)]
+	})
+
+	if len(available) == 0 {
+		available = p
+	}
+
+	result := make(map[string]string, len(namespaces))
+	unpinned := make([]string, 0, len(namespaces))
+
+	for _, ns := range namespaces {
+		if color, ok := pins[ns]; ok {
+			result[ns] = color
+		} else {
+			unpinned = append(unpinned, ns)
+		}
+	}
+
+	for ns, index := range assignIndexes(unpinned, len(available)) {
+		result[ns] = available[index]
+	}
+
+	return result
+}
+
+// assignIndexes returns an index below size for each of the sorted names. Each name takes the
+// index selected by a hash of the name or, if an earlier name already has it, the next free
+// one, so that indexes are distinct until all are in use. Later names then take the index of
+// their hash, sharing it. A name therefore keeps its index when names sorting after it come
+// and go, but may move when an earlier name takes its index.
+func assignIndexes(names []string, size int) map[string]int {
+	result := make(map[string]int, len(names))
+	used := make([]bool, size)
+	free := size
+
+	for _, name := range names {
+		index := paletteIndex(name, size)
+
+		if free > 0 {
+			for used[index] {
+				index = (index + 1) % size
+			}
+
+			used[index] = true
+			free--
+		}
+
+		result[name] = index
+	}
+
+	return result
+}
+
+// paletteIndex returns the index of the palette color (or tint step) selected by a hash of the
+// namespace.
+func paletteIndex(ns string, size int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(ns)) // Writing to a hash never fails
+
+	return int(h.Sum32() % uint32(size)) //nolint:gosec // The palette is tiny, so there's no overflow
+}
+
 // collectAllNamespaces returns all distinct namespaces found in the graph,
 // including parent namespaces.
 func collectAllNamespaces(gr *graph.Graph) []string {
@@ -2,11 +2,14 @@ package autocolor
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
+	"github.com/theunrepentantgeek/task-graph/internal/config"
 	"github.com/theunrepentantgeek/task-graph/internal/graph"
 	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+	"github.com/theunrepentantgeek/task-graph/internal/rgb"
 )
 
 func TestGenerateRules_NoNamespaces_ReturnsEmptyRules(t *testing.T) {
@@ -42,14 +45,31 @@ func TestGenerateRules_SingleNamespace_ReturnsTwoRules(t *testing.T) {
 	// Assert: exact match + children match
 	g.Expect(rules).To(HaveLen(2))
 	g.Expect(rules[0].Match).To(Equal("cmd"))
-	g.Expect(rules[0].FillColor).To(Equal(palette[0]))
+	g.Expect(rules[0].FillColor).To(Equal(palette[paletteIndex("cmd", len(palette))]))
 	g.Expect(rules[0].Style).To(Equal("filled"))
 	g.Expect(rules[1].Match).To(Equal("cmd[-.:]*"))
-	g.Expect(rules[1].FillColor).To(Equal(palette[0]))
+	g.Expect(rules[1].FillColor).To(Equal(rules[0].FillColor))
 	g.Expect(rules[1].Style).To(Equal("filled"))
 }
 
-func TestGenerateRules_MultipleNamespaces_AssignsColorsAlphabetically(t *testing.T) {
+func TestGenerateRules_SingleNamespace_NamesOnlyExactMatchRule(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("cmd:build")
+
+	// Act
+	rules := GenerateRules(gr)
+
+	// Assert: one named rule per namespace, so each colour appears once in a legend
+	g.Expect(rules).To(HaveLen(2))
+	g.Expect(rules[0].Name).To(Equal("cmd"))
+	g.Expect(rules[1].Name).To(BeEmpty())
+}
+
+func TestGenerateRules_MultipleNamespaces_AssignsDistinctColors(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
@@ -66,14 +86,14 @@ func TestGenerateRules_MultipleNamespaces_AssignsColorsAlphabetically(t *testing
 	g.Expect(rules).To(HaveLen(4))
 
 	g.Expect(rules[0].Match).To(Equal("cmd"))
-	g.Expect(rules[0].FillColor).To(Equal(palette[0]))
 	g.Expect(rules[1].Match).To(Equal("cmd[-.:]*"))
-	g.Expect(rules[1].FillColor).To(Equal(palette[0]))
+	g.Expect(rules[1].FillColor).To(Equal(rules[0].FillColor))
 
 	g.Expect(rules[2].Match).To(Equal("controllers"))
-	g.Expect(rules[2].FillColor).To(Equal(palette[1]))
 	g.Expect(rules[3].Match).To(Equal("controllers[-.:]*"))
-	g.Expect(rules[3].FillColor).To(Equal(palette[1]))
+	g.Expect(rules[3].FillColor).To(Equal(rules[2].FillColor))
+
+	g.Expect(rules[0].FillColor).NotTo(Equal(rules[2].FillColor))
 }
 
 func TestGenerateRules_NestedNamespaces_GeneratesRulesForAll(t *testing.T) {
@@ -93,16 +113,16 @@ func TestGenerateRules_NestedNamespaces_GeneratesRulesForAll(t *testing.T) {
 	// Expect two rules per namespace: "cmd" (depth 0) and "cmd:test" (depth 1)
 	g.Expect(rules).To(HaveLen(4))
 
-	// "cmd" comes first (shallower), then "cmd:test"
+	// "cmd" comes first (shallower), then "cmd:test", each in its own colour
 	g.Expect(rules[0].Match).To(Equal("cmd"))
-	g.Expect(rules[0].FillColor).To(Equal(palette[0]))
 	g.Expect(rules[1].Match).To(Equal("cmd[-.:]*"))
-	g.Expect(rules[1].FillColor).To(Equal(palette[0]))
+	g.Expect(rules[1].FillColor).To(Equal(rules[0].FillColor))
 
 	g.Expect(rules[2].Match).To(Equal("cmd:test"))
-	g.Expect(rules[2].FillColor).To(Equal(palette[1]))
 	g.Expect(rules[3].Match).To(Equal("cmd:test:*"))
-	g.Expect(rules[3].FillColor).To(Equal(palette[1]))
+	g.Expect(rules[3].FillColor).To(Equal(rules[2].FillColor))
+
+	g.Expect(rules[0].FillColor).NotTo(Equal(rules[2].FillColor))
 }
 
 func TestGenerateRules_MoreNamespacesThanPalette_CyclesColors(t *testing.T) {
@@ -121,9 +141,17 @@ func TestGenerateRules_MoreNamespacesThanPalette_CyclesColors(t *testing.T) {
 	// Assert: two rules per namespace
 	g.Expect(rules).To(HaveLen((len(palette) + 1) * 2))
 
-	// The last namespace's rules should cycle back to the first palette color
-	g.Expect(rules[len(palette)*2].FillColor).To(Equal(palette[0]))
-	g.Expect(rules[len(palette)*2+1].FillColor).To(Equal(palette[0]))
+	// Every palette color is used once before the last namespace shares the one its hash selects
+	colors := make([]string, 0, len(palette))
+	for i := 0; i < len(palette)*2; i += 2 {
+		colors = append(colors, rules[i].FillColor)
+	}
+
+	last := rules[len(palette)*2]
+
+	g.Expect(colors).To(ConsistOf(palette))
+	g.Expect(last.FillColor).To(Equal(palette[paletteIndex(last.Match, len(palette))]))
+	g.Expect(rules[len(palette)*2+1].FillColor).To(Equal(last.FillColor))
 }
 
 func TestGenerateRules_EmptyGraph_ReturnsEmptyRules(t *testing.T) {
@@ -204,7 +232,7 @@ func TestGenerateRules_SingleNamespace_IncludesExpectedFieldValues(t *testing.T)
 	g.Expect(rule.FillColor).NotTo(BeEmpty())
 	g.Expect(rule.Style).To(Equal("filled"))
 	g.Expect(rule.Color).To(BeEmpty())
-	g.Expect(rule.FontColor).To(BeEmpty())
+	g.Expect(rule.FontColor).To(Equal("black"))
 
 	// Check children-match rule
 	rule = rules[1]
@@ -212,7 +240,7 @@ func TestGenerateRules_SingleNamespace_IncludesExpectedFieldValues(t *testing.T)
 	g.Expect(rule.FillColor).NotTo(BeEmpty())
 	g.Expect(rule.Style).To(Equal("filled"))
 	g.Expect(rule.Color).To(BeEmpty())
-	g.Expect(rule.FontColor).To(BeEmpty())
+	g.Expect(rule.FontColor).To(Equal("black"))
 }
 
 func TestGenerateRules_TopLevelTaskMatchesNamespace_GetsColored(t *testing.T) {
@@ -275,10 +303,10 @@ func TestGenerateRulesWithPalette_CustomPalette_UsesSuppliedColors(t *testing.T)
 	// Act
 	rules := GenerateRulesWithPalette(gr, customPalette)
 
-	// Assert: both rules use the first custom color
+	// Assert: both rules use the custom color selected for the namespace
 	g.Expect(rules).To(HaveLen(2))
-	g.Expect(rules[0].FillColor).To(Equal("#FF0000"))
-	g.Expect(rules[1].FillColor).To(Equal("#FF0000"))
+	g.Expect(rules[0].FillColor).To(Equal(customPalette[paletteIndex("cmd", len(customPalette))]))
+	g.Expect(rules[1].FillColor).To(Equal(rules[0].FillColor))
 }
 
 func TestGenerateRulesWithPalette_ColorblindPalette_UsesHexColors(t *testing.T) {
@@ -299,3 +327,330 @@ func TestGenerateRulesWithPalette_ColorblindPalette_UsesHexColors(t *testing.T)
 		g.Expect(r.FillColor).To(HavePrefix("#"), "expected hex color from Okabe-Ito palette")
 	}
 }
+
+func TestGenerateHeatmapRules_NoDurations_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build")
+
+	// Act
+	rules := GenerateHeatmapRules(gr)
+
+	// Assert
+	g.Expect(rules).To(BeNil())
+}
+
+func TestGenerateHeatmapRules_ColorsByRelativeDuration(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build").Duration = 100 * time.Second
+	gr.AddNode("lint").Duration = 50 * time.Second
+	gr.AddNode("test").Duration = time.Second
+	gr.AddNode("docs")
+
+	// Act
+	rules := GenerateHeatmapRules(gr)
+
+	// Assert
+	g.Expect(rules).To(HaveLen(3))
+	g.Expect(rules[0].Match).To(Equal("build"))
+	g.Expect(rules[0].FillColor).To(Equal(HeatmapPalette[len(HeatmapPalette)-1]))
+	g.Expect(rules[1].Match).To(Equal("lint"))
+	g.Expect(rules[1].FillColor).To(Equal(HeatmapPalette[2]))
+	g.Expect(rules[2].Match).To(Equal("test"))
+	g.Expect(rules[2].FillColor).To(Equal(HeatmapPalette[0]))
+}
+
+func TestGenerateRules_AddingNamespace_KeepsColorsOfEarlierNamespaces(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		namespaces []string
+		added      string
+	}{
+		"fewer namespaces than colors": {
+			namespaces: []string{"build", "docs", "test"},
+			added:      "deploy",
+		},
+		"sorting after existing namespaces": {
+			namespaces: []string{"build", "ci", "deploy", "docs", "lint", "test"},
+			added:      "vet",
+		},
+		"more namespaces than colors": {
+			namespaces: []string{
+				"build", "ci", "deploy", "docs", "gen", "lint", "mocks", "release", "test", "tidy",
+			},
+			added: "api",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Arrange
+			before := graph.New()
+			after := graph.New()
+
+			for _, ns := range c.namespaces {
+				before.AddNode(ns + ":task")
+				after.AddNode(ns + ":task")
+			}
+
+			after.AddNode(c.added + ":task")
+
+			// Act
+			beforeColors := fillColors(GenerateRules(before))
+			afterColors := fillColors(GenerateRules(after))
+
+			// Assert: only namespaces sorting after the new one may move
+			for ns, color := range beforeColors {
+				if ns < c.added {
+					g.Expect(afterColors).To(HaveKeyWithValue(ns, color))
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateRules_AddingNamespaceAfterPaletteIsFull_KeepsOtherColors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	namespaces := []string{"build", "ci", "deploy", "docs", "gen", "lint", "mocks", "release", "test", "tidy"}
+
+	before := graph.New()
+	after := graph.New()
+
+	for _, ns := range namespaces {
+		before.AddNode(ns + ":task")
+		after.AddNode(ns + ":task")
+	}
+
+	after.AddNode("vet:task")
+
+	// Act
+	beforeColors := fillColors(GenerateRules(before))
+	afterColors := fillColors(GenerateRules(after))
+
+	// Assert
+	for ns, color := range beforeColors {
+		g.Expect(afterColors).To(HaveKeyWithValue(ns, color))
+	}
+
+	g.Expect(afterColors).To(HaveKeyWithValue("vet", palette[paletteIndex("vet", len(palette))]))
+}
+
+func TestAssignColors_SameHash_TakesNextFreeColor(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange: two namespaces share the two colours between them, whichever their hashes select
+	p := []string{"red", "green"}
+
+	// Act
+	colors := assignColors([]string{"a", "b"}, p, nil)
+
+	// Assert
+	g.Expect([]string{colors["a"], colors["b"]}).To(ConsistOf(p))
+	g.Expect(colors["a"]).To(Equal(p[paletteIndex("a", len(p))]))
+}
+
+func TestAssignColors_Pins_AreKeptAndAvoided(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange: b is pinned to red, so a has to take green
+	p := []string{"red", "green"}
+	pins := map[string]string{"b": "Red"}
+
+	// Act
+	colors := assignColors([]string{"a", "b"}, p, pins)
+
+	// Assert
+	g.Expect(colors).To(HaveKeyWithValue("a", "green"))
+	g.Expect(colors).To(HaveKeyWithValue("b", "Red"))
+}
+
+// TestGenerateRulesWithOptions
+
+func TestGenerateRulesWithOptions_ColorblindPalette_GivesReadableFontColors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	for _, ns := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		gr.AddNode(ns + ":task")
+	}
+
+	// Act
+	rules := GenerateRulesWithOptions(gr, Options{Palette: ColorblindPalette})
+
+	// Assert: every fill has text with at least the WCAG AA contrast for large text
+	for _, rule := range rules {
+		fill, ok := rgb.Parse(rule.FillColor)
+		g.Expect(ok).To(BeTrue())
+
+		font, ok := rgb.Parse(rule.FontColor)
+		g.Expect(ok).To(BeTrue())
+
+		g.Expect(rgb.Contrast(fill, font)).To(BeNumerically(">=", 3), rule.Match)
+	}
+}
+
+func TestGenerateRulesWithOptions_Hierarchy_GivesSharedHueToDescendants(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build:docker:amd64:push")
+	gr.AddNode("build:docker:arm64:push")
+	gr.AddNode("test:unit")
+
+	opts := Options{
+		Palette:   []string{"#1f78b4", "#e31a1c"},
+		Hierarchy: true,
+	}
+
+	// Act
+	colors := fillColors(GenerateRulesWithOptions(gr, opts))
+
+	// Assert: each level below build is a lighter tint of its color
+	build := colors["build"]
+	docker := colors["build:docker"]
+	amd64 := colors["build:docker:amd64"]
+	arm64 := colors["build:docker:arm64"]
+
+	g.Expect(build).To(Equal(opts.Palette[paletteIndex("build", 2)]))
+	g.Expect(colors["test"]).NotTo(Equal(build))
+
+	for _, child := range []string{docker, amd64, arm64} {
+		g.Expect(child).NotTo(Equal(build))
+		g.Expect(dominantChannel(child)).To(Equal(dominantChannel(build)), child)
+	}
+
+	g.Expect(luminance(docker)).To(BeNumerically(">", luminance(build)))
+	g.Expect(luminance(amd64)).To(BeNumerically(">", luminance(docker)))
+	g.Expect(luminance(arm64)).To(BeNumerically(">", luminance(docker)))
+}
+
+func TestGenerateRulesWithOptions_Hierarchy_DarkensLightColors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("docs:site")
+
+	// Act
+	colors := fillColors(GenerateRulesWithOptions(gr, Options{Palette: []string{"lightyellow"}, Hierarchy: true}))
+
+	// Assert
+	g.Expect(colors["docs"]).To(Equal("lightyellow"))
+	g.Expect(luminance(colors["docs:site"])).To(BeNumerically("<", luminance("lightyellow")))
+}
+
+func TestGenerateRulesWithOptions_Hierarchy_PinnedNamespaceColorsItsDescendants(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build:docker:amd64:push")
+
+	opts := Options{
+		Palette:   []string{"lightblue"},
+		Pins:      map[string]string{"build:docker": "#000080"},
+		Hierarchy: true,
+	}
+
+	// Act
+	rules := GenerateRulesWithOptions(gr, opts)
+	colors := fillColors(rules)
+
+	// Assert
+	g.Expect(colors).To(HaveKeyWithValue("build", "lightblue"))
+	g.Expect(colors).To(HaveKeyWithValue("build:docker", "#000080"))
+	g.Expect(luminance(colors["build:docker:amd64"])).To(BeNumerically(">", luminance("#000080")))
+
+	for _, rule := range rules {
+		if rule.Match == "build:docker" {
+			g.Expect(rule.FontColor).To(Equal("white"))
+		}
+	}
+}
+
+func TestGenerateRulesWithOptions_Hierarchy_GivesSiblingsDifferentTints(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build:docker:amd64:push")
+	gr.AddNode("build:docker:arm64:push")
+	gr.AddNode("build:docker:riscv:push")
+
+	// Act
+	colors := fillColors(GenerateRulesWithOptions(gr, Options{Hierarchy: true}))
+
+	// Assert
+	g.Expect(colors["build:docker:amd64"]).NotTo(Equal(colors["build:docker:arm64"]))
+	g.Expect(colors["build:docker:amd64"]).NotTo(Equal(colors["build:docker:riscv"]))
+	g.Expect(colors["build:docker:arm64"]).NotTo(Equal(colors["build:docker:riscv"]))
+}
+
+func TestGenerateRulesWithOptions_EmptyPalette_UsesDefaultPalette(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("cmd:build")
+
+	// Act
+	rules := GenerateRulesWithOptions(gr, Options{})
+
+	// Assert
+	g.Expect(rules).To(Equal(GenerateRules(gr)))
+}
+
+// fillColors returns the fill color of each rule, keyed by its match pattern.
+func fillColors(rules []config.NodeStyleRule) map[string]string {
+	result := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		result[rule.Match] = rule.FillColor
+	}
+
+	return result
+}
+
+// luminance returns the relative luminance of a color.
+func luminance(fill string) float64 {
+	c, _ := rgb.Parse(fill)
+
+	return rgb.Luminance(c)
+}
+
+// dominantChannel returns the name of the strongest channel of the color.
+func dominantChannel(fill string) string {
+	c, _ := rgb.Parse(fill)
+
+	switch {
+	case c.R >= c.G && c.R >= c.B:
+		return "red"
+	case c.G >= c.B:
+		return "green"
+	default:
+		return "blue"
+	}
+}
@@ -0,0 +1,62 @@
+package autocolor
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// HeatmapPalette is the ordered list of fill colors used for duration heat-maps, from the
+// shortest duration to the longest. It is the five-class YlOrRd scheme from ColorBrewer.
+var HeatmapPalette = []string{
+	"#ffffb2",
+	"#fecc5c",
+	"#fd8d3c",
+	"#f03b20",
+	"#bd0026",
+}
+
+// GenerateHeatmapRules generates a NodeStyleRule for each node with a known duration, with a
+// fill color from HeatmapPalette chosen by how its duration compares to the longest duration
+// in the graph. Nodes without a duration are left unstyled.
+//
+// As with GenerateRules, the generated rules should be prepended to any existing
+// NodeStyleRules so that user-defined rules take precedence.
+func GenerateHeatmapRules(gr *graph.Graph) []config.NodeStyleRule {
+	var nodes []*graph.Node
+
+	for node := range gr.Nodes() {
+		if node.Duration > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(nodes, func(left, right *graph.Node) int {
+		return cmp.Compare(left.ID(), right.ID())
+	})
+
+	longest := slices.MaxFunc(nodes, func(left, right *graph.Node) int {
+		return cmp.Compare(left.Duration, right.Duration)
+	}).Duration
+
+	rules := make([]config.NodeStyleRule, 0, len(nodes))
+	for _, node := range nodes {
+		ratio := float64(node.Duration) / float64(longest)
+		index := min(int(ratio*float64(len(HeatmapPalette))), len(HeatmapPalette)-1)
+
+		rules = append(rules, config.NodeStyleRule{
+			Match:     namespace.QuoteMatchPattern(node.ID()),
+			FillColor: HeatmapPalette[index],
+			Style:     "filled",
+		})
+	}
+
+	return rules
+}
@@ -0,0 +1,113 @@
+package autocolor
+
+import (
+	"image/color"
+	"maps"
+
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+	"github.com/theunrepentantgeek/task-graph/internal/rgb"
+)
+
+// tintSteps are how far each nested namespace moves from the color of its parent, towards
+// black or white. Siblings take different steps, selected by assignIndexes, so that they can
+// be told apart while still looking related.
+var tintSteps = []float64{0.18, 0.26, 0.34}
+
+// hierarchyColors returns the color of each namespace, which must be sorted with shallower
+// namespaces first. Top-level namespaces are colored by assignColors; every other namespace
+// has its pin or, failing that, a tint or shade of the color of its parent.
+//
+// Dark colors are lightened and light colors darkened, with the direction set by the
+// top-level (or pinned) namespace and followed by all its descendants, so that deeper
+// namespaces are always further from their ancestor.
+func hierarchyColors(namespaces []string, p []string, pins map[string]string) map[string]string {
+	var topLevel []string
+
+	for _, ns := range namespaces {
+		if namespace.Parent(ns) == "" {
+			topLevel = append(topLevel, ns)
+		}
+	}
+
+	result := assignColors(topLevel, p, pins)
+	steps := siblingSteps(namespaces)
+	targets := make(map[string]color.RGBA, len(namespaces))
+
+	for _, ns := range namespaces {
+		fill, ok := result[ns]
+		if !ok {
+			fill, ok = pins[ns]
+		}
+
+		if ok {
+			result[ns] = fill
+			targets[ns] = tintTarget(fill)
+
+			continue
+		}
+
+		parent := namespace.Parent(ns)
+		target := targets[parent]
+		targets[ns] = target
+		result[ns] = tint(result[parent], target, tintSteps[steps[ns]])
+	}
+
+	return result
+}
+
+// siblingSteps returns the index of the tint step of each nested namespace, chosen so that
+// siblings differ where possible.
+func siblingSteps(namespaces []string) map[string]int {
+	children := make(map[string][]string)
+
+	for _, ns := range namespaces {
+		if parent := namespace.Parent(ns); parent != "" {
+			children[parent] = append(children[parent], ns)
+		}
+	}
+
+	result := make(map[string]int, len(namespaces))
+
+	for _, siblings := range children {
+		maps.Copy(result, assignIndexes(siblings, len(tintSteps)))
+	}
+
+	return result
+}
+
+// tintTarget returns the color that the descendants of a namespace with the given fill move
+// towards: white for a dark fill, black for a light one.
+func tintTarget(fill string) color.RGBA {
+	c, ok := rgb.Parse(fill)
+	if ok && rgb.Luminance(c) < 0.5 {
+		return rgb.White
+	}
+
+	return rgb.Black
+}
+
+// tint returns fill moved by the given fraction towards target, or fill itself if it can't be
+// parsed.
+func tint(fill string, target color.RGBA, fraction float64) string {
+	c, ok := rgb.Parse(fill)
+	if !ok || c.A == 0 {
+		return fill
+	}
+
+	return rgb.Hex(rgb.Mix(c, target, fraction))
+}
+
+// readableColor returns black or white, whichever contrasts more with the fill color, or
+// nothing if the fill can't be parsed.
+func readableColor(fill string) string {
+	c, ok := rgb.Parse(fill)
+	if !ok || c.A == 0 {
+		return ""
+	}
+
+	if rgb.Readable(c) == rgb.White {
+		return "white"
+	}
+
+	return "black"
+}
@@ -1,106 +1,158 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/phsym/console-slog"
 	"github.com/rotisserie/eris"
-	"gopkg.in/yaml.v3"
 
 	"github.com/theunrepentantgeek/task-graph/internal/autocolor"
 	"github.com/theunrepentantgeek/task-graph/internal/config"
-	"github.com/theunrepentantgeek/task-graph/internal/dot"
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+	"github.com/theunrepentantgeek/task-graph/internal/durations"
 	"github.com/theunrepentantgeek/task-graph/internal/graph"
 	"github.com/theunrepentantgeek/task-graph/internal/graphviz"
 	"github.com/theunrepentantgeek/task-graph/internal/loader"
-	"github.com/theunrepentantgeek/task-graph/internal/mermaid"
 	"github.com/theunrepentantgeek/task-graph/internal/namespace"
-	"github.com/theunrepentantgeek/task-graph/internal/taskgraph"
+	"github.com/theunrepentantgeek/task-graph/internal/schedule"
 )
 
-// graphTypeDot and graphTypeMermaid are the supported graph output formats.
+// stdio is the path used to read the taskfile from stdin, or write the graph to stdout.
+const stdio = "-"
+
+// graphTypeDot, graphTypeMermaid and graphTypeGantt are the supported graph output formats.
 const (
 	graphTypeDot     = "dot"
 	graphTypeMermaid = "mermaid"
+	graphTypeGantt   = "gantt"
 )
 
 //nolint:tagalign // Not useful here because different members have different tags.
 type CLI struct {
-	Taskfile string `arg:"" help:"Path to the taskfile to process."`
-	Output   string `help:"Path to the output file." long:"output" required:"true" short:"o"`
-	Config   string `help:"Path to a config file (YAML or JSON)." long:"config" short:"c"`
+	Taskfiles []string `arg:"" help:"Paths to the taskfiles to process, or directories to search as task does. Defaults to the current directory. Several taskfiles are drawn as one graph, each in its own cluster." optional:""` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Dir string `help:"Directory used to resolve includes when reading the taskfile from stdin (-). Defaults to the current directory." long:"dir" short:"d"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Discover string `help:"Search the given directory tree for taskfiles, adding each to the graph." long:"discover"`
+	Output   string `help:"Path to the output file, or - to write to stdout. Required unless the config has profiles." long:"output" short:"o"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Config string `help:"Path to a config file (YAML or JSON). Defaults to .task-graph.yml alongside the taskfile, if present." long:"config" short:"c"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
 	GroupByNamespace bool `help:"Group tasks in the same namespace together in the output." long:"group-by-namespace"`
 
 	AutoColor bool `help:"Automatically color nodes by namespace using a built-in palette." long:"auto-color"`
 
+	AutoColorMode string `help:"How --auto-color assigns colours: namespace (default), hierarchy (tints of a colour per top-level namespace) or duration (a heat-map of measured task durations)." long:"auto-color-mode"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
 	ColorblindMode bool `help:"Use an accessibility-optimised colour palette (Okabe-Ito) for --auto-color instead of the default palette." long:"colorblind-mode"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
 	IncludeGlobalVars bool `help:"Include global variables as nodes in the graph, with edges to consuming tasks." long:"include-global-vars"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
-	GraphType string `help:"Type of graph to generate (dot or mermaid). Defaults to dot." long:"graph-type"`
+	Durations string `help:"Path to a file of measured task durations: a timestamped 'task --verbose' log, or a JSON or CSV file." long:"durations"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	CriticalPath bool `help:"Highlight the critical path, the chain of tasks with the longest total duration. Requires --durations." long:"critical-path"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Theme string `help:"Theme setting the fonts and colours of the output: light, dark, monochrome-print, high-contrast, presentation, or one defined in the config." long:"theme"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Legend bool `help:"Include a legend explaining the colours, shapes and edge styles used in the graph." long:"legend"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	GraphType string `help:"Type of graph to generate (dot, mermaid or gantt). Defaults to dot; the graphType of a profile takes precedence." long:"graph-type"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	GanttTask string `help:"Task whose execution is shown by a gantt graph, as a Mermaid Gantt chart." long:"gantt-task"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	PlatformSafe bool `help:"Write Mermaid as Markdown ready for GitHub or GitLab: in a fenced block, split into several diagrams if too big for them to render." long:"platform-safe"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
 	Highlight string `help:"Highlight specific tasks in the graph. Accepts task names or glob patterns, separated by commas or semicolons." long:"highlight"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
 	HighlightColor string `help:"Fill colour to use when highlighting tasks (e.g. orange, #ff9900). Defaults to yellow." long:"highlight-color"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
-	RenderImage string `help:"Render the graph as an image using graphviz dot. Specify the file type (e.g. png, svg)." long:"render-image"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+	RenderImage string `help:"Render the graph as images, using graphviz dot, mermaid-cli or the built-in renderer. List file types (e.g. svg,png), each optionally with =PATH; otherwise written beside --output, or to it if its extension matches." long:"render-image"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	RenderTimeout string `help:"How long to wait for dot or mermaid-cli to render images, such as 30s or 2m. Defaults to 1m." long:"render-timeout"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Layout string `help:"Graphviz layout engine used by --render-image: dot (default), neato, fdp, sfdp, circo, twopi, osage or patchwork." long:"layout"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Renderer string `help:"How --render-image draws images: auto (the default; mermaid-cli for Mermaid graphs, else dot if found, else built-in), graphviz, mermaid, or builtin (svg and png without Graphviz)." long:"renderer"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	ExportConfig string `help:"Export the effective configuration to a file (YAML or JSON based on file extension). YAML exports show where each value came from." long:"export-config"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Analyze string `help:"Analyze how the given task would execute: print its execution waves, maximum parallelism, longest chain and critical path." long:"analyze"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	RankByWave bool `help:"Place tasks in the same execution wave on the same rank in dot output. Requires --analyze." long:"rank-by-wave"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Download bool `help:"Download remote includes that aren't cached. Otherwise they are resolved only from the caches and --remote-include." long:"download"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	DownloadTimeout string `help:"How long downloading remote includes may take, such as 10s or 1m. Defaults to 30s." long:"download-timeout"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	TrustedHost []string `help:"Use remote includes downloaded from this host, including any port, without task having trusted them. May be repeated." long:"trusted-host" placeholder:"HOST"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Offline bool `help:"Never download remote includes, even with --download." long:"offline"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
-	ExportConfig string `help:"Export the effective configuration to a file (YAML or JSON based on file extension)." long:"export-config"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+	CacheDir string `help:"Directory in which downloaded remote includes are cached. Defaults to task-graph in the user cache directory." long:"cache-dir"` //nolint:revive // Intentionally long line for clarity in the CLI help.
 
-	Focus   string `help:"Show only tasks matching the given patterns together with all their transitive dependencies and dependents. Accepts task names or glob patterns, separated by commas or semicolons." long:"focus"` //nolint:revive // Intentionally long line for clarity in the CLI help.
-	Verbose bool   `help:"Enable verbose logging."`
+	RemoteInclude map[string]string `help:"Use a local file in place of a remote include, given as URL=PATH. May be repeated." long:"remote-include" mapsep:"none" placeholder:"URL=PATH"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	RemotePlaceholders bool `help:"Draw remote includes that can't be loaded as a placeholder node, instead of failing." long:"remote-placeholders"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+
+	Focus   string   `help:"Show only tasks matching the given patterns together with all their transitive dependencies and dependents. Accepts task names or glob patterns, separated by commas or semicolons." long:"focus"` //nolint:revive // Intentionally long line for clarity in the CLI help.
+	Exclude string   `help:"Leave out tasks matching the given patterns, along with their edges. Accepts task names or glob patterns, separated by commas or semicolons." long:"exclude"`                                      //nolint:revive // Intentionally long line for clarity in the CLI help.
+	Profile []string `help:"Produce only the named profiles from the config, instead of all of them. May be repeated." long:"profile"`                                                                                         //nolint:revive // Intentionally long line for clarity in the CLI help.
+	Verbose bool     `help:"Enable verbose logging."`
+
+	// sources records where each value of the config came from, for --export-config
+	sources config.Sources
+
+	// stdin holds the Taskfile given as "-", once read, as both the config embedded in it and
+	// its graph are loaded from it
+	stdin []byte
 }
 
-// Run executes the CLI command with the given flags.
-func (c *CLI) Run( //nolint:revive // Cognitive complexity is acceptable for a top-level command entry point.
+// Run executes the CLI command with the given flags. The Taskfiles are loaded and their graph
+// built once, then each output is produced from it: the one given by --output, or else each
+// profile from the config.
+func (c *CLI) Run(
 	flags *Flags,
 ) error {
 	ctx := context.Background()
 
-	tf, err := loader.Load(ctx, c.Taskfile)
+	profiles, err := c.selectProfiles(flags.Config)
 	if err != nil {
-		return eris.Wrap(err, "failed to load taskfile")
+		return err
 	}
 
-	flags.Log.Info(
-		"Loaded taskfile",
-		"taskfile", c.Taskfile,
-		"tasks", tf.Tasks.Len())
-
-	builder := taskgraph.New(tf)
-	builder.IncludeGlobalVars = flags.Config.IncludeGlobalVars
-	gr := builder.Build()
-
-	if c.Focus != "" {
-		var matched bool
-
-		gr, matched, err = applyFocus(gr, c.Focus)
-		if err != nil {
-			return eris.Wrap(err, "failed to apply focus filter")
-		}
-
-		if !matched {
-			flags.Log.Warn(
-				"focus pattern matched no tasks; showing full graph",
-				"focus", c.Focus)
-		}
+	gr, err := c.loadGraph(ctx, flags)
+	if err != nil {
+		return err
 	}
 
-	applyAutoColor(flags.Config, gr)
+	// Checked after loading, to include the rules embedded in included Taskfiles
+	c.warnAboutConfig(flags)
+	applyPlaceholders(flags, gr)
 
-	err = c.saveGraph(gr, flags)
+	err = applyDurations(flags, gr)
 	if err != nil {
 		return err
 	}
 
-	if c.RenderImage != "" {
-		err = c.renderImage(ctx, flags)
+	if c.Analyze != "" {
+		err = c.analyze(gr, flags, reportWriter(flags, profiles))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, profile := range profiles {
+		err = c.produce(ctx, gr, profile, flags)
 		if err != nil {
 			return err
 		}
@@ -111,8 +163,8 @@ func (c *CLI) Run( //nolint:revive // Cognitive complexity is acceptable for a t
 	return nil
 }
 
-// CreateLogger builds a slog logger configured from the CLI flags.
-func (c *CLI) CreateLogger() *slog.Logger {
+// CreateLogger builds a slog logger configured from the CLI flags, writing to w.
+func (c *CLI) CreateLogger(w io.Writer) *slog.Logger {
 	level := slog.LevelInfo
 	if c.Verbose {
 		level = slog.LevelDebug
@@ -121,29 +173,282 @@ func (c *CLI) CreateLogger() *slog.Logger {
 	opts := &console.HandlerOptions{
 		Level: level,
 	}
-	handler := console.NewHandler(os.Stderr, opts)
+	handler := console.NewHandler(w, opts)
 
 	return slog.New(handler)
 }
 
-// CreateConfig builds a Config from the optional config file and CLI flag overrides.
-func (c *CLI) CreateConfig() (*config.Config, error) {
+// CreateConfig builds a Config from the defaults, overridden in turn by any theme, any
+// x-task-graph block in the Taskfile, the config file (and any it extends), TASK_GRAPH_*
+// environment variables and CLI flags. The config file is the one given by --config, or else a
+// .task-graph.yml found alongside the Taskfile. stdin supplies a Taskfile given as "-";
+// os.Stdin is used if nil.
+func (c *CLI) CreateConfig(stdin io.Reader) (*config.Config, error) {
+	c.sources = config.Sources{}
 	cfg := config.New()
 
-	if c.Config != "" {
-		err := c.loadConfigFile(cfg)
+	// The theme replaces the defaults, so every other setting is layered on top of it
+	if name, theme, ok := c.findTheme(stdin); ok {
+		err := config.ApplyTheme(cfg, name, theme, c.sources)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	c.applyConfigOverrides(cfg)
+	cfg, err := c.layerConfig(cfg, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := cfg.LookupTheme(cfg.Theme); cfg.Theme != "" && !ok {
+		return nil, eris.Errorf(
+			"%s: unknown theme %q, must be one of %s",
+			c.sources.Lookup("theme"),
+			cfg.Theme,
+			strings.Join(cfg.ThemeNames(), ", "))
+	}
 
 	return cfg, nil
 }
 
+// findTheme returns the theme selected by the flags, the environment, the config file or the
+// Taskfile, whichever takes precedence, if it exists. Nothing is recorded and problems are
+// ignored, as both are left to layerConfig.
+func (c *CLI) findTheme(stdin io.Reader) (string, config.Theme, bool) {
+	cfg := config.New()
+
+	_ = c.loadEmbeddedConfig(cfg, stdin, nil)
+
+	if path := c.configFile(); path != "" {
+		_ = config.Load(path, cfg, nil)
+	}
+
+	_ = config.ApplyEnv(cfg, os.Environ(), nil)
+
+	if c.Theme != "" {
+		cfg.Theme = c.Theme
+	}
+
+	if cfg.Theme == "" {
+		return "", config.Theme{}, false
+	}
+
+	theme, ok := cfg.LookupTheme(cfg.Theme)
+
+	return cfg.Theme, theme, ok
+}
+
+// warnAboutConfig logs the doubtful values in the config, each with where it was set if known,
+// and any TASK_GRAPH_* environment variables that were ignored as they set nothing.
+func (c *CLI) warnAboutConfig(flags *Flags) {
+	for _, name := range config.UnknownEnv(os.Environ()) {
+		flags.Log.Warn(
+			"ignoring unknown environment variable; variables are named after a config value, such as "+
+				config.EnvName("graphviz.fontSize"),
+			"variable", "$"+name)
+	}
+
+	for _, warning := range flags.Config.Warnings() {
+		args := []any{"setting", warning.Path}
+		if source := c.sources.Lookup(warning.Path); source != config.DefaultSource {
+			args = append(args, "source", source)
+		}
+
+		flags.Log.Warn(warning.Message, args...)
+	}
+}
+
+// layerConfig overrides the values of cfg with those of the Taskfile, the config file, the
+// environment and the flags, in turn, recording the source of each value.
+func (c *CLI) layerConfig(cfg *config.Config, stdin io.Reader) (*config.Config, error) {
+	embeddedErr := c.loadEmbeddedConfig(cfg, stdin, c.sources)
+
+	var loadErr error
+	if path := c.configFile(); path != "" {
+		loadErr = config.Load(path, cfg, c.sources)
+		if loadErr != nil {
+			loadErr = eris.Wrapf(loadErr, "failed to load config file: %s", path)
+		}
+	}
+
+	envErr := config.ApplyEnv(cfg, os.Environ(), c.sources)
+
+	// Report problems with the config, the environment and the flags together
+	err := errors.Join(embeddedErr, loadErr, envErr, c.validateFlags())
+	if err != nil {
+		return nil, err
+	}
+
+	c.applyConfigOverrides(cfg, c.sources)
+
+	return cfg, nil
+}
+
+// configFile returns the path of the config file to load: the one given by --config, or else
+// one found in the directory of the first Taskfile, or "" if there is none.
+func (c *CLI) configFile() string {
+	if c.Config != "" {
+		return c.Config
+	}
+
+	path := ""
+	if len(c.Taskfiles) > 0 {
+		path = c.Taskfiles[0]
+	}
+
+	switch {
+	case path == stdio:
+		return config.Find(c.Dir)
+	case path == "" && c.Discover != "":
+		return config.Find(c.Discover)
+	}
+
+	taskfile, err := loader.Find(path)
+	if err != nil {
+		// Reported when the Taskfile is loaded
+		return ""
+	}
+
+	return config.Find(filepath.Dir(taskfile))
+}
+
+// loadEmbeddedConfig loads any config embedded in the root Taskfile into cfg, recording the
+// source of each value in sources. Only a single Taskfile is considered, from stdin if given
+// as "-"; when drawing several, the config embedded in each applies only to its own tasks, as
+// for included Taskfiles.
+func (c *CLI) loadEmbeddedConfig(cfg *config.Config, stdin io.Reader, sources config.Sources) error {
+	if len(c.Taskfiles) > 1 || c.Discover != "" {
+		return nil
+	}
+
+	path := ""
+	if len(c.Taskfiles) == 1 {
+		path = c.Taskfiles[0]
+	}
+
+	var (
+		taskfile string
+		content  []byte
+		err      error
+	)
+
+	if path == stdio {
+		taskfile = "stdin"
+		content, err = c.readStdin(stdin)
+	} else {
+		taskfile, content, err = readTaskfile(path)
+	}
+
+	if err != nil {
+		// Reported when the Taskfile is loaded
+		return nil
+	}
+
+	err = config.LoadEmbedded(taskfile, content, cfg, sources)
+	if err != nil {
+		return eris.Wrapf(err, "failed to load config embedded in taskfile: %s", taskfile)
+	}
+
+	return nil
+}
+
+// readTaskfile finds the Taskfile at path, as task does, returning its location and content.
+func readTaskfile(path string) (string, []byte, error) {
+	taskfile, err := loader.Find(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := os.ReadFile(taskfile)
+	if err != nil {
+		return "", nil, eris.Wrapf(err, "failed to read taskfile: %s", taskfile)
+	}
+
+	return taskfile, content, nil
+}
+
+// readStdin returns the Taskfile given as "-", read from stdin (or os.Stdin if nil) the first
+// time it is needed.
+func (c *CLI) readStdin(stdin io.Reader) ([]byte, error) {
+	if c.stdin != nil {
+		return c.stdin, nil
+	}
+
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read taskfile from stdin")
+	}
+
+	c.stdin = content
+
+	return content, nil
+}
+
+// validateFlags checks the patterns and colours given on the command line, returning all the
+// problems found as a *diagnostic.Error.
+func (c *CLI) validateFlags() error {
+	var problems []diagnostic.Diagnostic
+
+	check := func(flag string, err error) {
+		if err != nil {
+			problems = append(problems, diagnostic.Diagnostic{Message: flag + ": " + err.Error()})
+		}
+	}
+
+	for _, pattern := range splitPatterns(c.Highlight) {
+		check("--highlight", config.ValidatePattern(pattern))
+	}
+
+	for _, pattern := range splitPatterns(c.Focus) {
+		check("--focus", config.ValidatePattern(pattern))
+	}
+
+	for _, pattern := range splitPatterns(c.Exclude) {
+		check("--exclude", config.ValidatePattern(pattern))
+	}
+
+	if c.HighlightColor != "" {
+		// Unknown colour names are only warned about, once the config is complete
+		if err := config.ValidateColor(c.HighlightColor); !errors.Is(err, config.ErrUnknownColor) {
+			check("--highlight-color", err)
+		}
+	}
+
+	if c.Layout != "" && !slices.Contains(config.GraphvizLayouts, c.Layout) {
+		check("--layout", eris.Errorf(
+			"unsupported value %q, must be one of %s",
+			c.Layout,
+			strings.Join(config.GraphvizLayouts, ", ")))
+	}
+
+	if c.Renderer != "" && !slices.Contains(config.Renderers, c.Renderer) {
+		check("--renderer", eris.Errorf(
+			"unsupported value %q, must be one of %s",
+			c.Renderer,
+			strings.Join(config.Renderers, ", ")))
+	}
+
+	_, err := config.ParseImages(c.RenderImage)
+	check("--render-image", err)
+
+	if c.RenderTimeout != "" {
+		check("--render-timeout", config.ValidateDuration(c.RenderTimeout))
+	}
+
+	if c.DownloadTimeout != "" {
+		check("--download-timeout", config.ValidateDuration(c.DownloadTimeout))
+	}
+
+	return diagnostic.New("", nil, problems)
+}
+
 // ExportConfigToFile writes the effective configuration to the given file path.
-// The format is determined by the file extension (.yaml, .yml, or .json).
+// The format is determined by the file extension (.yaml, .yml, or .json). YAML exports show
+// where each value came from in a comment on its line.
 func (c *CLI) ExportConfigToFile(cfg *config.Config) error {
 	if c.ExportConfig == "" {
 		return nil
@@ -158,9 +463,9 @@ func (c *CLI) ExportConfigToFile(cfg *config.Config) error {
 
 	switch ext {
 	case ".yaml", ".yml":
-		data, err = yaml.Marshal(cfg)
+		data, err = config.MarshalYAML(cfg, c.sources)
 		if err != nil {
-			return eris.Wrap(err, "failed to marshal config as YAML")
+			return err
 		}
 
 	case ".json":
@@ -181,121 +486,293 @@ func (c *CLI) ExportConfigToFile(cfg *config.Config) error {
 	return nil
 }
 
+// saveGraph writes the graph to the output file, or to stdout if the output is "-".
 func (c *CLI) saveGraph(
+	gr *graph.Graph,
+	output string,
+	flags *Flags,
+) error {
+	if output == stdio {
+		return c.writeGraph(flags.stdout(), gr, flags)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return eris.Wrapf(err, "failed to create file: %s", output)
+	}
+
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+
+	err = c.writeGraph(bw, gr, flags)
+	if err != nil {
+		return err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return eris.Wrapf(err, "failed to write file: %s", output)
+	}
+
+	flags.Log.Info(
+		"Saved graph",
+		"output", output,
+	)
+
+	return nil
+}
+
+// writeGraph writes the graph to the given writer, in the graph type of the config, which
+// profileConfig has already resolved.
+func (*CLI) writeGraph(
+	w io.Writer,
 	gr *graph.Graph,
 	flags *Flags,
 ) error {
-	graphType := c.resolveGraphType(flags)
+	graphType := flags.Config.GraphType
 
 	var err error
 
 	switch graphType {
 	case graphTypeDot:
-		err = graphviz.SaveTo(c.Output, gr, flags.Config)
+		err = graphviz.WriteTo(w, gr, flags.Config)
 	case graphTypeMermaid:
-		err = mermaid.SaveTo(c.Output, gr, flags.Config)
+		err = writeMermaid(w, gr, flags)
+	case graphTypeGantt:
+		if flags.Config.GanttTask == "" {
+			return eris.New("a gantt graph requires a task to simulate; use --gantt-task")
+		}
+
+		err = writeGantt(w, gr, flags)
 	default:
-		return eris.Errorf("unsupported graph type: %q, must be dot or mermaid", graphType)
+		return eris.Errorf("unsupported graph type: %q, must be dot, mermaid or gantt", graphType)
 	}
 
 	if err != nil {
 		return eris.Wrap(err, "failed to save graph")
 	}
 
-	flags.Log.Info(
-		"Saved graph",
-		"output", c.Output,
-	)
-
 	return nil
 }
 
-func (c *CLI) resolveGraphType(flags *Flags) string {
-	graphType := c.GraphType
-	if graphType == "" {
-		graphType = flags.Config.GraphType
+// resolveGraphType returns the type of graph to generate for the profile: its own graphType,
+// else that of the config (which --graph-type has already overridden), else dot.
+func resolveGraphType(cfg *config.Config, profile config.Profile) string {
+	if profile.GraphType != "" {
+		return profile.GraphType
 	}
 
-	if graphType == "" {
-		graphType = graphTypeDot
+	if cfg.GraphType != "" {
+		return cfg.GraphType
 	}
 
-	return graphType
+	return graphTypeDot
 }
 
-func (c *CLI) renderImage(ctx context.Context, flags *Flags) error {
-	dotPath := ""
-	if flags.Config != nil {
-		dotPath = flags.Config.DotPath
+// applyConfigOverrides applies CLI flag overrides to the configuration, recording each flag
+// as the source of the value it sets.
+func (c *CLI) applyConfigOverrides(cfg *config.Config, sources config.Sources) {
+	if c.GroupByNamespace {
+		cfg.GroupByNamespace = true
+		sources.Set("groupByNamespace", "--group-by-namespace")
 	}
 
-	dotExe, err := dot.FindExecutable(dotPath)
-	if err != nil {
-		return eris.Wrap(err, "failed to find dot executable")
+	if c.GraphType != "" {
+		cfg.GraphType = c.GraphType
+		sources.Set("graphType", "--graph-type")
 	}
 
-	ext := filepath.Ext(c.Output)
-	imageFile := strings.TrimSuffix(c.Output, ext) + "." + c.RenderImage
+	if c.GanttTask != "" {
+		cfg.GanttTask = c.GanttTask
+		sources.Set("ganttTask", "--gantt-task")
+	}
 
-	err = dot.RenderImage(ctx, dotExe, c.Output, imageFile, c.RenderImage)
-	if err != nil {
-		return eris.Wrap(err, "failed to render image")
+	if c.IncludeGlobalVars {
+		cfg.IncludeGlobalVars = true
+		sources.Set("includeGlobalVars", "--include-global-vars")
 	}
 
-	flags.Log.Info(
-		"Rendered image",
-		"output", imageFile,
-	)
+	if c.Durations != "" {
+		cfg.Durations = c.Durations
+		sources.Set("durations", "--durations")
+	}
 
-	return nil
+	if c.Legend {
+		cfg.Legend = true
+		sources.Set("legend", "--legend")
+	}
+
+	if c.PlatformSafe {
+		mermaidConfig(cfg).PlatformSafe = true
+		sources.Set("mermaid.platformSafe", "--platform-safe")
+	}
+
+	c.applyLayoutOverrides(cfg, sources)
+	c.applyColorOverrides(cfg, sources)
+	c.applyRemoteOverrides(cfg, sources)
+
+	if c.Highlight != "" {
+		c.applyHighlightOverrides(cfg, sources)
+	}
 }
 
-// applyConfigOverrides applies CLI flag overrides to the configuration.
-func (c *CLI) applyConfigOverrides(cfg *config.Config) {
-	if c.GroupByNamespace {
-		cfg.GroupByNamespace = true
+// applyLayoutOverrides applies CLI flag overrides for how the graph is laid out and rendered.
+func (c *CLI) applyLayoutOverrides(cfg *config.Config, sources config.Sources) {
+	if c.RankByWave {
+		graphvizConfig(cfg).RankByWave = true
+		sources.Set("graphviz.rankByWave", "--rank-by-wave")
+	}
+
+	if c.Layout != "" {
+		graphvizConfig(cfg).Layout = c.Layout
+		sources.Set("graphviz.layout", "--layout")
+	}
+
+	if c.Renderer != "" {
+		cfg.Renderer = c.Renderer
+		sources.Set("renderer", "--renderer")
+	}
+
+	if c.RenderTimeout != "" {
+		cfg.RenderTimeout = c.RenderTimeout
+		sources.Set("renderTimeout", "--render-timeout")
+	}
+}
+
+// graphvizConfig returns the Graphviz settings of cfg, creating them if needed.
+func graphvizConfig(cfg *config.Config) *config.Graphviz {
+	if cfg.Graphviz == nil {
+		cfg.Graphviz = &config.Graphviz{}
+	}
+
+	return cfg.Graphviz
+}
+
+// mermaidConfig returns the Mermaid settings of cfg, creating them if needed.
+func mermaidConfig(cfg *config.Config) *config.Mermaid {
+	if cfg.Mermaid == nil {
+		cfg.Mermaid = &config.Mermaid{}
+	}
+
+	return cfg.Mermaid
+}
+
+// applyColorOverrides applies CLI flag overrides for the theme and for colouring nodes.
+func (c *CLI) applyColorOverrides(cfg *config.Config, sources config.Sources) {
+	if c.Theme != "" {
+		cfg.Theme = c.Theme
+		sources.Set("theme", "--theme")
 	}
 
 	if c.AutoColor {
 		cfg.AutoColor = true
+		sources.Set("autoColor", "--auto-color")
 	}
 
-	if c.ColorblindMode {
-		cfg.ColorblindMode = true
+	if c.AutoColorMode != "" {
+		cfg.AutoColorMode = c.AutoColorMode
+		sources.Set("autoColorMode", "--auto-color-mode")
 	}
 
-	if c.GraphType != "" {
-		cfg.GraphType = c.GraphType
+	if c.ColorblindMode {
+		cfg.ColorblindMode = true
+		sources.Set("colorblindMode", "--colorblind-mode")
 	}
 
 	if c.HighlightColor != "" {
 		cfg.HighlightColor = c.HighlightColor
+		sources.Set("highlightColor", "--highlight-color")
 	}
 
-	if c.IncludeGlobalVars {
-		cfg.IncludeGlobalVars = true
+	if c.CriticalPath {
+		cfg.CriticalPath = true
+		sources.Set("criticalPath", "--critical-path")
 	}
+}
 
-	if c.Highlight != "" {
-		c.applyHighlightOverrides(cfg)
+// applyRemoteOverrides applies CLI flag overrides for resolving remote includes.
+func (c *CLI) applyRemoteOverrides(cfg *config.Config, sources config.Sources) {
+	if !c.hasRemoteOverrides() {
+		return
+	}
+
+	if cfg.Remote == nil {
+		cfg.Remote = &config.Remote{}
+	}
+
+	if c.Download {
+		cfg.Remote.Download = true
+		sources.Set("remote.download", "--download")
+	}
+
+	if c.DownloadTimeout != "" {
+		cfg.Remote.Timeout = c.DownloadTimeout
+		sources.Set("remote.timeout", "--download-timeout")
+	}
+
+	if len(c.TrustedHost) > 0 {
+		cfg.Remote.TrustedHosts = append(cfg.Remote.TrustedHosts, c.TrustedHost...)
+		sources.Set("remote.trustedHosts", "--trusted-host")
+	}
+
+	if c.Offline {
+		cfg.Remote.Offline = true
+		sources.Set("remote.offline", "--offline")
+	}
+
+	if c.CacheDir != "" {
+		cfg.Remote.CacheDir = c.CacheDir
+		sources.Set("remote.cacheDir", "--cache-dir")
 	}
+
+	if len(c.RemoteInclude) > 0 && cfg.Remote.Includes == nil {
+		cfg.Remote.Includes = make(map[string]string, len(c.RemoteInclude))
+	}
+
+	for url, path := range c.RemoteInclude {
+		cfg.Remote.Includes[url] = path
+		sources.Set("remote.includes."+url, "--remote-include")
+	}
+
+	if c.RemotePlaceholders {
+		cfg.Remote.Placeholders = true
+		sources.Set("remote.placeholders", "--remote-placeholders")
+	}
+}
+
+// hasRemoteOverrides returns true if any flag for resolving remote includes is set.
+func (c *CLI) hasRemoteOverrides() bool {
+	return c.Download || c.DownloadTimeout != "" || len(c.TrustedHost) > 0 || c.Offline || c.CacheDir != "" ||
+		len(c.RemoteInclude) > 0 || c.RemotePlaceholders
 }
 
 // applyHighlightOverrides parses the --highlight flag and appends matching style rules.
-func (c *CLI) applyHighlightOverrides(cfg *config.Config) {
-	color := "yellow"
-	if cfg.HighlightColor != "" {
-		color = cfg.HighlightColor
+func (c *CLI) applyHighlightOverrides(cfg *config.Config, sources config.Sources) {
+	for _, rule := range highlightRules(splitPatterns(c.Highlight), cfg.HighlightColor) {
+		sources.Set(fmt.Sprintf("nodeStyleRules[%d]", len(cfg.NodeStyleRules)), "--highlight")
+		cfg.NodeStyleRules = append(cfg.NodeStyleRules, rule)
 	}
+}
 
-	for _, pattern := range splitPatterns(c.Highlight) {
-		rule := config.NodeStyleRule{
+// highlightRules returns style rules filling the tasks matching each pattern with color, or
+// yellow if no color is given.
+func highlightRules(patterns []string, color string) []config.NodeStyleRule {
+	if color == "" {
+		color = "yellow"
+	}
+
+	result := make([]config.NodeStyleRule, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		result = append(result, config.NodeStyleRule{
 			Match:     pattern,
 			FillColor: color,
 			Style:     "filled",
-		}
-		cfg.NodeStyleRules = append(cfg.NodeStyleRules, rule)
+		})
 	}
+
+	return result
 }
 
 // splitPatterns splits a comma-or-semicolon-separated string of patterns into
@@ -319,53 +796,170 @@ func splitPatterns(s string) []string {
 	return result
 }
 
-// applyAutoColor generates auto-color rules from the graph's namespaces and
-// prepends them to cfg.NodeStyleRules so that user-defined rules take precedence.
-func applyAutoColor(cfg *config.Config, gr *graph.Graph) {
+// applyAutoColor generates auto-color rules from the graph, either by namespace or by
+// duration, and prepends them to cfg.NodeStyleRules so that user-defined rules take precedence.
+func applyAutoColor(cfg *config.Config, gr *graph.Graph) error {
 	if !cfg.AutoColor {
-		return
+		return nil
 	}
 
 	var autoRules []config.NodeStyleRule
-	if cfg.ColorblindMode {
-		autoRules = autocolor.GenerateRulesWithPalette(gr, autocolor.ColorblindPalette)
-	} else {
-		autoRules = autocolor.GenerateRules(gr)
+
+	switch cfg.AutoColorMode {
+	case "", config.AutoColorModeNamespace, config.AutoColorModeHierarchy:
+		autoRules = autocolor.GenerateRulesWithOptions(gr, autoColorOptions(cfg))
+	case config.AutoColorModeDuration:
+		autoRules = autocolor.GenerateHeatmapRules(gr)
+	default:
+		return eris.Errorf(
+			"unsupported auto-color mode: %q, must be %s, %s or %s",
+			cfg.AutoColorMode,
+			config.AutoColorModeNamespace,
+			config.AutoColorModeHierarchy,
+			config.AutoColorModeDuration)
 	}
 
 	cfg.NodeStyleRules = append(autoRules, cfg.NodeStyleRules...)
+
+	return nil
+}
+
+// autoColorOptions returns the options for coloring by namespace: the configured palette, else
+// the colorblind palette if selected, and any pinned colors.
+func autoColorOptions(cfg *config.Config) autocolor.Options {
+	opts := autocolor.Options{
+		Palette:   cfg.AutoColorPalette,
+		Pins:      cfg.AutoColorPins,
+		Hierarchy: cfg.AutoColorMode == config.AutoColorModeHierarchy,
+	}
+
+	if len(opts.Palette) == 0 && cfg.ColorblindMode {
+		opts.Palette = autocolor.ColorblindPalette
+	}
+
+	return opts
 }
 
-func (c *CLI) loadConfigFile(cfg *config.Config) error {
-	raw, err := os.ReadFile(c.Config)
+// analyze reports on how the target task would execute, writing the report to w, and records
+// the execution wave of each task it needs so that waves can be shown when rendering.
+func (c *CLI) analyze(gr *graph.Graph, flags *Flags, w io.Writer) error {
+	analysis, err := schedule.Analyze(gr, c.Analyze)
 	if err != nil {
-		return eris.Wrapf(err, "failed to read config file: %s", c.Config)
+		return eris.Wrap(err, "failed to analyze execution")
 	}
 
-	ext := strings.ToLower(filepath.Ext(c.Config))
+	err = analysis.WriteReport(w)
+	if err != nil {
+		return err
+	}
 
-	switch ext {
-	case ".yaml", ".yml":
-		err = yaml.Unmarshal(raw, cfg)
-	case ".json":
-		err = json.Unmarshal(raw, cfg)
-	default:
-		// Attempt YAML first, then JSON, for unknown extensions.
-		yamlErr := yaml.Unmarshal(raw, cfg)
-		if yamlErr == nil {
-			return nil
+	analysis.Apply()
+
+	cfg := flags.Config
+	if cfg.Graphviz != nil && cfg.Graphviz.RankByWave && cfg.GroupByNamespace {
+		flags.Log.Warn("execution waves cannot be shown as ranks when grouping by namespace")
+	}
+
+	return nil
+}
+
+// reportWriter returns the writer for reports written for the user: stdout, unless a graph is
+// being written there, in which case stderr keeps the graph output clean.
+func reportWriter(flags *Flags, profiles []namedProfile) io.Writer {
+	for _, profile := range profiles {
+		if profile.Output == stdio {
+			return flags.stderr()
 		}
+	}
+
+	return flags.stdout()
+}
 
-		err = json.Unmarshal(raw, cfg)
+// applyDurations loads measured task durations, if configured, and applies them to the graph.
+func applyDurations(flags *Flags, gr *graph.Graph) error {
+	if flags.Config.Durations == "" {
+		return nil
 	}
 
+	measured, err := durations.Load(flags.Config.Durations)
 	if err != nil {
-		return eris.Wrapf(err, "failed to parse config file: %s", c.Config)
+		return eris.Wrap(err, "failed to load durations")
+	}
+
+	unmatched := durations.Apply(gr, measured)
+
+	flags.Log.Info(
+		"Loaded durations",
+		"file", flags.Config.Durations,
+		"tasks", len(measured)-len(unmatched))
+
+	if len(unmatched) > 0 {
+		flags.Log.Warn(
+			"durations file contains tasks not found in the graph",
+			"tasks", strings.Join(unmatched, ", "))
 	}
 
 	return nil
 }
 
+// applyCriticalPath finds the chain of tasks with the longest total duration and appends
+// style rules highlighting its nodes and the edges between them, so that it stands out over
+// other colouring. The chain is found by the same schedule as --analyze and Gantt charts, in
+// which called tasks run one after another.
+func applyCriticalPath(flags *Flags, gr *graph.Graph) {
+	cfg := flags.Config
+	if !cfg.CriticalPath {
+		return
+	}
+
+	path := schedule.CriticalPath(gr)
+	if len(path) == 0 {
+		flags.Log.Warn("critical path requires task durations; use --durations to supply them")
+
+		return
+	}
+
+	color := "red"
+	if cfg.CriticalPathColor != "" {
+		color = cfg.CriticalPathColor
+	}
+
+	onPath := make(map[*graph.Node]bool, len(path))
+
+	for i, node := range path {
+		rule := config.NodeStyleRule{
+			Match: namespace.QuoteMatchPattern(node.ID()),
+			Color: color,
+		}
+
+		if i == 0 {
+			// Name the first rule so that the critical path is shown in any legend
+			rule.Name = "critical path"
+		}
+
+		cfg.NodeStyleRules = append(cfg.NodeStyleRules, rule)
+		onPath[node] = true
+	}
+
+	// Consecutive tasks need not be joined by an edge, as a called task waits for the one
+	// called before it, so every dependency or call between tasks on the path is highlighted
+	for _, node := range path {
+		for _, edge := range node.Edges() {
+			class := edge.Class()
+			if (class != graph.EdgeClassDep && class != graph.EdgeClassCall) || !onPath[edge.To()] {
+				continue
+			}
+
+			cfg.EdgeStyleRules = append(cfg.EdgeStyleRules, config.EdgeStyleRule{
+				From:  namespace.QuoteMatchPattern(node.ID()),
+				To:    namespace.QuoteMatchPattern(edge.To().ID()),
+				Color: color,
+				Width: 3,
+			})
+		}
+	}
+}
+
 // applyFocus returns a new graph containing only the nodes that match any of the
 // given comma-or-semicolon-separated patterns (glob-style), together with all
 // nodes transitively reachable from them in either direction.
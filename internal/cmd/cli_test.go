@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 
@@ -22,7 +24,7 @@ func TestCreateConfig_DefaultsWhenNoFileProvided(t *testing.T) {
 
 	cli := CLI{}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg).To(Equal(config.New()))
@@ -36,7 +38,7 @@ func TestCreateConfig_LoadsYAMLConfig(t *testing.T) {
 		Config: filepath.Join("testdata", "config.yaml"),
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.Graphviz.Font).To(Equal("Fira Code"))
@@ -53,7 +55,7 @@ func TestCreateConfig_LoadsJSONConfig(t *testing.T) {
 		Config: filepath.Join("testdata", "config.json"),
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.Graphviz.Font).To(Equal("JetBrains Mono"))
@@ -68,7 +70,7 @@ func TestCreateConfig_MissingFileReturnsError(t *testing.T) {
 
 	cli := CLI{Config: "does-not-exist.yml"}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(cfg).To(BeNil())
 	g.Expect(err).To(MatchError(ContainSubstring("failed to read config file")))
@@ -80,7 +82,7 @@ func TestCreateConfig_GroupByNamespaceFlagSetsConfig(t *testing.T) {
 
 	cli := CLI{GroupByNamespace: true}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.GroupByNamespace).To(BeTrue())
@@ -92,7 +94,7 @@ func TestCreateConfig_AutoColorFlagSetsConfig(t *testing.T) {
 
 	cli := CLI{AutoColor: true}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.AutoColor).To(BeTrue())
@@ -107,7 +109,7 @@ func TestCreateConfig_GroupByNamespaceFlagOverridesConfigFile(t *testing.T) {
 		GroupByNamespace: true,
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.GroupByNamespace).To(BeTrue())
@@ -121,7 +123,7 @@ func TestCreateConfig_HighlightFlagAddsSingleStyleRule(t *testing.T) {
 		Highlight: "build",
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
@@ -138,7 +140,7 @@ func TestCreateConfig_HighlightFlagWithCommaSeparatorAddsMultipleStyleRules(t *t
 
 	cli := CLI{Highlight: "build,doc"}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(2))
@@ -152,7 +154,7 @@ func TestCreateConfig_HighlightFlagWithSemicolonSeparatorAddsMultipleStyleRules(
 
 	cli := CLI{Highlight: "build;doc"}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(2))
@@ -169,7 +171,7 @@ func TestCreateConfig_HighlightFlagUsesConfiguredHighlightColor(t *testing.T) {
 		Highlight: "build",
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
@@ -182,7 +184,7 @@ func TestCreateConfig_HighlightFlagWithGlobPattern(t *testing.T) {
 
 	cli := CLI{Highlight: "cmd:*"}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
@@ -200,7 +202,7 @@ func TestCreateConfig_HighlightColorFlagSetsHighlightColor(t *testing.T) {
 	}
 
 	// Act
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	// Assert: HighlightColor is propagated from the CLI flag
 	g.Expect(err).NotTo(HaveOccurred())
@@ -219,7 +221,7 @@ func TestCreateConfig_HighlightColorFlagOverridesConfigFile(t *testing.T) {
 	}
 
 	// Act
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	// Assert: CLI flag wins over config file
 	g.Expect(err).NotTo(HaveOccurred())
@@ -237,7 +239,7 @@ func TestCreateConfig_HighlightColorFlagAloneDoesNotAddStyleRules(t *testing.T)
 	cli := CLI{HighlightColor: "orange"}
 
 	// Act
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	// Assert: colour is stored but no style rules are added
 	g.Expect(err).NotTo(HaveOccurred())
@@ -251,7 +253,7 @@ func TestCreateConfig_IncludeGlobalVarsFlagSetsConfig(t *testing.T) {
 
 	cli := CLI{IncludeGlobalVars: true}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.IncludeGlobalVars).To(BeTrue())
@@ -263,12 +265,48 @@ func TestCreateConfig_DefaultIncludeGlobalVarsIsFalse(t *testing.T) {
 
 	cli := CLI{}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.IncludeGlobalVars).To(BeFalse())
 }
 
+func TestCreateConfig_LegendFlagSetsConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Legend: true}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Legend).To(BeTrue())
+}
+
+func TestCreateConfig_DurationFlagsSetConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{
+		Durations:     "durations.json",
+		CriticalPath:  true,
+		AutoColorMode: config.AutoColorModeDuration,
+	}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Durations).To(Equal("durations.json"))
+	g.Expect(cfg.CriticalPath).To(BeTrue())
+	g.Expect(cfg.AutoColorMode).To(Equal(config.AutoColorModeDuration))
+}
+
 // TestApplyAutoColor
 
 func TestApplyAutoColor_WhenDisabled_LeavesRulesUnchanged(t *testing.T) {
@@ -286,7 +324,8 @@ func TestApplyAutoColor_WhenDisabled_LeavesRulesUnchanged(t *testing.T) {
 	gr.AddNode("cmd:build")
 
 	// Act
-	applyAutoColor(cfg, gr)
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
@@ -307,7 +346,8 @@ func TestApplyAutoColor_WithNamespacedGraph_GeneratesRulesForNamespaces(t *testi
 	gr.AddNode("controllers:deploy")
 
 	// Act
-	applyAutoColor(cfg, gr)
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert
 	matches := make([]string, len(cfg.NodeStyleRules))
@@ -334,7 +374,8 @@ func TestApplyAutoColor_AutoRulesPrependedBeforeUserRules(t *testing.T) {
 	gr.AddNode("cmd:test")
 
 	// Act
-	applyAutoColor(cfg, gr)
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert: auto-generated rules come first, user-defined rule comes last
 	g.Expect(cfg.NodeStyleRules).To(HaveLen(3))
@@ -350,6 +391,40 @@ func TestApplyAutoColor_AutoRulesPrependedBeforeUserRules(t *testing.T) {
 	g.Expect(userRule.FillColor).To(Equal("gold"))
 }
 
+func TestApplyAutoColor_HierarchyMode_UsesPaletteAndPins(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.AutoColor = true
+	cfg.AutoColorMode = config.AutoColorModeHierarchy
+	cfg.ColorblindMode = true
+	cfg.AutoColorPalette = []string{"#1f78b4"}
+	cfg.AutoColorPins = map[string]string{"docs": "navy"}
+
+	gr := graph.New()
+	gr.AddNode("build:docker:amd64")
+	gr.AddNode("docs:site")
+
+	// Act
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Assert: the configured palette wins over the colorblind one, and nested namespaces
+	// are tinted rather than given their own palette colour
+	fills := make(map[string]string, len(cfg.NodeStyleRules))
+	for _, r := range cfg.NodeStyleRules {
+		fills[r.Match] = r.FillColor
+		g.Expect(r.FontColor).To(BeElementOf("black", "white"))
+	}
+
+	g.Expect(fills).To(HaveKeyWithValue("build", "#1f78b4"))
+	g.Expect(fills).To(HaveKeyWithValue("docs", "navy"))
+	g.Expect(fills).To(HaveKey("build:docker"))
+	g.Expect(fills["build:docker"]).NotTo(Equal("#1f78b4"))
+}
+
 // TestExportConfigToFile
 
 func TestExportConfigToFile_NoExportPathDoesNothing(t *testing.T) {
@@ -466,7 +541,7 @@ func TestCreateConfig_GraphTypeFlagSetsConfig(t *testing.T) {
 
 	cli := CLI{GraphType: graphTypeMermaid}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.GraphType).To(Equal(graphTypeMermaid))
@@ -480,7 +555,7 @@ func TestCreateConfig_ColorblindModeFlagSetsConfig(t *testing.T) {
 
 	cli := CLI{ColorblindMode: true}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.ColorblindMode).To(BeTrue())
@@ -496,7 +571,7 @@ func TestLoadConfigFile_UnknownExtensionFallsBackToYAML(t *testing.T) {
 		Config: filepath.Join("testdata", "config.conf"),
 	}
 
-	cfg, err := cli.CreateConfig()
+	cfg, err := cli.CreateConfig(nil)
 
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(cfg.Graphviz.Font).To(Equal("Courier New"))
@@ -509,39 +584,40 @@ func TestResolveGraphType_DefaultsToDot(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	cli := CLI{}
-	flags := &Flags{Config: config.New()}
-
-	result := cli.resolveGraphType(flags)
+	// Act
+	result := resolveGraphType(config.New(), config.Profile{})
 
+	// Assert
 	g.Expect(result).To(Equal(graphTypeDot))
 }
 
-func TestResolveGraphType_CLIFlagTakesPrecedence(t *testing.T) {
+func TestResolveGraphType_ProfileTakesPrecedence(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	cli := CLI{GraphType: graphTypeMermaid}
+	// Arrange
 	cfg := config.New()
 	cfg.GraphType = graphTypeDot
-	flags := &Flags{Config: cfg}
 
-	result := cli.resolveGraphType(flags)
+	// Act
+	result := resolveGraphType(cfg, config.Profile{GraphType: graphTypeMermaid})
 
+	// Assert
 	g.Expect(result).To(Equal(graphTypeMermaid))
 }
 
-func TestResolveGraphType_FallsBackToConfigWhenFlagEmpty(t *testing.T) {
+func TestResolveGraphType_FallsBackToConfigWhenProfileEmpty(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	cli := CLI{}
+	// Arrange
 	cfg := config.New()
 	cfg.GraphType = graphTypeMermaid
-	flags := &Flags{Config: cfg}
 
-	result := cli.resolveGraphType(flags)
+	// Act
+	result := resolveGraphType(cfg, config.Profile{})
 
+	// Assert
 	g.Expect(result).To(Equal(graphTypeMermaid))
 }
 
@@ -566,9 +642,9 @@ func TestRun_FocusMatchesNoTasks_LogsWarning(t *testing.T) {
 	}
 
 	cli := CLI{
-		Taskfile: taskfile,
-		Focus:    "nonexistent-pattern-xyz",
-		Output:   focusedOutput,
+		Taskfiles: []string{taskfile},
+		Focus:     "nonexistent-pattern-xyz",
+		Output:    focusedOutput,
 	}
 
 	err := cli.Run(flags)
@@ -579,8 +655,8 @@ func TestRun_FocusMatchesNoTasks_LogsWarning(t *testing.T) {
 	)
 
 	expectedCLI := CLI{
-		Taskfile: taskfile,
-		Output:   fullOutput,
+		Taskfiles: []string{taskfile},
+		Output:    fullOutput,
 	}
 
 	err = expectedCLI.Run(&Flags{
@@ -602,6 +678,151 @@ func TestRun_FocusMatchesNoTasks_LogsWarning(t *testing.T) {
 	g.Expect(focusedGraph).To(Equal(fullGraph))
 }
 
+func TestRun_Analyze_WritesReportAndAssignsWaves(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	taskfile := filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")
+	output := filepath.Join(t.TempDir(), "graph.dot")
+
+	var report bytes.Buffer
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &report,
+	}
+
+	cli := CLI{
+		Taskfiles:  []string{taskfile},
+		Output:     output,
+		Analyze:    "tidy",
+		RankByWave: true,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	flags.Config = cfg
+
+	// Act
+	err = cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(report.String()).To(ContainSubstring(`Execution analysis for "tidy"`))
+	g.Expect(report.String()).To(ContainSubstring("Critical path"))
+
+	dot, err := os.ReadFile(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(dot)).To(ContainSubstring("rank=same"))
+}
+
+func TestRun_AnalyzeWithGraphOnStdout_WritesReportToStderr(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var stdout, stderr bytes.Buffer
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:    stdio,
+		Analyze:   "tidy",
+	}
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(stderr.String()).To(ContainSubstring(`Execution analysis for "tidy"`))
+	g.Expect(stdout.String()).To(HavePrefix("digraph"))
+	g.Expect(stdout.String()).NotTo(ContainSubstring("Execution analysis"))
+}
+
+func TestRun_AnalyzeUnknownTask_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &bytes.Buffer{},
+	}
+
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:    filepath.Join(t.TempDir(), "graph.dot"),
+		Analyze:   "no-such-task",
+	}
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("no-such-task")))
+}
+
+func TestRun_GanttWithoutTask_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:    filepath.Join(t.TempDir(), "gantt.mmd"),
+		GraphType: graphTypeGantt,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	err = cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("--gantt-task")))
+}
+
+func TestRun_Gantt_WritesGanttChart(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	output := filepath.Join(t.TempDir(), "gantt.mmd")
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:    output,
+		GraphType: graphTypeGantt,
+		GanttTask: "tidy",
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	err = cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(HavePrefix("gantt\n  title tidy\n"))
+}
+
 // TestApplyFocus
 
 func TestApplyFocus_NoMatchingPatterns_ReturnsSameGraph(t *testing.T) {
@@ -751,7 +972,8 @@ func TestApplyAutoColor_ColorblindMode_UsesOkabeItoPalette(t *testing.T) {
 	gr.AddNode("cmd:test")
 
 	// Act
-	applyAutoColor(cfg, gr)
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert: rules are generated and use Okabe-Ito hex colors, not the default named colors
 	g.Expect(cfg.NodeStyleRules).NotTo(BeEmpty())
@@ -778,7 +1000,8 @@ func TestApplyAutoColor_ColorblindModeDisabled_UsesDefaultPalette(t *testing.T)
 	gr.AddNode("cmd:build")
 
 	// Act
-	applyAutoColor(cfg, gr)
+	err := applyAutoColor(cfg, gr)
+	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert: default palette uses named CSS colors, not hex strings
 	g.Expect(cfg.NodeStyleRules).NotTo(BeEmpty())
@@ -863,3 +1086,677 @@ func TestSplitPatterns_GlobPattern_PreservesWildcards(t *testing.T) {
 	// Assert
 	g.Expect(result).To(ConsistOf("cmd:*", "api:?"))
 }
+
+func TestApplyAutoColor_DurationMode_GeneratesHeatmapRules(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.AutoColor = true
+	cfg.AutoColorMode = config.AutoColorModeDuration
+
+	gr := graph.New()
+	gr.AddNode("cmd:build").Duration = 10 * time.Second
+	gr.AddNode("cmd:test").Duration = time.Second
+
+	// Act
+	err := applyAutoColor(cfg, gr)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.NodeStyleRules).To(HaveLen(2))
+	g.Expect(cfg.NodeStyleRules[0].Match).To(Equal("cmd:build"))
+	g.Expect(cfg.NodeStyleRules[0].FillColor).To(Equal("#bd0026"))
+	g.Expect(cfg.NodeStyleRules[1].Match).To(Equal("cmd:test"))
+	g.Expect(cfg.NodeStyleRules[1].FillColor).To(Equal("#ffffb2"))
+}
+
+func TestApplyAutoColor_UnknownMode_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.AutoColor = true
+	cfg.AutoColorMode = "rainbow"
+
+	// Act
+	err := applyAutoColor(cfg, graph.New())
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("rainbow")))
+}
+
+func TestApplyCriticalPath_HighlightsLongestChain(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.CriticalPath = true
+	flags := &Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)}
+
+	gr := graph.New()
+	build := gr.AddNode("build")
+	compile := gr.AddNode("compile")
+	lint := gr.AddNode("lint")
+	build.AddEdge(compile).SetClass(graph.EdgeClassDep)
+	build.AddEdge(lint).SetClass(graph.EdgeClassDep)
+	build.Duration = time.Second
+	compile.Duration = 5 * time.Second
+	lint.Duration = 2 * time.Second
+
+	// Act
+	applyCriticalPath(flags, gr)
+
+	// Assert
+	g.Expect(cfg.NodeStyleRules).To(HaveLen(2))
+	g.Expect(cfg.NodeStyleRules[0].Match).To(Equal("compile"))
+	g.Expect(cfg.NodeStyleRules[0].Name).To(Equal("critical path"))
+	g.Expect(cfg.NodeStyleRules[1].Match).To(Equal("build"))
+	g.Expect(cfg.EdgeStyleRules).To(ConsistOf(config.EdgeStyleRule{
+		From:  "build",
+		To:    "compile",
+		Color: "red",
+		Width: 3,
+	}))
+}
+
+func TestApplyCriticalPath_TaskCallingTwoSlowTasks_HighlightsCallsInTurn(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.CriticalPath = true
+	flags := &Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)}
+
+	// Calls run one after another, so together they outlast the slower dependency
+	gr := graph.New()
+	release := gr.AddNode("release")
+	lint := gr.AddNode("lint")
+	pkg := gr.AddNode("package")
+	publish := gr.AddNode("publish")
+	release.AddEdge(lint).SetClass(graph.EdgeClassDep)
+	release.AddEdge(pkg).SetClass(graph.EdgeClassCall)
+	release.AddEdge(publish).SetClass(graph.EdgeClassCall)
+	release.Duration = time.Second
+	lint.Duration = 50 * time.Second
+	pkg.Duration = 30 * time.Second
+	publish.Duration = 30 * time.Second
+
+	// Act
+	applyCriticalPath(flags, gr)
+
+	// Assert
+	matches := make([]string, 0, len(cfg.NodeStyleRules))
+	for _, rule := range cfg.NodeStyleRules {
+		matches = append(matches, rule.Match)
+	}
+
+	g.Expect(matches).To(Equal([]string{"lint", "package", "publish", "release"}))
+	g.Expect(cfg.EdgeStyleRules).To(HaveLen(3))
+}
+
+func TestApplyCriticalPath_WithoutDurations_LogsWarning(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	cfg := config.New()
+	cfg.CriticalPath = true
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+
+	gr := graph.New()
+	gr.AddNode("build")
+
+	// Act
+	applyCriticalPath(flags, gr)
+
+	// Assert
+	g.Expect(cfg.NodeStyleRules).To(BeEmpty())
+	g.Expect(buf.String()).To(ContainSubstring("critical path requires task durations"))
+}
+
+func TestCreateConfig_InvalidFlags_ReportsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{
+		Highlight:      "build,test[",
+		HighlightColor: "#bluish",
+	}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(cfg).To(BeNil())
+	g.Expect(err).To(MatchError(ContainSubstring(`--highlight: failed to compile pattern "test["`)))
+	g.Expect(err).To(MatchError(ContainSubstring(`--highlight-color: invalid colour "#bluish"`)))
+}
+
+func TestRun_UnknownColourName_WarnsAndDraws(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{
+		Taskfiles:      []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:         "-",
+		Highlight:      "tidy",
+		HighlightColor: "bluish",
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var stdout, logs bytes.Buffer
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.NewTextHandler(&logs, nil)),
+		Stdout: &stdout,
+	}
+
+	// Act
+	err = cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(ContainSubstring("bluish"))
+	g.Expect(logs.String()).To(ContainSubstring(`unknown colour \"bluish\"`))
+	g.Expect(logs.String()).To(ContainSubstring("source=--highlight-color"))
+}
+
+func TestCreateConfig_FindsConfigAlongsideTaskfile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte("version: '3'\n"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, ".task-graph.yml"), []byte("legend: true\n"), 0o600)).To(Succeed())
+
+	cli := CLI{Taskfiles: []string{dir}}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Legend).To(BeTrue())
+}
+
+func TestCreateConfig_ConfigFlagTakesPrecedenceOverFoundConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte("version: '3'\n"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, ".task-graph.yml"), []byte("legend: true\n"), 0o600)).To(Succeed())
+
+	cli := CLI{
+		Taskfiles: []string{dir},
+		Config:    filepath.Join("testdata", "config.yaml"),
+	}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Legend).To(BeFalse())
+}
+
+//nolint:paralleltest // Sets environment variables
+func TestCreateConfig_EnvironmentOverridesFileAndFlagsOverrideEnvironment(t *testing.T) {
+	g := NewWithT(t)
+
+	// Arrange
+	t.Setenv("TASK_GRAPH_GRAPHVIZ_FONT", "Helvetica")
+	t.Setenv("TASK_GRAPH_HIGHLIGHT_COLOR", "orange")
+
+	cli := CLI{
+		Config:         filepath.Join("testdata", "config.yaml"),
+		HighlightColor: "pink",
+	}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Graphviz.Font).To(Equal("Helvetica"))
+	g.Expect(cfg.HighlightColor).To(Equal("pink"))
+}
+
+func TestExportConfigToFile_YAMLShowsSourceOfEachValue(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	outFile := filepath.Join(t.TempDir(), "out.yaml")
+
+	cli := CLI{
+		Config:       filepath.Join("testdata", "config.yaml"),
+		AutoColor:    true,
+		ExportConfig: outFile,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	err = cli.ExportConfigToFile(cfg)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	raw, err := os.ReadFile(outFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(raw)).To(ContainSubstring("autoColor: true # from --auto-color"))
+	g.Expect(string(raw)).To(ContainSubstring("# from " + filepath.Join("testdata", "config.yaml") + ":"))
+	g.Expect(string(raw)).To(ContainSubstring("# default"))
+}
+
+func TestCreateConfig_ConfigFileOverridesConfigEmbeddedInTaskfile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	taskfile := "version: '3'\nx-task-graph:\n  legend: true\n  graphType: mermaid\ntasks: {}\n"
+	g.Expect(os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte(taskfile), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, ".task-graph.yml"), []byte("legend: false\n"), 0o600)).To(Succeed())
+
+	cli := CLI{Taskfiles: []string{dir}}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Legend).To(BeFalse())
+	g.Expect(cfg.GraphType).To(Equal("mermaid"))
+	g.Expect(cli.sources.Lookup("graphType")).To(Equal(filepath.Join(dir, "Taskfile.yml") + ":4"))
+}
+
+func TestCreateConfig_ThemeFlagReplacesDefaultsButNotConfigFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	g.Expect(os.WriteFile(path, []byte("graphviz:\n  fontSize: 10\n"), 0o600)).To(Succeed())
+
+	cli := CLI{
+		Config: path,
+		Theme:  config.ThemePresentation,
+	}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Theme).To(Equal(config.ThemePresentation))
+	g.Expect(cfg.Graphviz.Font).To(Equal("Helvetica"))
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(10))
+	g.Expect(cli.sources.Lookup("graphviz.font")).To(Equal("theme presentation"))
+	g.Expect(cli.sources.Lookup("theme")).To(Equal("--theme"))
+}
+
+func TestCreateConfig_ThemeDefinedInConfigFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := "theme: corporate\n" +
+		"themes:\n" +
+		"  corporate:\n" +
+		"    graphviz:\n" +
+		"      background: ivory\n" +
+		"    mermaid:\n" +
+		"      theme: forest\n"
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	cli := CLI{Config: path}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Graphviz.Background).To(Equal("ivory"))
+	g.Expect(cfg.Mermaid.Theme).To(Equal("forest"))
+}
+
+func TestCreateConfig_UnknownThemeFlag_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Theme: "sepia"}
+
+	// Act
+	_, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`--theme: unknown theme "sepia"`)))
+}
+
+func TestCreateConfig_UnknownThemeInConfigFile_ReportsConfigFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte("theme: sepia\n"), 0o600)).To(Succeed())
+
+	cli := CLI{Config: path}
+
+	// Act
+	_, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(path)))
+	g.Expect(err).To(MatchError(ContainSubstring(`"sepia"`)))
+	g.Expect(err).NotTo(MatchError(ContainSubstring("--theme")))
+}
+
+//nolint:paralleltest // Sets environment variables
+func TestCreateConfig_ThemeFromEnvironment_RecordsEachSource(t *testing.T) {
+	g := NewWithT(t)
+
+	// Arrange
+	t.Setenv("TASK_GRAPH_THEME", config.ThemePresentation)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte("graphviz:\n  fontSize: 10\n"), 0o600)).To(Succeed())
+
+	cli := CLI{Config: path}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Graphviz.Font).To(Equal("Helvetica"))
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(10))
+	g.Expect(cli.sources.Lookup("theme")).To(Equal("$TASK_GRAPH_THEME"))
+	g.Expect(cli.sources.Lookup("graphviz.font")).To(Equal("theme presentation"))
+	g.Expect(cli.sources.Lookup("graphviz.fontSize")).To(Equal(path + ":2"))
+}
+
+func TestCreateConfig_LayoutFlagSetsConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Layout: "neato"}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Graphviz.Layout).To(Equal("neato"))
+}
+
+func TestCreateConfig_UnknownLayoutFlag_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Layout: "spring"}
+
+	// Act
+	_, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`--layout: unsupported value "spring"`)))
+}
+
+func TestCreateConfig_RendererFlagSetsConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Renderer: config.RendererBuiltin}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Renderer).To(Equal(config.RendererBuiltin))
+}
+
+func TestCreateConfig_UnknownRendererFlag_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Renderer: "inkjet"}
+
+	// Act
+	_, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`--renderer: unsupported value "inkjet"`)))
+}
+
+func TestCreateConfig_RenderTimeoutFlagSetsConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{RenderTimeout: "2m"}
+
+	// Act
+	cfg, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.RenderTimeoutOrDefault()).To(Equal(2 * time.Minute))
+}
+
+func TestCreateConfig_InvalidRenderFlags_ReturnsErrors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{RenderImage: "svg,png=", RenderTimeout: "soon"}
+
+	// Act
+	_, err := cli.CreateConfig(nil)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`--render-image: missing path after "png="`)))
+	g.Expect(err).To(MatchError(ContainSubstring(`--render-timeout: invalid duration "soon"`)))
+}
+
+func TestResolveImages_WithoutPaths_WritesBesideOutput(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	images, err := resolveImages("svg, png:cairo", filepath.Join("out", "graph.dot"))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(Equal([]config.Image{
+		{Format: "svg", Path: filepath.Join("out", "graph.svg")},
+		{Format: "png:cairo", Path: filepath.Join("out", "graph.png")},
+	}))
+}
+
+func TestResolveImages_WithPath_UsesPath(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	images, err := resolveImages("pdf=docs/tasks.pdf", stdio)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(Equal([]config.Image{{Format: "pdf", Path: "docs/tasks.pdf"}}))
+}
+
+func TestResolveImages_StdoutOutput_WritesImageToStdout(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	images, err := resolveImages("svg", stdio)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(Equal([]config.Image{{Format: "svg", Path: stdio}}))
+}
+
+func TestResolveImages_SamePathTwice_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := resolveImages("png,png:cairo", "graph.dot")
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("more than one image would be written to graph.png")))
+}
+
+func TestRun_OutputWithImageExtension_WritesOnlyTheImage(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	output := filepath.Join(t.TempDir(), "graph.svg")
+	cli := CLI{
+		Taskfiles:   []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:      output,
+		RenderImage: "svg",
+		Renderer:    config.RendererBuiltin,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	err = cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring("<svg"))
+}
+
+func TestRenderImages_BuiltinRenderer_WritesEachImage(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build").AddEdge(gr.AddNode("lint"))
+
+	cfg := config.New()
+	cfg.Renderer = config.RendererBuiltin
+
+	var stdout bytes.Buffer
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &stdout,
+	}
+
+	pngFile := filepath.Join(t.TempDir(), "graph.png")
+
+	// Act
+	err := renderImages(t.Context(), gr, []config.Image{
+		{Format: "svg", Path: stdio},
+		{Format: "png", Path: pngFile},
+	}, flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(stdout.String()).To(ContainSubstring(">lint</text>"))
+	g.Expect(pngFile).To(BeAnExistingFile())
+}
+
+func TestRenderImages_MermaidGraph_RendersWithMermaidCLI(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake mmdc is a shell script, skipping on Windows")
+	}
+
+	// Arrange
+	// A stand-in for mmdc, echoing the Mermaid it is given
+	dir := t.TempDir()
+	//nolint:gosec // The script must be executable
+	err := os.WriteFile(filepath.Join(dir, "mmdc"), []byte("#!/bin/sh\ncat\n"), 0o700)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gr := graph.New()
+	gr.AddNode("build").AddEdge(gr.AddNode("lint"))
+
+	cfg := config.New()
+	cfg.GraphType = graphTypeMermaid
+	cfg.MmdcPath = dir
+
+	var stdout bytes.Buffer
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &stdout,
+	}
+
+	// Act
+	err = renderImages(t.Context(), gr, []config.Image{{Format: "svg", Path: stdio}}, flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(HavePrefix("flowchart TD\n"))
+}
+
+func TestRenderImages_MermaidCLIMissing_ReturnsClearError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	gr.AddNode("build")
+
+	cfg := config.New()
+	cfg.Renderer = config.RendererMermaid
+	cfg.MmdcPath = t.TempDir()
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+	}
+
+	// Act
+	err := renderImages(t.Context(), gr, []config.Image{{Format: "svg", Path: "graph.svg"}}, flags)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("mermaid-cli is needed to render Mermaid images")))
+}
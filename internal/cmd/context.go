@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"io"
 	"log/slog"
+	"os"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
 )
@@ -11,4 +13,42 @@ type Flags struct {
 	Verbose bool
 	Log     *slog.Logger
 	Config  *config.Config
+
+	// Stdin supplies a taskfile given as "-"; os.Stdin is used if nil.
+	Stdin io.Reader
+
+	// Stdout receives reports written for the user, and the graph when the output is "-";
+	// os.Stdout is used if nil.
+	Stdout io.Writer
+
+	// Stderr receives reports when the graph is written to stdout, and problems found in files;
+	// os.Stderr is used if nil.
+	Stderr io.Writer
+}
+
+// stdin returns the reader to use for a taskfile read from standard input.
+func (f *Flags) stdin() io.Reader {
+	if f.Stdin == nil {
+		return os.Stdin
+	}
+
+	return f.Stdin
+}
+
+// stdout returns the writer to use for reports written for the user.
+func (f *Flags) stdout() io.Writer {
+	if f.Stdout == nil {
+		return os.Stdout
+	}
+
+	return f.Stdout
+}
+
+// stderr returns the writer to use for reports kept apart from the output.
+func (f *Flags) stderr() io.Writer {
+	if f.Stderr == nil {
+		return os.Stderr
+	}
+
+	return f.Stderr
 }
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/dot"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/graphviz"
+	"github.com/theunrepentantgeek/task-graph/internal/layered"
+	"github.com/theunrepentantgeek/task-graph/internal/mermaid"
+	"github.com/theunrepentantgeek/task-graph/internal/mmdc"
+	"github.com/theunrepentantgeek/task-graph/internal/render"
+)
+
+// resolveImages returns the images listed by renderImage, each with the path to write it to:
+// as given, or else alongside the output with the extension of its file type. When the output
+// is stdout, an image without a path is written there instead.
+func resolveImages(renderImage string, output string) ([]config.Image, error) {
+	images, err := config.ParseImages(renderImage)
+	if err != nil {
+		return nil, eris.Wrap(err, "invalid image list")
+	}
+
+	seen := make(map[string]bool, len(images))
+
+	for i, image := range images {
+		if image.Path == "" {
+			image.Path = stdio
+			if output != stdio {
+				image.Path = strings.TrimSuffix(output, filepath.Ext(output)) + "." + image.FileType()
+			}
+
+			images[i] = image
+		}
+
+		if seen[image.Path] {
+			return nil, eris.Errorf(
+				"more than one image would be written to %s; give each its own path, as format=path",
+				image.Path)
+		}
+
+		seen[image.Path] = true
+	}
+
+	return images, nil
+}
+
+// renderImages renders the graph as each of the images, using dot, mermaid-cli or the built-in
+// renderer, as configured.
+func renderImages(
+	ctx context.Context,
+	gr *graph.Graph,
+	images []config.Image,
+	flags *Flags,
+) error {
+	var err error
+
+	switch chooseRenderer(images, flags) {
+	case config.RendererBuiltin:
+		err = renderBuiltin(gr, images, flags)
+	case config.RendererMermaid:
+		err = renderWithMermaid(ctx, gr, images, flags)
+	default:
+		err = renderWithDot(ctx, gr, images, flags)
+	}
+
+	if err != nil {
+		return eris.Wrap(err, "failed to render image")
+	}
+
+	for _, image := range images {
+		flags.Log.Info(
+			"Rendered image",
+			"output", image.Path,
+		)
+	}
+
+	return nil
+}
+
+// chooseRenderer returns the renderer to use for the images. Unless one is configured, Mermaid
+// graphs are rendered with mermaid-cli; otherwise dot is used if it can be found, falling back
+// to the built-in renderer if it supports every format.
+func chooseRenderer(images []config.Image, flags *Flags) string {
+	cfg := flags.Config
+	if cfg.Renderer != "" && cfg.Renderer != config.RendererAuto {
+		return cfg.Renderer
+	}
+
+	if cfg.GraphType == graphTypeMermaid || cfg.GraphType == graphTypeGantt {
+		return config.RendererMermaid
+	}
+
+	_, err := dot.FindExecutable(cfg.DotPath)
+	if err != nil && builtinSupports(images) {
+		flags.Log.Info("dot not found; using the built-in renderer")
+
+		return config.RendererBuiltin
+	}
+
+	return config.RendererGraphviz
+}
+
+// builtinSupports reports whether the built-in renderer can draw every one of the images.
+func builtinSupports(images []config.Image) bool {
+	for _, image := range images {
+		if !slices.Contains(layered.Formats, image.Format) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// renderBuiltin draws the graph as each of the images, using the built-in renderer.
+func renderBuiltin(gr *graph.Graph, images []config.Image, flags *Flags) error {
+	for _, image := range images {
+		var err error
+		if image.Path == stdio {
+			err = layered.WriteTo(flags.stdout(), gr, flags.Config, image.Format)
+		} else {
+			err = layered.SaveTo(image.Path, gr, flags.Config, image.Format)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderWithDot renders the graph as each of the images in a single run of dot, sending it the
+// graph as DOT on stdin.
+func renderWithDot(
+	ctx context.Context,
+	gr *graph.Graph,
+	images []config.Image,
+	flags *Flags,
+) error {
+	cfg := flags.Config
+
+	dotExe, err := dot.FindExecutable(cfg.DotPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to find dot executable")
+	}
+
+	var source bytes.Buffer
+
+	err = graphviz.WriteTo(&source, gr, cfg)
+	if err != nil {
+		return eris.Wrap(err, "failed to generate DOT")
+	}
+
+	renderer := &dot.Renderer{
+		Executable: dotExe,
+		Timeout:    cfg.RenderTimeoutOrDefault(),
+		Stdout:     flags.stdout(),
+	}
+
+	if cfg.Graphviz != nil {
+		renderer.Layout = cfg.Graphviz.Layout
+	}
+
+	return renderSource(ctx, renderer, source.Bytes(), images)
+}
+
+// renderWithMermaid renders the graph as each of the images using mermaid-cli, sending it the
+// graph on stdin as a Mermaid gantt chart, for a gantt graph, or otherwise as a flowchart. The
+// theme and theme variables of the config are passed to mermaid-cli too.
+func renderWithMermaid(
+	ctx context.Context,
+	gr *graph.Graph,
+	images []config.Image,
+	flags *Flags,
+) error {
+	cfg := flags.Config
+
+	mmdcExe, err := mmdc.FindExecutable(cfg.MmdcPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to find mmdc executable")
+	}
+
+	var source bytes.Buffer
+
+	if cfg.GraphType == graphTypeGantt {
+		if cfg.GanttTask == "" {
+			return eris.New("a gantt graph requires a task to simulate; use --gantt-task")
+		}
+
+		err = mermaid.WriteGanttTo(&source, gr, cfg.GanttTask)
+	} else {
+		err = mermaid.WriteTo(&source, gr, cfg)
+	}
+
+	if err != nil {
+		return eris.Wrap(err, "failed to generate Mermaid")
+	}
+
+	mermaidConfig, err := mermaid.ConfigJSON(cfg)
+	if err != nil {
+		return err
+	}
+
+	renderer := &mmdc.Renderer{
+		Executable: mmdcExe,
+		Config:     mermaidConfig,
+		Timeout:    cfg.RenderTimeoutOrDefault(),
+		Stdout:     flags.stdout(),
+	}
+
+	if cfg.Mermaid != nil {
+		renderer.Theme = cfg.Mermaid.Theme
+	}
+
+	return renderSource(ctx, renderer, source.Bytes(), images)
+}
+
+// renderSource renders the source as each of the images with the renderer, writing those whose
+// path is - to stdout.
+func renderSource(ctx context.Context, renderer render.Renderer, source []byte, images []config.Image) error {
+	targets := make([]render.Image, len(images))
+	for i, image := range images {
+		targets[i] = render.Image{Format: image.Format}
+		if image.Path != stdio {
+			targets[i].Path = image.Path
+		}
+	}
+
+	return renderer.Render(ctx, source, targets)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/mermaid"
+)
+
+// platformSafe reports whether Mermaid output is to be written as Markdown for GitHub or GitLab.
+func platformSafe(cfg *config.Config) bool {
+	return cfg.Mermaid != nil && cfg.Mermaid.PlatformSafe
+}
+
+// writeMermaid writes the graph as a Mermaid flowchart, or as platform-safe Markdown, warning
+// if it had to be split or is still too big for GitHub or GitLab to render.
+func writeMermaid(w io.Writer, gr *graph.Graph, flags *Flags) error {
+	if !platformSafe(flags.Config) {
+		return mermaid.WriteTo(w, gr, flags.Config)
+	}
+
+	report, err := mermaid.WriteMarkdownTo(w, gr, flags.Config)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Diagrams) > 1 {
+		flags.Log.Warn(
+			"graph is too big for GitHub or GitLab to render; split into an overview and a diagram per namespace",
+			"diagrams", len(report.Diagrams))
+	}
+
+	for _, d := range report.Oversized {
+		flags.Log.Warn(
+			"diagram exceeds the Mermaid limits of GitHub or GitLab",
+			"diagram", cmp.Or(d.Title, "graph"),
+			"nodes", d.Nodes,
+			"edges", d.Edges,
+			"textSize", d.TextSize)
+	}
+
+	return nil
+}
+
+// writeGantt writes the graph as a Mermaid Gantt chart, fenced as Markdown if platform-safe.
+func writeGantt(w io.Writer, gr *graph.Graph, flags *Flags) error {
+	if !platformSafe(flags.Config) {
+		return mermaid.WriteGanttTo(w, gr, flags.Config.GanttTask)
+	}
+
+	var buf bytes.Buffer
+
+	err := mermaid.WriteGanttTo(&buf, gr, flags.Config.GanttTask)
+	if err != nil {
+		return err
+	}
+
+	return mermaid.WriteFencedTo(w, buf.Bytes())
+}
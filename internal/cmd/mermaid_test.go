@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestRun_PlatformSafe_WritesFencedMarkdown(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var stdout bytes.Buffer
+
+	cli := CLI{
+		Taskfiles:    []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:       "-",
+		GraphType:    graphTypeMermaid,
+		PlatformSafe: true,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	err = cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler), Stdout: &stdout})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Mermaid.PlatformSafe).To(BeTrue())
+	g.Expect(stdout.String()).To(HavePrefix("```mermaid\nflowchart TD\n"))
+	g.Expect(stdout.String()).To(HaveSuffix("```\n"))
+}
+
+func TestWriteMermaid_PlatformSafeBeyondLimits_WarnsOfSplit(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var out, log bytes.Buffer
+
+	cfg := config.New()
+	cfg.Mermaid.PlatformSafe = true
+	cfg.Mermaid.MaxNodes = 1
+
+	gr := graph.New()
+	gr.AddNode("ci").AddEdge(gr.AddNode("go:build"))
+	gr.AddNode("go:test").AddEdge(gr.AddNode("go:vet"))
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+
+	// Act
+	err := writeMermaid(&out, gr, flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(HavePrefix("## Overview\n\n```mermaid\n"))
+	g.Expect(log.String()).To(ContainSubstring("split into an overview"))
+	g.Expect(log.String()).To(ContainSubstring("diagram exceeds the Mermaid limits"))
+}
+
+func TestRun_PlatformSafeGantt_WritesFencedMarkdown(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var stdout bytes.Buffer
+
+	cfg := config.New()
+	cfg.GraphType = graphTypeGantt
+	cfg.GanttTask = "tidy"
+	cfg.Mermaid.PlatformSafe = true
+
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:    "-",
+	}
+
+	// Act
+	err := cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler), Stdout: &stdout})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(HavePrefix("```mermaid\ngantt\n"))
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// namedProfile is a profile to produce, along with its name from the config.
+type namedProfile struct {
+	config.Profile
+	name string
+}
+
+// selectProfiles returns the outputs to produce. An output given by --output is produced on
+// its own, using the focus and exclusions given by flags; otherwise each profile chosen with
+// --profile is produced, or every profile in the config, in order of name.
+func (c *CLI) selectProfiles(cfg *config.Config) ([]namedProfile, error) {
+	if c.Output != "" {
+		if len(c.Profile) > 0 {
+			return nil, eris.New("--profile cannot be combined with --output; the output of each profile is in the config")
+		}
+
+		return []namedProfile{{
+			Profile: config.Profile{
+				Output:      c.Output,
+				Focus:       splitPatterns(c.Focus),
+				Exclude:     splitPatterns(c.Exclude),
+				RenderImage: c.RenderImage,
+			},
+		}}, nil
+	}
+
+	if len(cfg.Profiles) == 0 {
+		return nil, eris.New("no output given; use --output, or define profiles in the config")
+	}
+
+	names := c.Profile
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(cfg.Profiles))
+	}
+
+	result := make([]namedProfile, 0, len(names))
+
+	for _, name := range names {
+		profile, ok := cfg.Profiles[name]
+		if !ok {
+			return nil, eris.Errorf(
+				"unknown profile %q; the config defines %s",
+				name,
+				strings.Join(slices.Sorted(maps.Keys(cfg.Profiles)), ", "))
+		}
+
+		result = append(result, namedProfile{Profile: profile, name: name})
+	}
+
+	return result, nil
+}
+
+// produce writes the output of a single profile, drawn from the shared graph gr.
+func (c *CLI) produce(
+	ctx context.Context,
+	gr *graph.Graph,
+	profile namedProfile,
+	flags *Flags,
+) error {
+	profileFlags := *flags
+	profileFlags.Config = profileConfig(flags.Config, profile.Profile)
+
+	if profile.name != "" {
+		profileFlags.Log = flags.Log.With("profile", profile.name)
+	}
+
+	gr, err := filterGraph(gr, profile.Profile, &profileFlags)
+	if err != nil {
+		return err
+	}
+
+	err = applyAutoColor(profileFlags.Config, gr)
+	if err != nil {
+		return err
+	}
+
+	applyCriticalPath(&profileFlags, gr)
+
+	images, err := resolveImages(profile.RenderImage, profile.Output)
+	if err != nil {
+		return err
+	}
+
+	// The output is skipped when it is one of the images
+	if !slices.ContainsFunc(images, func(image config.Image) bool { return image.Path == profile.Output }) {
+		err = c.saveGraph(gr, profile.Output, &profileFlags)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(images) > 0 {
+		return renderImages(ctx, gr, images, &profileFlags)
+	}
+
+	return nil
+}
+
+// profileConfig returns a copy of cfg with the options of the profile applied. Rules are
+// copied, so that those added for one profile don't affect another; other nested settings are
+// shared. The graph type is always resolved, so that the graph is written and rendered as the
+// same type.
+func profileConfig(cfg *config.Config, profile config.Profile) *config.Config {
+	result := *cfg
+	result.NodeStyleRules = slices.Clone(cfg.NodeStyleRules)
+	result.EdgeStyleRules = slices.Clone(cfg.EdgeStyleRules)
+	result.GraphType = resolveGraphType(cfg, profile)
+
+	if profile.GanttTask != "" {
+		result.GanttTask = profile.GanttTask
+	}
+
+	if profile.GroupByNamespace != nil {
+		result.GroupByNamespace = *profile.GroupByNamespace
+	}
+
+	result.NodeStyleRules = append(
+		result.NodeStyleRules,
+		highlightRules(profile.Highlight, result.HighlightColor)...)
+
+	return &result
+}
+
+// filterGraph returns the part of gr to draw for the profile: only the focused tasks with
+// their dependencies and dependents, if any, less any excluded tasks.
+func filterGraph(gr *graph.Graph, profile config.Profile, flags *Flags) (*graph.Graph, error) {
+	if len(profile.Focus) > 0 {
+		focus := strings.Join(profile.Focus, ",")
+
+		focused, matched, err := applyFocus(gr, focus)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to apply focus filter")
+		}
+
+		if !matched {
+			flags.Log.Warn(
+				"focus pattern matched no tasks; showing full graph",
+				"focus", focus)
+		}
+
+		gr = focused
+	}
+
+	if len(profile.Exclude) > 0 {
+		return applyExclude(gr, profile.Exclude)
+	}
+
+	return gr, nil
+}
+
+// applyExclude returns gr without the tasks matching any of the patterns.
+func applyExclude(gr *graph.Graph, patterns []string) (*graph.Graph, error) {
+	matchers := make([]func(string) bool, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		re, err := namespace.CompileMatchPattern(pattern)
+		if err != nil {
+			return nil, eris.Wrapf(err, "invalid exclude pattern %q", pattern)
+		}
+
+		matchers = append(matchers, re.MatchString)
+	}
+
+	keep := make(map[string]bool)
+
+	for node := range gr.Nodes() {
+		excluded := slices.ContainsFunc(matchers, func(match func(string) bool) bool {
+			return match(node.ID())
+		})
+
+		if !excluded {
+			keep[node.ID()] = true
+		}
+	}
+
+	return gr.FilterNodes(keep), nil
+}
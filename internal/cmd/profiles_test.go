@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestRun_WithProfiles_WritesEachOutput(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+	}
+
+	cfg := config.New()
+	cfg.Profiles = map[string]config.Profile{
+		"full": {Output: filepath.Join(dir, "full.dot")},
+		"tasks": {
+			Output:    filepath.Join(dir, "TASKS.md"),
+			GraphType: graphTypeMermaid,
+			Focus:     []string{"tidy"},
+			Highlight: []string{"tidy"},
+		},
+	}
+
+	// Act
+	err := cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	full, err := os.ReadFile(filepath.Join(dir, "full.dot"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(full)).To(HavePrefix("digraph"))
+	g.Expect(string(full)).To(ContainSubstring(`"lint"`))
+	g.Expect(string(full)).NotTo(ContainSubstring("yellow"))
+
+	tasks, err := os.ReadFile(filepath.Join(dir, "TASKS.md"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(tasks)).To(HavePrefix("flowchart"))
+	g.Expect(string(tasks)).NotTo(ContainSubstring(`build["build"]`))
+	g.Expect(string(tasks)).To(ContainSubstring("yellow"))
+
+	// Rules added for one profile don't leak into the shared config
+	g.Expect(cfg.NodeStyleRules).To(BeEmpty())
+}
+
+func TestRun_WithSelectedProfile_WritesOnlyThatOutput(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Profile:   []string{"tasks"},
+	}
+
+	cfg := config.New()
+	cfg.Profiles = map[string]config.Profile{
+		"full":  {Output: filepath.Join(dir, "full.dot")},
+		"tasks": {Output: filepath.Join(dir, "TASKS.md"), GraphType: graphTypeMermaid},
+	}
+
+	// Act
+	err := cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(filepath.Join(dir, "TASKS.md")).To(BeAnExistingFile())
+	g.Expect(filepath.Join(dir, "full.dot")).NotTo(BeAnExistingFile())
+}
+
+func TestRun_ProfileGraphTypeWithGraphTypeFlag_WritesAndRendersProfileType(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake mmdc is a shell script, skipping on Windows")
+	}
+
+	// Arrange
+	// A stand-in for mmdc, saving the Mermaid it is given as its output
+	dir := t.TempDir()
+	script := "#!/bin/sh\nwhile [ \"$1\" != --output ]; do shift; done\ncat > \"$2\"\n"
+	//nolint:gosec // The script must be executable
+	err := os.WriteFile(filepath.Join(dir, "mmdc"), []byte(script), 0o700)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cli := CLI{
+		Taskfiles: []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		GraphType: graphTypeDot,
+	}
+
+	cfg := config.New()
+	cfg.GraphType = graphTypeDot
+	cfg.MmdcPath = dir
+	cfg.Profiles = map[string]config.Profile{
+		"tasks": {Output: filepath.Join(dir, "TASKS.md"), GraphType: graphTypeMermaid, RenderImage: "svg"},
+	}
+
+	// Act
+	err = cli.Run(&Flags{Config: cfg, Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	tasks, err := os.ReadFile(filepath.Join(dir, "TASKS.md"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(tasks)).To(HavePrefix("flowchart"))
+
+	image, err := os.ReadFile(filepath.Join(dir, "TASKS.svg"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(image)).To(HavePrefix("flowchart"))
+}
+
+func TestSelectProfiles_UnknownProfile_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{Profile: []string{"overveiw"}}
+
+	cfg := config.New()
+	cfg.Profiles = map[string]config.Profile{
+		"overview": {Output: "overview.dot"},
+	}
+
+	// Act
+	_, err := cli.selectProfiles(cfg)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`unknown profile "overveiw"; the config defines overview`)))
+}
+
+func TestSelectProfiles_NoOutputOrProfiles_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{}
+
+	// Act
+	_, err := cli.selectProfiles(config.New())
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("no output given")))
+}
+
+func TestApplyExclude_RemovesMatchingTasksAndTheirEdges(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	build := gr.AddNode("build")
+	lint := gr.AddNode("lint")
+	test := gr.AddNode("test:unit")
+	build.AddEdge(lint)
+	build.AddEdge(test)
+
+	// Act
+	result, err := applyExclude(gr, []string{"test:*"})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(collectNodeIDs(result)).To(ConsistOf("build", "lint"))
+
+	node, _ := result.Node("build")
+	g.Expect(node.Edges()).To(HaveLen(1))
+}
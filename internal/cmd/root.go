@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+)
+
+// Root is the set of commands supported by task-graph. Drawing a graph is the default, so the
+// command may be omitted.
+//
+//nolint:tagalign // Not useful here because different members have different tags.
+type Root struct {
+	Graph  CLI           `cmd:"" default:"withargs" help:"Draw a graph of the tasks in a taskfile (the default command)."`
+	Init   InitCommand   `cmd:"" help:"Write a starter config file documenting every option."`
+	Schema SchemaCommand `cmd:"" help:"Write the JSON Schema for the config file, for use by editors."`
+}
+
+// InitCommand writes a starter config file.
+type InitCommand struct {
+	Path  string `arg:"" default:".task-graph.yml" help:"Path of the config file to write, or - to write to stdout. Defaults to .task-graph.yml." optional:""` //nolint:revive // Intentionally long line for clarity in the CLI help.
+	Force bool   `help:"Overwrite the config file if it already exists." long:"force"`
+}
+
+// Run writes the starter config file, refusing to overwrite an existing file unless forced.
+func (c *InitCommand) Run(flags *Flags) error {
+	path := c.Path
+
+	var buf bytes.Buffer
+
+	err := config.WriteStarter(&buf)
+	if err != nil {
+		return err
+	}
+
+	if path == stdio {
+		_, err = flags.stdout().Write(buf.Bytes())
+
+		return eris.Wrap(err, "failed to write starter config")
+	}
+
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if c.Force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, mode, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return eris.Errorf("config file %s already exists; use --force to overwrite it", path)
+	}
+
+	if err != nil {
+		return eris.Wrapf(err, "failed to create config file: %s", path)
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(buf.Bytes())
+	if err != nil {
+		return eris.Wrapf(err, "failed to write config file: %s", path)
+	}
+
+	flags.Log.Info(
+		"Wrote starter config",
+		"output", path,
+	)
+
+	return nil
+}
+
+// SchemaCommand writes the JSON Schema for the config file.
+type SchemaCommand struct {
+	Output string `default:"-" help:"Path to the output file, or - to write to stdout." long:"output" short:"o"`
+}
+
+// Run writes the schema to the output file, or to stdout.
+func (c *SchemaCommand) Run(flags *Flags) error {
+	if c.Output == "" || c.Output == stdio {
+		_, err := flags.stdout().Write(config.Schema())
+
+		return eris.Wrap(err, "failed to write config schema")
+	}
+
+	err := os.WriteFile(c.Output, config.Schema(), 0o600)
+	if err != nil {
+		return eris.Wrapf(err, "failed to write config schema: %s", c.Output)
+	}
+
+	flags.Log.Info(
+		"Saved config schema",
+		"output", c.Output,
+	)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+)
+
+func TestInitCommand_WritesStarterConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), ".task-graph.yml")
+	command := InitCommand{Path: path}
+
+	// Act
+	err := command.Run(&Flags{Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg := config.New()
+	g.Expect(config.Load(path, cfg, nil)).To(Succeed())
+	g.Expect(cfg).To(Equal(config.New()))
+}
+
+func TestInitCommand_RefusesToOverwriteExistingFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), ".task-graph.yml")
+	g.Expect(os.WriteFile(path, []byte("autoColor: true\n"), 0o600)).To(Succeed())
+
+	command := InitCommand{Path: path}
+
+	// Act
+	err := command.Run(&Flags{Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("already exists")))
+
+	content, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("autoColor: true\n"))
+}
+
+func TestInitCommand_WithForce_OverwritesExistingFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), ".task-graph.yml")
+	g.Expect(os.WriteFile(path, []byte("autoColor: true\n"), 0o600)).To(Succeed())
+
+	command := InitCommand{Path: path, Force: true}
+
+	// Act
+	err := command.Run(&Flags{Log: slog.New(slog.DiscardHandler)})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(HavePrefix("# yaml-language-server: $schema="))
+}
+
+func TestSchemaCommand_WritesSchemaToStdout(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var stdout bytes.Buffer
+
+	schema := SchemaCommand{Output: stdio}
+
+	// Act
+	err := schema.Run(&Flags{Stdout: &stdout})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.Bytes()).To(Equal(config.Schema()))
+}
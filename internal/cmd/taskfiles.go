@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-task/task/v3/taskfile/ast"
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/loader"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+	"github.com/theunrepentantgeek/task-graph/internal/taskgraph"
+)
+
+// taskfileSource is a Taskfile to be loaded, along with the namespace for its tasks.
+type taskfileSource struct {
+	path      string
+	namespace string
+}
+
+// loadGraph loads all the requested Taskfiles and builds a single graph from them.
+// A single Taskfile is graphed as is; when there are several, the tasks of each are placed in
+// a namespace derived from its relative path, and tasks are grouped by namespace so that each
+// file is drawn as a cluster.
+func (c *CLI) loadGraph(ctx context.Context, flags *Flags) (*graph.Graph, error) {
+	sources, err := c.taskfileSources(flags.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := c.linkBaseDir(flags.Config, sources)
+
+	if len(sources) == 1 {
+		gr, files, err := c.buildGraph(ctx, sources[0].path, baseDir, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		// The config embedded in the root Taskfile has already been loaded
+		err = applyEmbeddedRules(flags.Config, files[1:], "")
+		if err != nil {
+			return nil, err
+		}
+
+		return gr, nil
+	}
+
+	result := graph.New()
+
+	var embedded error
+
+	for _, source := range sources {
+		gr, files, err := c.buildGraph(ctx, source.path, baseDir, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		result.AddGraph(gr, source.namespace+":")
+		embedded = errors.Join(embedded, applyEmbeddedRules(flags.Config, files, source.namespace))
+	}
+
+	if embedded != nil {
+		return nil, embedded
+	}
+
+	flags.Config.GroupByNamespace = true
+
+	return result, nil
+}
+
+// buildGraph loads a single Taskfile, from stdin if path is "-", and builds its graph, with the
+// paths of Taskfiles relative to baseDir. The Taskfiles read are also returned, starting with
+// the one at path.
+func (c *CLI) buildGraph(
+	ctx context.Context,
+	path string,
+	baseDir string,
+	flags *Flags,
+) (*graph.Graph, []loader.File, error) {
+	var (
+		tf    *ast.Taskfile
+		files []loader.File
+		err   error
+	)
+
+	opts := loaderOptions(flags.Config)
+
+	if path == stdio {
+		var content []byte
+
+		content, err = c.readStdin(flags.stdin())
+		if err == nil {
+			tf, files, err = loader.LoadReader(ctx, bytes.NewReader(content), c.Dir, opts)
+		}
+	} else {
+		tf, files, err = loader.Load(ctx, path, opts)
+	}
+
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "failed to load taskfile")
+	}
+
+	flags.Log.Info(
+		"Loaded taskfile",
+		"taskfile", path,
+		"tasks", tf.Tasks.Len())
+
+	builder := taskgraph.New(tf)
+	builder.IncludeGlobalVars = flags.Config.IncludeGlobalVars
+	builder.Placeholders = placeholderFiles(files)
+	builder.BaseDir = baseDir
+
+	return builder.Build(), files, nil
+}
+
+// applyEmbeddedRules adds the style rules embedded in each of the Taskfiles to cfg, scoped to
+// the namespace of its tasks within prefix. Other settings embedded in these Taskfiles are
+// ignored, as they would affect the whole graph. The rules are added before any already
+// present, so that rules from the root Taskfile, the config file and flags take precedence.
+func applyEmbeddedRules(cfg *config.Config, files []loader.File, prefix string) error {
+	var (
+		nodeRules []config.NodeStyleRule
+		edgeRules []config.EdgeStyleRule
+		problems  error
+	)
+
+	for _, file := range files {
+		if file.Content == nil {
+			continue
+		}
+
+		embedded := &config.Config{}
+
+		err := config.LoadEmbedded(file.Location, file.Content, embedded, nil)
+		if err != nil {
+			problems = errors.Join(
+				problems,
+				eris.Wrapf(err, "failed to load config embedded in taskfile: %s", file.Location))
+
+			continue
+		}
+
+		ns := strings.Trim(prefix+":"+file.Namespace, ":")
+
+		nodes, edges := embedded.ScopedRules(ns)
+		nodeRules = append(nodeRules, nodes...)
+		edgeRules = append(edgeRules, edges...)
+	}
+
+	cfg.NodeStyleRules = append(nodeRules, cfg.NodeStyleRules...)
+	cfg.EdgeStyleRules = append(edgeRules, cfg.EdgeStyleRules...)
+
+	return problems
+}
+
+// loaderOptions returns the options for resolving remote includes, as configured.
+func loaderOptions(cfg *config.Config) loader.Options {
+	if cfg.Remote == nil {
+		return loader.Options{}
+	}
+
+	return loader.Options{
+		CacheDir:     cfg.Remote.CacheDir,
+		Download:     cfg.Remote.Download,
+		Offline:      cfg.Remote.Offline,
+		Timeout:      cfg.Remote.TimeoutOrDefault(),
+		TrustedHosts: cfg.Remote.TrustedHosts,
+		Mappings:     cfg.Remote.Includes,
+		Placeholders: cfg.Remote.Placeholders,
+	}
+}
+
+// placeholderFiles returns the locations of the Taskfiles standing in for remote includes that
+// could not be loaded.
+func placeholderFiles(files []loader.File) map[string]bool {
+	result := make(map[string]bool)
+
+	for _, file := range files {
+		if file.Placeholder {
+			result[file.Location] = true
+		}
+	}
+
+	return result
+}
+
+// applyPlaceholders warns about each remote include that could not be loaded and was replaced
+// by a placeholder, and styles the placeholders so that they stand out as missing.
+func applyPlaceholders(flags *Flags, gr *graph.Graph) {
+	var ids []string
+
+	for node := range gr.Nodes() {
+		if node.Placeholder {
+			ids = append(ids, node.ID())
+		}
+	}
+
+	slices.Sort(ids)
+
+	for i, id := range ids {
+		flags.Log.Warn(
+			"remote include could not be loaded; drawn as a placeholder",
+			"namespace", namespace.Namespace(id))
+
+		rule := config.NodeStyleRule{
+			Match:     namespace.QuoteMatchPattern(id),
+			Color:     "gray",
+			FontColor: "gray",
+			Style:     "dashed",
+		}
+
+		if i == 0 {
+			// Name the first rule so that placeholders are explained in any legend
+			rule.Name = "unreachable include"
+		}
+
+		flags.Config.NodeStyleRules = append(flags.Config.NodeStyleRules, rule)
+	}
+}
+
+// linkBaseDir returns the directory that the paths of Taskfiles are made relative to, for links
+// to task definitions: links.baseDir, resolved against the directory containing the root
+// Taskfiles, or that directory itself. Paths are left absolute if it can't be determined.
+func (c *CLI) linkBaseDir(cfg *config.Config, sources []taskfileSource) string {
+	var root string
+
+	for i, source := range sources {
+		dir := filepath.Dir(source.path)
+		if source.path == stdio {
+			dir = cmp.Or(c.Dir, ".")
+		}
+
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return ""
+		}
+
+		if i == 0 {
+			root = dir
+		} else {
+			root = commonDir(root, dir)
+		}
+	}
+
+	if cfg.Links == nil || cfg.Links.BaseDir == "" || root == "" {
+		return root
+	}
+
+	if filepath.IsAbs(cfg.Links.BaseDir) {
+		return filepath.Clean(cfg.Links.BaseDir)
+	}
+
+	return filepath.Join(root, cfg.Links.BaseDir)
+}
+
+// commonDir returns the deepest directory containing both of the absolute directories a and b.
+func commonDir(a string, b string) string {
+	for {
+		rel, err := filepath.Rel(a, b)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return a
+		}
+
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+
+		a = parent
+	}
+}
+
+// taskfileSources returns the Taskfiles given as arguments, followed by any found with
+// --discover, each with a distinct namespace. Arguments naming a directory are searched for a
+// Taskfile in the same way as task itself; if there are no arguments and --discover is not used,
+// the search starts from the working directory.
+func (c *CLI) taskfileSources(log *slog.Logger) ([]taskfileSource, error) {
+	var result []taskfileSource
+
+	base, err := os.Getwd()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to determine working directory")
+	}
+
+	paths := c.Taskfiles
+	if len(paths) == 0 && c.Discover == "" {
+		paths = []string{""}
+	}
+
+	for _, path := range paths {
+		if path == stdio {
+			result = append(result, taskfileSource{path: stdio, namespace: "stdin"})
+
+			continue
+		}
+
+		found, err := loader.Find(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if found != path {
+			log.Info("Using taskfile", "taskfile", found)
+		}
+
+		result = append(result, taskfileSource{
+			path:      found,
+			namespace: taskfileNamespace(base, found),
+		})
+	}
+
+	if c.Discover != "" {
+		found, err := loader.Discover(c.Discover)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(found) == 0 {
+			return nil, eris.Errorf("no taskfiles found in %s", c.Discover)
+		}
+
+		for _, path := range found {
+			result = append(result, taskfileSource{
+				path:      path,
+				namespace: taskfileNamespace(c.Discover, path),
+			})
+		}
+	}
+
+	err = checkDistinctNamespaces(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// taskfileNamespace returns the namespace for the tasks of the Taskfile at path, based on the
+// path of its directory relative to base, with each directory name becoming a namespace level.
+// Leading ".." elements are dropped, and the directory name itself is used when the Taskfile
+// is directly within base.
+func taskfileNamespace(base string, path string) string {
+	dir := filepath.Dir(path)
+
+	absBase, baseErr := filepath.Abs(base)
+	absDir, dirErr := filepath.Abs(dir)
+
+	if baseErr == nil && dirErr == nil {
+		if rel, err := filepath.Rel(absBase, absDir); err == nil {
+			dir = rel
+		}
+	}
+
+	parts := slices.DeleteFunc(
+		strings.Split(filepath.ToSlash(dir), "/"),
+		func(part string) bool {
+			return part == "" || part == "." || part == ".."
+		})
+
+	if len(parts) == 0 {
+		if absDir == "" {
+			absDir = dir
+		}
+
+		return filepath.Base(absDir)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// checkDistinctNamespaces returns an error if two Taskfiles would share a namespace, as their
+// tasks would then overwrite each other.
+func checkDistinctNamespaces(sources []taskfileSource) error {
+	seen := make(map[string]string, len(sources))
+
+	for _, source := range sources {
+		if other, ok := seen[source.namespace]; ok {
+			return eris.Errorf(
+				"taskfiles %s and %s would share the namespace %q; only one Taskfile per directory is supported",
+				other,
+				source.path,
+				source.namespace)
+		}
+
+		seen[source.namespace] = source.path
+	}
+
+	return nil
+}
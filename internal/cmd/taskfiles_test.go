@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+)
+
+func TestTaskfileNamespace(t *testing.T) {
+	t.Parallel()
+
+	base := filepath.Join("work", "repos")
+
+	cases := map[string]struct {
+		path     string
+		expected string
+	}{
+		"nested directory": {
+			path:     filepath.Join(base, "services", "api", "Taskfile.yml"),
+			expected: "services:api",
+		},
+		"sibling directory": {
+			path:     filepath.Join("work", "other", "Taskfile.yml"),
+			expected: "other",
+		},
+		"base directory": {
+			path:     filepath.Join(base, "Taskfile.yml"),
+			expected: "repos",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			result := taskfileNamespace(base, c.path)
+
+			// Assert
+			g.Expect(result).To(Equal(c.expected))
+		})
+	}
+}
+
+func TestLinkBaseDir(t *testing.T) {
+	t.Parallel()
+
+	repo := filepath.Join(t.TempDir(), "repo")
+	docs := taskfileSource{path: filepath.Join(repo, "docs", "Taskfile.yml")}
+	api := taskfileSource{path: filepath.Join(repo, "services", "api", "Taskfile.yml")}
+
+	cases := map[string]struct {
+		cli      CLI
+		baseDir  string
+		sources  []taskfileSource
+		expected string
+	}{
+		"root taskfile": {
+			sources:  []taskfileSource{docs},
+			expected: filepath.Join(repo, "docs"),
+		},
+		"several taskfiles": {
+			sources:  []taskfileSource{docs, api},
+			expected: repo,
+		},
+		"relative base directory": {
+			baseDir:  "..",
+			sources:  []taskfileSource{docs},
+			expected: repo,
+		},
+		"absolute base directory": {
+			baseDir:  repo,
+			sources:  []taskfileSource{api},
+			expected: repo,
+		},
+		"stdin": {
+			cli:      CLI{Dir: filepath.Join(repo, "docs")},
+			sources:  []taskfileSource{{path: stdio}},
+			expected: filepath.Join(repo, "docs"),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Arrange
+			cfg := config.New()
+			cfg.Links = &config.Links{BaseDir: c.baseDir}
+
+			// Act
+			result := c.cli.linkBaseDir(cfg, c.sources)
+
+			// Assert
+			g.Expect(result).To(Equal(c.expected))
+		})
+	}
+}
+
+func TestTaskfileSources_DirectoryArgument_FindsTaskfile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	nested := filepath.Join(root, "services", "api")
+	g.Expect(os.MkdirAll(nested, 0o750)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(nested, "Taskfile.yml"), []byte("version: '3'\n"), 0o600)).To(Succeed())
+
+	var buf bytes.Buffer
+
+	cli := CLI{Taskfiles: []string{nested}}
+
+	// Act
+	sources, err := cli.taskfileSources(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sources).To(HaveLen(1))
+	g.Expect(sources[0].path).To(Equal(filepath.Join(nested, "Taskfile.yml")))
+	g.Expect(buf.String()).To(ContainSubstring("Using taskfile"))
+}
+
+func TestTaskfileSources_SharedNamespace_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cli := CLI{
+		Taskfiles: []string{
+			filepath.Join("..", "..", "samples", "aso-taskfile.yml"),
+			filepath.Join("..", "..", "samples", "crddoc-taskfile.yml"),
+		},
+	}
+
+	// Act
+	_, err := cli.taskfileSources(slog.New(slog.DiscardHandler))
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(`share the namespace "samples"`)))
+}
+
+func TestRun_WithDiscover_DrawsEachTaskfileAsCluster(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	for dir, sample := range map[string]string{
+		"api":  "go-vcr-tidy-taskfile.yml",
+		"docs": "crddoc-taskfile.yml",
+	} {
+		content, err := os.ReadFile(filepath.Join("..", "..", "samples", sample))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(os.MkdirAll(filepath.Join(root, dir), 0o750)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(root, dir, "Taskfile.yml"), content, 0o600)).To(Succeed())
+	}
+
+	output := filepath.Join(t.TempDir(), "graph.dot")
+	cli := CLI{
+		Discover: root,
+		Output:   output,
+	}
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)),
+	}
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dot, err := os.ReadFile(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(dot)).To(ContainSubstring("subgraph cluster_api {"))
+	g.Expect(string(dot)).To(ContainSubstring("subgraph cluster_docs {"))
+	g.Expect(string(dot)).To(ContainSubstring(`"api_tidy"`))
+}
+
+func TestRun_StdinToStdout_WritesGraphToStdout(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content, err := os.ReadFile(filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var stdout, logs bytes.Buffer
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.NewTextHandler(&logs, nil)),
+		Stdin:  bytes.NewReader(content),
+		Stdout: &stdout,
+	}
+
+	cli := CLI{
+		Taskfiles: []string{"-"},
+		Output:    "-",
+	}
+
+	// Act
+	err = cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(HavePrefix("digraph {"))
+	g.Expect(stdout.String()).To(ContainSubstring(`"tidy"`))
+	g.Expect(logs.String()).NotTo(BeEmpty())
+}
+
+func TestRun_Stdin_AppliesEmbeddedConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\n" +
+		"x-task-graph:\n" +
+		"  nodeStyleRules:\n" +
+		"    - match: build\n" +
+		"      fillColor: gold\n" +
+		"tasks:\n" +
+		"  build: {}\n"
+
+	var stdout bytes.Buffer
+
+	cli := CLI{
+		Taskfiles: []string{"-"},
+		Output:    "-",
+	}
+
+	stdin := strings.NewReader(content)
+
+	cfg, err := cli.CreateConfig(stdin)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+		Stdin:  stdin,
+		Stdout: &stdout,
+	}
+
+	// Act
+	err = cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(ContainSubstring(`"build"`))
+	g.Expect(stdout.String()).To(ContainSubstring("gold"))
+}
+
+func TestRun_StdoutWithRenderImage_WritesImageToStdout(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var stdout bytes.Buffer
+
+	cfg := config.New()
+	cfg.Renderer = config.RendererBuiltin
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+		Stdout: &stdout,
+	}
+
+	cli := CLI{
+		Taskfiles:   []string{filepath.Join("..", "..", "samples", "go-vcr-tidy-taskfile.yml")},
+		Output:      "-",
+		RenderImage: "svg",
+	}
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(HavePrefix("<?xml"))
+	g.Expect(stdout.String()).NotTo(ContainSubstring("digraph"))
+}
+
+func TestRun_OfflineWithPlaceholders_DrawsUnreachableInclude(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\n" +
+		"includes:\n" +
+		"  shared: https://example.com/shared/Taskfile.yml\n" +
+		"tasks:\n" +
+		"  build: {}\n"
+
+	taskfile := filepath.Join(t.TempDir(), "Taskfile.yml")
+	g.Expect(os.WriteFile(taskfile, []byte(content), 0o600)).To(Succeed())
+
+	var stdout, logs bytes.Buffer
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.NewTextHandler(&logs, nil)),
+		Stdout: &stdout,
+	}
+
+	cli := CLI{
+		Taskfiles:          []string{taskfile},
+		Output:             "-",
+		Offline:            true,
+		CacheDir:           t.TempDir(),
+		RemotePlaceholders: true,
+	}
+
+	cli.applyConfigOverrides(flags.Config, nil)
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(ContainSubstring(`"shared_unreachable-include"`))
+	g.Expect(stdout.String()).To(ContainSubstring("dashed"))
+	g.Expect(logs.String()).To(ContainSubstring("drawn as a placeholder"))
+}
+
+func TestRun_TaskNamedLikePlaceholder_IsNotStyledAsPlaceholder(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\n" +
+		"tasks:\n" +
+		"  unreachable-include: {}\n"
+
+	taskfile := filepath.Join(t.TempDir(), "Taskfile.yml")
+	g.Expect(os.WriteFile(taskfile, []byte(content), 0o600)).To(Succeed())
+
+	var stdout, logs bytes.Buffer
+
+	flags := &Flags{
+		Config: config.New(),
+		Log:    slog.New(slog.NewTextHandler(&logs, nil)),
+		Stdout: &stdout,
+	}
+
+	cli := CLI{
+		Taskfiles:          []string{taskfile},
+		Output:             "-",
+		RemotePlaceholders: true,
+	}
+
+	cli.applyConfigOverrides(flags.Config, nil)
+
+	// Act
+	err := cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(stdout.String()).To(ContainSubstring(`"unreachable-include"`))
+	g.Expect(stdout.String()).NotTo(ContainSubstring("dashed"))
+	g.Expect(logs.String()).NotTo(ContainSubstring("drawn as a placeholder"))
+}
+
+func TestRun_EmbeddedConfig_ScopesIncludedRulesToNamespace(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	root := "version: '3'\n" +
+		"x-task-graph:\n" +
+		"  nodeStyleRules:\n" +
+		"    - match: build\n" +
+		"      color: red\n" +
+		"includes:\n" +
+		"  docs: ./docs\n" +
+		"tasks:\n" +
+		"  build:\n" +
+		"    deps: [docs:build]\n"
+	docs := "version: '3'\n" +
+		"x-task-graph:\n" +
+		"  graphType: mermaid\n" +
+		"  nodeStyleRules:\n" +
+		"    - match: build\n" +
+		"      color: blue\n" +
+		"tasks:\n" +
+		"  build: {}\n"
+
+	g.Expect(os.MkdirAll(filepath.Join(dir, "docs"), 0o750)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "Taskfile.yml"), []byte(root), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "docs", "Taskfile.yml"), []byte(docs), 0o600)).To(Succeed())
+
+	output := filepath.Join(t.TempDir(), "graph.dot")
+	cli := CLI{
+		Taskfiles: []string{dir},
+		Output:    output,
+	}
+
+	cfg, err := cli.CreateConfig(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	flags := &Flags{
+		Config: cfg,
+		Log:    slog.New(slog.DiscardHandler),
+	}
+
+	// Act
+	err = cli.Run(flags)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Only style rules are taken from included Taskfiles, and they apply only to their own tasks
+	g.Expect(flags.Config.GraphType).To(BeEmpty())
+	g.Expect(flags.Config.NodeStyleRules).To(Equal([]config.NodeStyleRule{
+		{Match: "docs:build", Color: "blue"},
+		{Match: "build", Color: "red"},
+	}))
+
+	dot, err := os.ReadFile(output)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(dot)).To(ContainSubstring("blue"))
+	g.Expect(string(dot)).To(ContainSubstring("red"))
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// colorNames are the named colours understood by both Graphviz and Mermaid (CSS). Graphviz
+// also accepts many of these with a numeric suffix, such as gray42 or red3.
+var colorNames = map[string]bool{
+	"aliceblue": true, "antiquewhite": true, "aqua": true, "aquamarine": true, "azure": true,
+	"beige": true, "bisque": true, "black": true, "blanchedalmond": true, "blue": true,
+	"blueviolet": true, "brown": true, "burlywood": true, "cadetblue": true, "chartreuse": true,
+	"chocolate": true, "coral": true, "cornflowerblue": true, "cornsilk": true, "crimson": true,
+	"cyan": true, "darkblue": true, "darkcyan": true, "darkgoldenrod": true, "darkgray": true,
+	"darkgreen": true, "darkgrey": true, "darkkhaki": true, "darkmagenta": true,
+	"darkolivegreen": true, "darkorange": true, "darkorchid": true, "darkred": true,
+	"darksalmon": true, "darkseagreen": true, "darkslateblue": true, "darkslategray": true,
+	"darkslategrey": true, "darkturquoise": true, "darkviolet": true, "deeppink": true,
+	"deepskyblue": true, "dimgray": true, "dimgrey": true, "dodgerblue": true, "firebrick": true,
+	"floralwhite": true, "forestgreen": true, "fuchsia": true, "gainsboro": true,
+	"ghostwhite": true, "gold": true, "goldenrod": true, "gray": true, "grey": true,
+	"green": true, "greenyellow": true, "honeydew": true, "hotpink": true, "indianred": true,
+	"indigo": true, "ivory": true, "khaki": true, "lavender": true, "lavenderblush": true,
+	"lawngreen": true, "lemonchiffon": true, "lightblue": true, "lightcoral": true,
+	"lightcyan": true, "lightgoldenrod": true, "lightgoldenrodyellow": true, "lightgray": true,
+	"lightgreen": true, "lightgrey": true, "lightpink": true, "lightsalmon": true,
+	"lightseagreen": true, "lightskyblue": true, "lightslateblue": true, "lightslategray": true,
+	"lightslategrey": true, "lightsteelblue": true, "lightyellow": true, "lime": true,
+	"limegreen": true, "linen": true, "magenta": true, "maroon": true, "mediumaquamarine": true,
+	"mediumblue": true, "mediumorchid": true, "mediumpurple": true, "mediumseagreen": true,
+	"mediumslateblue": true, "mediumspringgreen": true, "mediumturquoise": true,
+	"mediumvioletred": true, "midnightblue": true, "mintcream": true, "mistyrose": true,
+	"moccasin": true, "navajowhite": true, "navy": true, "navyblue": true, "none": true,
+	"oldlace": true, "olive": true, "olivedrab": true, "orange": true, "orangered": true,
+	"orchid": true, "palegoldenrod": true, "palegreen": true, "paleturquoise": true,
+	"palevioletred": true, "papayawhip": true, "peachpuff": true, "peru": true, "pink": true,
+	"plum": true, "powderblue": true, "purple": true, "rebeccapurple": true, "red": true,
+	"rosybrown": true, "royalblue": true, "saddlebrown": true, "salmon": true,
+	"sandybrown": true, "seagreen": true, "seashell": true, "sienna": true, "silver": true,
+	"skyblue": true, "slateblue": true, "slategray": true, "slategrey": true, "snow": true,
+	"springgreen": true, "steelblue": true, "tan": true, "teal": true, "thistle": true,
+	"tomato": true, "transparent": true, "turquoise": true, "violet": true, "violetred": true,
+	"wheat": true, "white": true, "whitesmoke": true, "yellow": true, "yellowgreen": true,
+}
+
+var (
+	// hexColor matches #rgb, #rrggbb and #rrggbbaa colours.
+	hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+	// nameColor matches anything that could be a colour name, with any numeric suffix.
+	nameColor = regexp.MustCompile(`^[a-z]+[0-9]*$`)
+
+	// numberedColor matches a colour name with a numeric suffix, as used by Graphviz.
+	numberedColor = regexp.MustCompile(`^([a-z]+)[0-9]{1,3}$`)
+
+	// schemeColor matches a Graphviz colour from a named colour scheme, such as /blues9/3 or
+	// /x11/red. The scheme may be left empty to use the default.
+	schemeColor = regexp.MustCompile(`^/[a-z0-9]*/[a-z0-9]+$`)
+
+	// functionColor matches CSS colour functions, as used by Mermaid.
+	functionColor = regexp.MustCompile(`^(rgb|rgba|hsl|hsla)\([^()]*\)$`)
+
+	// hsvColor matches a Graphviz HSV colour: three numbers between 0 and 1.
+	hsvColor = regexp.MustCompile(`^` + unitNumber + `([ ,]+` + unitNumber + `){2}$`)
+)
+
+// unitNumber matches a decimal number between 0 and 1.
+const unitNumber = `(0(\.[0-9]+)?|1(\.0+)?|\.[0-9]+)`
+
+// ErrUnknownColor is returned (wrapped) by ValidateColor for a colour that looks like a name,
+// but isn't one known to task-graph. Graphviz and browsers know of others, so it may still be
+// valid.
+var ErrUnknownColor = errors.New("not a colour name known to task-graph, so passed on as given")
+
+// ValidateColor returns an error if color is not a colour that can be used in the output.
+// Hex colours, named colours, Graphviz scheme colours, CSS colour functions and Graphviz HSV
+// colours are accepted. A Graphviz colour list (colours separated by ':', each optionally
+// followed by ';' and a weight) is accepted if each colour in it is valid. A name that isn't
+// known gives an error wrapping ErrUnknownColor, which need not be fatal.
+func ValidateColor(color string) error {
+	unknown := false
+
+	for part := range strings.SplitSeq(color, ":") {
+		name, _, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+
+		switch {
+		case isColor(name):
+			continue
+		case nameColor.MatchString(strings.ToLower(name)):
+			unknown = true
+		default:
+			return eris.Errorf("invalid colour %q", color)
+		}
+	}
+
+	if unknown {
+		return eris.Wrapf(ErrUnknownColor, "unknown colour %q", color)
+	}
+
+	return nil
+}
+
+// isColor returns true if s is a single valid colour.
+func isColor(s string) bool {
+	lower := strings.ToLower(s)
+
+	if colorNames[lower] || hexColor.MatchString(s) || functionColor.MatchString(lower) {
+		return true
+	}
+
+	if match := numberedColor.FindStringSubmatch(lower); match != nil && colorNames[match[1]] {
+		return true
+	}
+
+	return schemeColor.MatchString(lower) || hsvColor.MatchString(s)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateColor(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		color   string
+		valid   bool
+		unknown bool
+	}{
+		"named":                   {color: "red", valid: true},
+		"named mixed case":        {color: "DarkGreen", valid: true},
+		"numbered":                {color: "gray42", valid: true},
+		"short hex":               {color: "#f90", valid: true},
+		"hex":                     {color: "#ff9900", valid: true},
+		"hex with alpha":          {color: "#ff990080", valid: true},
+		"css function":            {color: "rgb(255, 153, 0)", valid: true},
+		"hsv":                     {color: "0.650 0.700 0.700", valid: true},
+		"hsv with commas":         {color: "0,1.0,.5", valid: true},
+		"scheme":                  {color: "/blues9/3", valid: true},
+		"x11 scheme":              {color: "/x11/red", valid: true},
+		"default scheme":          {color: "//red", valid: true},
+		"color list":              {color: "red;0.3:blue", valid: true},
+		"unknown name":            {color: "bluish", unknown: true},
+		"numbered unknown":        {color: "bluish3", unknown: true},
+		"list with unknown":       {color: "red:bluish", unknown: true},
+		"bad hex":                 {color: "#ff99"},
+		"hsv out of range":        {color: "1.5 0.5 0.5"},
+		"hsv with bare points":    {color: ". . ."},
+		"hsv too few numbers":     {color: "0.5 0.5"},
+		"scheme without name":     {color: "/blues9/"},
+		"list with bad hex":       {color: "red:#ff99"},
+		"unknown and bad in list": {color: "bluish:#ff99"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			err := ValidateColor(c.color)
+
+			// Assert
+			switch {
+			case c.valid:
+				g.Expect(err).NotTo(HaveOccurred())
+			case c.unknown:
+				g.Expect(err).To(MatchError(ErrUnknownColor))
+			default:
+				g.Expect(err).To(MatchError(ContainSubstring("invalid colour")))
+				g.Expect(err).NotTo(MatchError(ErrUnknownColor))
+			}
+		})
+	}
+}
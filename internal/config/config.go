@@ -1,14 +1,57 @@
 package config
 
+import "time"
+
+// AutoColorModeNamespace, AutoColorModeHierarchy and AutoColorModeDuration are the supported
+// AutoColorMode values.
+const (
+	AutoColorModeNamespace = "namespace"
+	AutoColorModeHierarchy = "hierarchy"
+	AutoColorModeDuration  = "duration"
+)
+
+// GraphTypes are the supported GraphType values.
+var GraphTypes = []string{"dot", "mermaid", "gantt"}
+
+// RendererAuto, RendererGraphviz, RendererMermaid and RendererBuiltin are the supported Renderer
+// values.
+const (
+	RendererAuto     = "auto"
+	RendererGraphviz = "graphviz"
+	RendererMermaid  = "mermaid"
+	RendererBuiltin  = "builtin"
+)
+
+// Renderers are the supported Renderer values.
+var Renderers = []string{RendererAuto, RendererGraphviz, RendererMermaid, RendererBuiltin}
+
+// DefaultRenderTimeout is how long to wait for dot or mermaid-cli to render images when
+// RenderTimeout is not set.
+const DefaultRenderTimeout = time.Minute
+
+// AutoColorModes are the supported AutoColorMode values.
+var AutoColorModes = []string{AutoColorModeNamespace, AutoColorModeHierarchy, AutoColorModeDuration}
+
+// Config is the configuration for task-graph, usually loaded from a YAML or JSON file.
 type Config struct {
+	// Extends lists other config files that this one builds on, relative to this file. They are
+	// loaded in order, each overriding the ones before, and then this file overrides them all.
+	// Settings are merged field by field, while NodeStyleRules and EdgeStyleRules are
+	// concatenated, so shared rules come first.
+	Extends []string `json:"extends,omitempty" yaml:"extends,omitempty"`
+
 	// GroupByNamespace controls whether tasks in the same namespace are grouped together
 	// in the output. Namespace is defined by a common prefix prior to a colon (`:`).
 	GroupByNamespace bool `json:"groupByNamespace,omitempty" yaml:"groupByNamespace,omitempty"`
 
-	// GraphType is the type of graph to generate. Valid values: dot, mermaid.
+	// GraphType is the type of graph to generate. Valid values: dot, mermaid, gantt.
 	// Defaults to "dot" when not specified.
 	GraphType string `json:"graphType,omitempty" yaml:"graphType,omitempty"`
 
+	// GanttTask is the task whose execution is simulated for a Mermaid Gantt chart.
+	// Required when GraphType is gantt.
+	GanttTask string `json:"ganttTask,omitempty" yaml:"ganttTask,omitempty"`
+
 	// HighlightColor is the fill color used for highlighted task nodes (from --highlight flag).
 	// Defaults to "yellow" when not specified.
 	HighlightColor string `json:"highlightColor,omitempty" yaml:"highlightColor,omitempty"`
@@ -18,29 +61,111 @@ type Config struct {
 	// namespace found in the taskfile. User-defined NodeStyleRules take precedence.
 	AutoColor bool `json:"autoColor,omitempty" yaml:"autoColor,omitempty"`
 
+	// AutoColorMode selects how nodes are coloured when AutoColor is true.
+	// Valid values: namespace (a distinct colour per namespace), hierarchy (a distinct colour
+	// per top-level namespace, with lighter or darker tints of it for nested namespaces),
+	// duration (a heat-map based on measured task durations). Defaults to "namespace" when not
+	// specified. Namespace text is black or white, whichever contrasts more with the fill.
+	AutoColorMode string `json:"autoColorMode,omitempty" yaml:"autoColorMode,omitempty"`
+
 	// ColorblindMode selects an accessibility-optimised colour palette (Okabe-Ito) for
 	// auto-colouring instead of the default one. It has no effect unless AutoColor is
 	// also true.
 	ColorblindMode bool `json:"colorblindMode,omitempty" yaml:"colorblindMode,omitempty"`
 
+	// AutoColorPalette is the list of colours used to colour namespaces, in place of the
+	// built-in palette (or the one chosen by ColorblindMode).
+	AutoColorPalette []string `json:"autoColorPalette,omitempty" yaml:"autoColorPalette,omitempty"`
+
+	// AutoColorPins maps namespaces to the colour they are always given when colouring by
+	// namespace, such as build: "#1f78b4". In hierarchy mode, nested namespaces are tinted from
+	// the pinned colour. Other namespaces avoid pinned colours while the palette allows.
+	AutoColorPins map[string]string `json:"autoColorPins,omitempty" yaml:"autoColorPins,omitempty"`
+
 	// IncludeGlobalVars controls whether global Taskfile variables are included
 	// as nodes in the generated graph, with edges to the tasks that reference them.
 	IncludeGlobalVars bool `json:"includeGlobalVars,omitempty" yaml:"includeGlobalVars,omitempty"`
 
+	// Durations is the path to a file of measured task durations, shown in node labels.
+	// This may be the output of `task --verbose` with each line prefixed by a timestamp,
+	// or a JSON or CSV file mapping task names to durations.
+	Durations string `json:"durations,omitempty" yaml:"durations,omitempty"`
+
+	// CriticalPath controls whether the critical path is highlighted: the chain of tasks that
+	// takes longest to run, with called tasks running one after another. Requires Durations.
+	CriticalPath bool `json:"criticalPath,omitempty" yaml:"criticalPath,omitempty"`
+
+	// CriticalPathColor is the colour used to highlight the critical path.
+	// Defaults to "red" when not specified.
+	CriticalPathColor string `json:"criticalPathColor,omitempty" yaml:"criticalPathColor,omitempty"`
+
+	// Legend controls whether a legend is included in the output, explaining the node colours,
+	// node shapes and edge styles used in the graph. Only named NodeStyleRules are shown.
+	Legend bool `json:"legend,omitempty" yaml:"legend,omitempty"`
+
+	// Links adds links and tooltips to task nodes, making rendered SVG output navigable.
+	Links *Links `json:"links,omitempty" yaml:"links,omitempty"`
+
 	// NodeStyleRules are additional style rules applied to matching task nodes, in order.
 	// All matching rules are applied; in case of conflicts, the last matching rule wins.
 	// These rules work across all graph types.
 	NodeStyleRules []NodeStyleRule `json:"nodeStyleRules,omitempty" yaml:"nodeStyleRules,omitempty"`
 
+	// EdgeStyleRules are style rules applied to matching edges, in order.
+	// All matching rules are applied; in case of conflicts, the last matching rule wins.
+	// These rules work across all graph types.
+	EdgeStyleRules []EdgeStyleRule `json:"edgeStyleRules,omitempty" yaml:"edgeStyleRules,omitempty"`
+
+	// Theme is the name of the theme setting fonts, colours and other presentation for both
+	// Graphviz and Mermaid output: one of light, dark, monochrome-print, high-contrast or
+	// presentation, or a theme defined in Themes. Other settings in the config override it.
+	Theme string `json:"theme,omitempty" yaml:"theme,omitempty"`
+
+	// Themes are additional named themes, available to choose with Theme. A theme with the
+	// name of a built-in theme replaces it.
+	Themes map[string]Theme `json:"themes,omitempty" yaml:"themes,omitempty"`
+
 	// Graphviz is the configuration for the Graphviz dot output.
 	Graphviz *Graphviz `json:"graphviz,omitempty" yaml:"graphviz,omitempty"`
 
 	// Mermaid is the configuration for the Mermaid flowchart output.
 	Mermaid *Mermaid `json:"mermaid,omitempty" yaml:"mermaid,omitempty"`
 
+	// Profiles are named sets of options, each producing one output. When there is no --output,
+	// every profile (or those chosen with --profile) is produced in a single run.
+	Profiles map[string]Profile `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// Remote is the configuration for resolving remote includes.
+	Remote *Remote `json:"remote,omitempty" yaml:"remote,omitempty"`
+
+	// Renderer selects how images are rendered. Valid values: auto (mermaid-cli for mermaid and
+	// gantt graphs; otherwise Graphviz dot if it can be found, else the built-in renderer for svg
+	// and png), graphviz (always dot), mermaid (always mermaid-cli, drawing a Mermaid flowchart or
+	// gantt chart), builtin (always the built-in renderer, which draws svg and png without
+	// Graphviz). Defaults to auto.
+	Renderer string `json:"renderer,omitempty" yaml:"renderer,omitempty"`
+
+	// RenderTimeout is how long to wait for dot or mermaid-cli to render images before giving up,
+	// such as 30s or 2m. Defaults to 1m.
+	RenderTimeout string `json:"renderTimeout,omitempty" yaml:"renderTimeout,omitempty"`
+
 	// DotPath is the path to the dot executable, or the folder containing it.
 	// If not specified, dot will be looked up on the PATH.
 	DotPath string `json:"dotPath,omitempty" yaml:"dotPath,omitempty"`
+
+	// MmdcPath is the path to the mmdc executable of mermaid-cli, or the folder containing it.
+	// If not specified, mmdc will be looked up on the PATH.
+	MmdcPath string `json:"mmdcPath,omitempty" yaml:"mmdcPath,omitempty"`
+}
+
+// RenderTimeoutOrDefault returns how long to wait for dot or mermaid-cli to render images.
+func (c *Config) RenderTimeoutOrDefault() time.Duration {
+	timeout, err := time.ParseDuration(c.RenderTimeout)
+	if err != nil || timeout <= 0 {
+		return DefaultRenderTimeout
+	}
+
+	return timeout
 }
 
 // New creates a new Config with default values.
@@ -82,6 +82,17 @@ func TestNew_NodeStyleRules_ReturnsEmpty(t *testing.T) {
 	g.Expect(cfg.NodeStyleRules).To(gomega.BeEmpty())
 }
 
+func TestNew_EdgeStyleRules_ReturnsEmpty(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Act
+	cfg := New()
+
+	// Assert
+	g.Expect(cfg.EdgeStyleRules).To(gomega.BeEmpty())
+}
+
 func TestNew_GroupByNamespace_ReturnsFalse(t *testing.T) {
 	t.Parallel()
 	g := gomega.NewWithT(t)
@@ -100,6 +111,45 @@ func TestNew_AutoColor_ReturnsFalse(t *testing.T) {
 	g.Expect(cfg.AutoColor).To(gomega.BeFalse())
 }
 
+func TestValidate_InvalidAutoColorPaletteAndPins_ReportsEach(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	cfg.AutoColorMode = AutoColorModeHierarchy
+	cfg.AutoColorPalette = []string{"#1f78b4", "#bluish"}
+	cfg.AutoColorPins = map[string]string{"build": "navy", "test": "#12345"}
+
+	// Act
+	problems := cfg.Validate()
+
+	// Assert
+	g.Expect(problems).To(gomega.HaveLen(2))
+	g.Expect(problems[0].Path).To(gomega.Equal("autoColorPalette[1]"))
+	g.Expect(problems[1].Path).To(gomega.Equal("autoColorPins.test"))
+}
+
+func TestValidate_UnknownColourName_IsWarningNotProblem(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	cfg.HighlightColor = "bluish"
+	cfg.CriticalPathColor = "/blues9/3"
+
+	// Act
+	problems := cfg.Validate()
+	warnings := cfg.Warnings()
+
+	// Assert
+	g.Expect(problems).To(gomega.BeEmpty())
+	g.Expect(warnings).To(gomega.HaveLen(1))
+	g.Expect(warnings[0].Path).To(gomega.Equal("highlightColor"))
+	g.Expect(warnings[0].Message).To(gomega.ContainSubstring(`unknown colour "bluish"`))
+}
+
 func TestNodeStyleRule_Fields_RoundTrip(t *testing.T) {
 	t.Parallel()
 	g := gomega.NewWithT(t)
@@ -0,0 +1,38 @@
+package config
+
+import "github.com/theunrepentantgeek/task-graph/internal/graph"
+
+// EdgeClasses are the supported EdgeStyleRule.Class values.
+var EdgeClasses = []string{graph.EdgeClassDep, graph.EdgeClassCall, graph.EdgeClassVar}
+
+// EdgeStyleRule defines a style rule that is applied to edges selected by the given criteria.
+// All selection criteria that are specified must be satisfied for the rule to apply; a rule
+// with no criteria applies to every edge.
+// These rules work across all graph types (dot, mermaid, etc.).
+type EdgeStyleRule struct {
+	// From is the pattern used to match the name of the node the edge starts from.
+	// Supports wildcards (* and ?).
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+
+	// To is the pattern used to match the name of the node the edge points to.
+	// Supports wildcards (* and ?).
+	To string `json:"to,omitempty" yaml:"to,omitempty"`
+
+	// Class selects edges of the given class. Valid values: dep, call, var.
+	Class string `json:"class,omitempty" yaml:"class,omitempty"`
+
+	// CrossesNamespace selects only edges whose endpoints are in different namespaces.
+	CrossesNamespace bool `json:"crossesNamespace,omitempty" yaml:"crossesNamespace,omitempty"`
+
+	// Color is the color of the edge.
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+
+	// Style is the style of the edge (e.g., "solid", "dashed", "dotted", "bold").
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+
+	// Width is the width of the edge. It can be any positive integer.
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+
+	// Label is the text shown alongside the edge, replacing any existing label.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+}
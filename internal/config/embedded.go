@@ -0,0 +1,78 @@
+package config
+
+import (
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// TaskfileKey is the top-level key of a block of config embedded in a Taskfile. Task ignores
+// top-level keys starting with x-, so config can live alongside the tasks it describes.
+const TaskfileKey = "x-task-graph"
+
+// LoadEmbedded reads the config embedded under TaskfileKey in a Taskfile into cfg, overriding
+// any values already set, in the same way as Load. content is the Taskfile, read from path;
+// files listed by extends are relative to it. It does nothing if there is no embedded config.
+func LoadEmbedded(path string, content []byte, cfg *Config, sources Sources) error {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return diagnostic.New(path, content, diagnostic.FromYAMLError(err))
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == TaskfileKey {
+			return loadNode(path, content, root.Content[i+1], cfg, sources, nil)
+		}
+	}
+
+	return nil
+}
+
+// ScopedRules returns the style rules of the config, changed to apply only within the namespace
+// ns, for config embedded in an included Taskfile. Patterns are relative to the namespace, and
+// an edge rule without any pattern applies to edges from tasks in the namespace. The rules are
+// returned unchanged if ns is empty.
+func (c *Config) ScopedRules(ns string) ([]NodeStyleRule, []EdgeStyleRule) {
+	if ns == "" {
+		return slices.Clone(c.NodeStyleRules), slices.Clone(c.EdgeStyleRules)
+	}
+
+	prefix := namespace.QuoteMatchPattern(ns + ":")
+
+	scope := func(pattern string) string {
+		if pattern == "" {
+			return ""
+		}
+
+		return prefix + pattern
+	}
+
+	nodeRules := make([]NodeStyleRule, 0, len(c.NodeStyleRules))
+	for _, rule := range c.NodeStyleRules {
+		rule.Match = scope(rule.Match)
+		nodeRules = append(nodeRules, rule)
+	}
+
+	edgeRules := make([]EdgeStyleRule, 0, len(c.EdgeStyleRules))
+	for _, rule := range c.EdgeStyleRules {
+		if rule.From == "" && rule.To == "" {
+			rule.From = "*"
+		}
+
+		rule.From = scope(rule.From)
+		rule.To = scope(rule.To)
+		edgeRules = append(edgeRules, rule)
+	}
+
+	return nodeRules, edgeRules
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+func TestLoadEmbedded_ReadsBlockFromTaskfile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\n" +
+		"x-task-graph:\n" +
+		"  graphType: mermaid\n" +
+		"  nodeStyleRules:\n" +
+		"    - match: build\n" +
+		"      color: red\n" +
+		"tasks:\n" +
+		"  build: {}\n"
+
+	cfg := New()
+	sources := Sources{}
+
+	// Act
+	err := LoadEmbedded("Taskfile.yml", []byte(content), cfg, sources)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.GraphType).To(Equal("mermaid"))
+	g.Expect(cfg.NodeStyleRules).To(ConsistOf(NodeStyleRule{Match: "build", Color: "red"}))
+	g.Expect(sources.Lookup("graphType")).To(Equal("Taskfile.yml:3"))
+}
+
+func TestLoadEmbedded_WithoutBlock_KeepsDefaults(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+
+	// Act
+	err := LoadEmbedded("Taskfile.yml", []byte("version: '3'\ntasks: {}\n"), cfg, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(Equal(New()))
+}
+
+func TestLoadEmbedded_InvalidValue_ReportsPositionInTaskfile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "Taskfile.yml")
+	content := "version: '3'\ntasks: {}\nx-task-graph:\n  graphType: svg\n"
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	// Act
+	err := LoadEmbedded(path, []byte(content), New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].File).To(Equal(path))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics[0].Line).To(Equal(4))
+}
+
+func TestScopedRules_PrefixesPatternsWithNamespace(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := &Config{
+		NodeStyleRules: []NodeStyleRule{{Match: "build*", Color: "red"}},
+		EdgeStyleRules: []EdgeStyleRule{
+			{From: "build", To: "lint", Color: "blue"},
+			{To: "test", Color: "green"},
+			{Color: "gray"},
+		},
+	}
+
+	// Act
+	nodes, edges := cfg.ScopedRules("docs")
+
+	// Assert
+	g.Expect(nodes).To(ConsistOf(NodeStyleRule{Match: "docs:build*", Color: "red"}))
+	g.Expect(edges).To(Equal([]EdgeStyleRule{
+		{From: "docs:build", To: "docs:lint", Color: "blue"},
+		{To: "docs:test", Color: "green"},
+		{From: "docs:*", Color: "gray"},
+	}))
+}
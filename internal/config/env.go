@@ -0,0 +1,189 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+// EnvPrefix is the prefix of the environment variables that override config values.
+const EnvPrefix = "TASK_GRAPH_"
+
+// envSetting is a config value that can be set by an environment variable.
+type envSetting struct {
+	path  string
+	index []int
+	kind  reflect.Kind
+}
+
+// ApplyEnv overrides config values from TASK_GRAPH_* environment variables, given as KEY=value
+// strings as returned by os.Environ. Every setting with a single value can be overridden, by a
+// variable named after its path: graphviz.fontSize is set by TASK_GRAPH_GRAPHVIZ_FONT_SIZE.
+// Invalid values are all returned together as a *diagnostic.Error. Unknown variables are
+// ignored, as they may be meant for another version of task-graph; UnknownEnv finds them. If
+// sources is not nil, the variable setting each value is recorded.
+func ApplyEnv(cfg *Config, environ []string, sources Sources) error {
+	settings := envSettings()
+
+	var problems []diagnostic.Diagnostic
+
+	set := make(map[string]string)
+
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+
+		setting, ok := settings[name]
+		if !ok {
+			continue
+		}
+
+		err := setting.apply(reflect.ValueOf(cfg).Elem(), value)
+		if err != nil {
+			problems = append(problems, diagnostic.Diagnostic{Message: "$" + name + ": " + err.Error()})
+
+			continue
+		}
+
+		set[setting.path] = name
+		sources.Set(setting.path, "$"+name)
+	}
+
+	for _, problem := range cfg.Validate() {
+		if name, ok := set[problem.Path]; ok {
+			problems = append(problems, diagnostic.Diagnostic{Message: "$" + name + ": " + problem.Message})
+		}
+	}
+
+	return diagnostic.New("", nil, problems)
+}
+
+// UnknownEnv returns the names of the TASK_GRAPH_* variables in environ, given as KEY=value
+// strings as returned by os.Environ, that don't name a setting, in order.
+func UnknownEnv(environ []string) []string {
+	settings := envSettings()
+
+	var result []string
+
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if _, ok := settings[name]; strings.HasPrefix(name, EnvPrefix) && !ok {
+			result = append(result, name)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// envSettings returns the settings that can be set by environment variables, keyed by the
+// name of the variable.
+func envSettings() map[string]envSetting {
+	result := make(map[string]envSetting)
+	addEnvSettings(result, reflect.TypeFor[Config](), "", nil)
+
+	return result
+}
+
+// EnvName returns the name of the environment variable that sets the config value at path.
+func EnvName(path string) string {
+	var b strings.Builder
+
+	b.WriteString(EnvPrefix)
+
+	for i, r := range path {
+		switch {
+		case r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0:
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return b.String()
+}
+
+// addEnvSettings adds a setting for each single-valued field of the struct type t, recursing
+// into nested structs. path and index locate values of type t within the Config.
+func addEnvSettings(settings map[string]envSetting, t reflect.Type, path string, index []int) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		fieldPath := joinPath(path, name)
+		fieldIndex := append(slices.Clone(index), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			addEnvSettings(settings, fieldType, fieldPath, fieldIndex)
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+			settings[EnvName(fieldPath)] = envSetting{
+				path:  fieldPath,
+				index: fieldIndex,
+				kind:  fieldType.Kind(),
+			}
+		default:
+			// Lists and maps can only be set in a config file
+		}
+	}
+}
+
+// apply parses value and sets it within cfg, creating any nested structs required.
+func (s envSetting) apply(cfg reflect.Value, value string) error {
+	v := cfg
+	for _, i := range s.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	switch s.kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return eris.Errorf("invalid boolean %q, must be true or false", value)
+		}
+
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return eris.Errorf("invalid integer %q", value)
+		}
+
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return eris.Errorf("invalid number %q", value)
+		}
+
+		v.SetFloat(f)
+	default:
+		v.SetString(value)
+	}
+
+	return nil
+}
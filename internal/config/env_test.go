@@ -0,0 +1,114 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+func TestEnvName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"autoColor":                    "TASK_GRAPH_AUTO_COLOR",
+		"dotPath":                      "TASK_GRAPH_DOT_PATH",
+		"graphviz.fontSize":            "TASK_GRAPH_GRAPHVIZ_FONT_SIZE",
+		"graphviz.taskNodes.fillColor": "TASK_GRAPH_GRAPHVIZ_TASK_NODES_FILL_COLOR",
+	}
+
+	for path, expected := range cases {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			result := EnvName(path)
+
+			// Assert
+			g.Expect(result).To(Equal(expected))
+		})
+	}
+}
+
+func TestApplyEnv_SetsValuesAndRecordsSources(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	sources := Sources{}
+
+	// Act
+	err := ApplyEnv(
+		cfg,
+		[]string{
+			"HOME=/home/user",
+			"TASK_GRAPH_AUTO_COLOR=true",
+			"TASK_GRAPH_GRAPHVIZ_FONT_SIZE=20",
+			"TASK_GRAPH_GRAPHVIZ_RANK_SEP=1.5",
+			"TASK_GRAPH_REMOTE_CACHE_DIR=/tmp/cache",
+		},
+		sources)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AutoColor).To(BeTrue())
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(20))
+	g.Expect(cfg.Graphviz.RankSep).To(Equal(1.5))
+	g.Expect(cfg.Remote.CacheDir).To(Equal("/tmp/cache"))
+	g.Expect(sources.Lookup("graphviz.fontSize")).To(Equal("$TASK_GRAPH_GRAPHVIZ_FONT_SIZE"))
+}
+
+func TestApplyEnv_InvalidValues_ReportsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := ApplyEnv(
+		New(),
+		[]string{
+			"TASK_GRAPH_LEGEND=maybe",
+			"TASK_GRAPH_HIGHLIGHT_COLOR=#notahex",
+		},
+		nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(2))
+	g.Expect(diags[0].Diagnostics[0].Message).To(HavePrefix("$TASK_GRAPH_LEGEND: invalid boolean"))
+	g.Expect(diags[0].Diagnostics[1].Message).To(HavePrefix("$TASK_GRAPH_HIGHLIGHT_COLOR: invalid colour"))
+}
+
+func TestApplyEnv_UnknownVariable_IsIgnored(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+
+	// Act
+	err := ApplyEnv(cfg, []string{"TASK_GRAPH_AUTO_COLOUR=true"}, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(Equal(New()))
+}
+
+func TestUnknownEnv_ListsOnlyUnknownVariables(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	unknown := UnknownEnv([]string{
+		"TASK_GRAPH_LEGEND=true",
+		"TASK_GRAPH_ZOOM=2",
+		"TASK_GRAPH_AUTO_COLOUR=true",
+		"HOME=/root",
+	})
+
+	// Assert
+	g.Expect(unknown).To(Equal([]string{"TASK_GRAPH_AUTO_COLOUR", "TASK_GRAPH_ZOOM"}))
+}
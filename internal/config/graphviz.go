@@ -1,5 +1,44 @@
 package config
 
+// GraphvizNodeStyles are the Graphviz styles for nodes; several may be combined, separated by commas.
+// https://graphviz.org/docs/attr-types/style/
+var GraphvizNodeStyles = []string{
+	"solid", "dashed", "dotted", "bold", "rounded", "diagonals", "filled", "striped", "wedged", "radial", "invis",
+}
+
+// GraphvizEdgeStyles are the Graphviz styles for edges; several may be combined, separated by commas.
+// https://graphviz.org/docs/attr-types/style/
+var GraphvizEdgeStyles = []string{"solid", "dashed", "dotted", "bold", "tapered", "invis"}
+
+// GraphvizClusterStyles are the Graphviz styles for clusters; several may be combined, separated by
+// commas.
+// https://graphviz.org/docs/attr-types/style/
+var GraphvizClusterStyles = []string{
+	"solid", "dashed", "dotted", "bold", "rounded", "filled", "striped", "radial", "invis",
+}
+
+// GraphvizRankDirs are the supported directions of a Graphviz graph.
+// https://graphviz.org/docs/attr-types/rankdir/
+var GraphvizRankDirs = []string{"TB", "LR", "BT", "RL"}
+
+// GraphvizSplines are the supported ways of drawing Graphviz edges.
+// https://graphviz.org/docs/attrs/splines/
+var GraphvizSplines = []string{"spline", "line", "polyline", "ortho", "curved", "none"}
+
+// GraphvizLayouts are the Graphviz layout engines that can be used to render images.
+// https://graphviz.org/docs/layouts/
+var GraphvizLayouts = []string{"dot", "neato", "fdp", "sfdp", "circo", "twopi", "osage", "patchwork"}
+
+// NodeLabelsRecord and NodeLabelsHTML are the supported NodeLabels values.
+const (
+	NodeLabelsRecord = "record"
+	NodeLabelsHTML   = "html"
+)
+
+// GraphvizNodeLabels are the supported ways of labelling Graphviz task nodes.
+var GraphvizNodeLabels = []string{NodeLabelsRecord, NodeLabelsHTML}
+
+// Graphviz holds configuration specific to Graphviz dot output.
 type Graphviz struct {
 	// Font is the font used for labels in the Graphviz output. It can be any valid Graphviz font.
 	// https://graphviz.org/docs/attrs/fontname/
@@ -9,6 +48,56 @@ type Graphviz struct {
 	// https://graphviz.org/docs/attrs/fontsize/
 	FontSize int `json:"fontSize,omitempty" yaml:"fontSize,omitempty"`
 
+	// FontColor is the colour of text that is not otherwise styled, such as cluster and edge
+	// labels. It can be any valid Graphviz color.
+	// https://graphviz.org/docs/attrs/fontcolor/
+	FontColor string `json:"fontColor,omitempty" yaml:"fontColor,omitempty"`
+
+	// Background is the background colour of the graph. It can be any valid Graphviz color.
+	// https://graphviz.org/docs/attrs/bgcolor/
+	Background string `json:"background,omitempty" yaml:"background,omitempty"`
+
+	// RankDir is the direction in which the graph is laid out. Valid values: TB (top to bottom),
+	// LR (left to right), BT (bottom to top), RL (right to left). Defaults to TB.
+	// https://graphviz.org/docs/attrs/rankdir/
+	RankDir string `json:"rankDir,omitempty" yaml:"rankDir,omitempty"`
+
+	// Splines controls how edges are drawn. Valid values: spline, line, polyline, ortho, curved,
+	// none. Defaults to spline.
+	// https://graphviz.org/docs/attrs/splines/
+	Splines string `json:"splines,omitempty" yaml:"splines,omitempty"`
+
+	// NodeSep is the minimum space between adjacent nodes in the same rank, in inches.
+	// https://graphviz.org/docs/attrs/nodesep/
+	NodeSep float64 `json:"nodeSep,omitempty" yaml:"nodeSep,omitempty"`
+
+	// RankSep is the minimum space between ranks, in inches.
+	// https://graphviz.org/docs/attrs/ranksep/
+	RankSep float64 `json:"rankSep,omitempty" yaml:"rankSep,omitempty"`
+
+	// Concentrate merges edges that share an end point into a single line where possible,
+	// reducing clutter in dense graphs.
+	// https://graphviz.org/docs/attrs/concentrate/
+	Concentrate bool `json:"concentrate,omitempty" yaml:"concentrate,omitempty"`
+
+	// Layout is the Graphviz layout engine used to render images. Valid values: dot, neato, fdp,
+	// sfdp, circo, twopi, osage, patchwork. Defaults to dot.
+	// https://graphviz.org/docs/layouts/
+	Layout string `json:"layout,omitempty" yaml:"layout,omitempty"`
+
+	// NodeLabels is how task nodes are labelled. Valid values: record (the default; the name,
+	// duration and description in a rounded record) or html (an HTML-like table laid out by
+	// LabelTemplate).
+	// https://graphviz.org/doc/info/shapes.html#html
+	NodeLabels string `json:"nodeLabels,omitempty" yaml:"nodeLabels,omitempty"`
+
+	// LabelTemplate is the Go template producing the HTML-like label of each task node when
+	// NodeLabels is html, in place of the built-in layout. Text fields such as {{.Name}},
+	// {{.Description}} and {{.Aliases}} are already escaped; {{.Sources}}, {{.Generates}},
+	// {{.Internal}}, {{.Prompt}}, {{.Namespace}}, {{.Duration}}, {{.Color}} and {{.BorderColor}}
+	// are also available, as is a join function.
+	LabelTemplate string `json:"labelTemplate,omitempty" yaml:"labelTemplate,omitempty"`
+
 	// DependencyEdges is the presentation for dependency edges between tasks
 	DependencyEdges *GraphvizEdge `json:"dependencyEdges,omitempty" yaml:"dependencyEdges,omitempty"`
 
@@ -23,8 +112,18 @@ type Graphviz struct {
 
 	// VariableEdges is the presentation for edges from variables to tasks
 	VariableEdges *GraphvizEdge `json:"variableEdges,omitempty" yaml:"variableEdges,omitempty"`
+
+	// Clusters is the presentation for the clusters drawn around each namespace
+	Clusters *GraphvizCluster `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+
+	// RankByWave places tasks in the same execution wave on the same rank, so that each row
+	// of the graph shows tasks that can run concurrently. Only tasks assigned a wave by
+	// execution analysis (--analyze) are affected, and it has no effect when grouping by
+	// namespace, as Graphviz cannot rank nodes across clusters.
+	RankByWave bool `json:"rankByWave,omitempty" yaml:"rankByWave,omitempty"`
 }
 
+// GraphvizNode holds the presentation of a kind of node in Graphviz dot output.
 type GraphvizNode struct {
 	// Color is the color of the node border. It can be any valid Graphviz color.
 	// https://graphviz.org/docs/attrs/color/
@@ -43,6 +142,35 @@ type GraphvizNode struct {
 	FontColor string `json:"fontColor,omitempty" yaml:"fontColor,omitempty"`
 }
 
+// GraphvizCluster holds the presentation of the clusters drawn around namespaces in Graphviz dot
+// output.
+type GraphvizCluster struct {
+	// Color is the color of the cluster border. It can be any valid Graphviz color.
+	// https://graphviz.org/docs/attrs/color/
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+
+	// FillColor is the background color of the cluster. It can be any valid Graphviz color.
+	// https://graphviz.org/docs/attrs/fillcolor/
+	FillColor string `json:"fillColor,omitempty" yaml:"fillColor,omitempty"`
+
+	// Style is the style of the cluster (e.g., "filled", "dashed", "rounded").
+	// https://graphviz.org/docs/attr-types/style/
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+
+	// Font is the font of the cluster label. It can be any valid Graphviz font.
+	// https://graphviz.org/docs/attrs/fontname/
+	Font string `json:"font,omitempty" yaml:"font,omitempty"`
+
+	// FontSize is the size of the cluster label, in points.
+	// https://graphviz.org/docs/attrs/fontsize/
+	FontSize int `json:"fontSize,omitempty" yaml:"fontSize,omitempty"`
+
+	// FontColor is the color of the cluster label. It can be any valid Graphviz color.
+	// https://graphviz.org/docs/attrs/fontcolor/
+	FontColor string `json:"fontColor,omitempty" yaml:"fontColor,omitempty"`
+}
+
+// GraphvizEdge holds the presentation of a kind of edge in Graphviz dot output.
 type GraphvizEdge struct {
 	// Color is the color of the edge. It can be any valid Graphviz color.
 	// https://graphviz.org/docs/attrs/color/
@@ -0,0 +1,25 @@
+package config
+
+// Links adds links and tooltips to task nodes, so that a rendered SVG (or Mermaid chart) can be
+// used to navigate to the definition of each task.
+type Links struct {
+	// URL is the link for each task node, as a Go template. {{.File}} is the path of the
+	// Taskfile defining the task, relative to BaseDir, {{.Line}} its line and {{.Task}} its
+	// name, as in https://github.com/org/repo/blob/main/{{.File}}#L{{.Line}}.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// BaseDir is the directory that {{.File}} is relative to, such as the root of the
+	// repository. A relative path is resolved against the directory of the root Taskfile (or,
+	// when drawing several, the directory containing them all), which is also the default.
+	// Taskfiles outside it are given by their full path.
+	BaseDir string `json:"baseDir,omitempty" yaml:"baseDir,omitempty"`
+
+	// Target is the browser window or frame in which links open, such as _blank for a new
+	// window. Defaults to the same window.
+	// https://graphviz.org/docs/attrs/target/
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+
+	// Tooltips adds a tooltip to each task node, showing its full description and a summary of
+	// its commands.
+	Tooltips bool `json:"tooltips,omitempty" yaml:"tooltips,omitempty"`
+}
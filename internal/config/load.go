@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+// FileNames are the names of the config files found alongside a Taskfile, in order of
+// preference.
+var FileNames = []string{".task-graph.yml", ".task-graph.yaml"}
+
+// Find returns the path of the config file in dir, or "" if there is none.
+func Find(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// Load reads the YAML or JSON config file at path into cfg, overriding any values already set.
+// Any files the config extends are loaded first. Decoding is strict: unknown fields are errors,
+// and every value is validated. All problems found are returned together as a
+// *diagnostic.Error for each file, each problem located within its file. If sources is not nil,
+// the file and line setting each value is recorded.
+func Load(path string, cfg *Config, sources Sources) error {
+	return loadFile(path, cfg, sources, nil)
+}
+
+// loadFile loads the config file at path into cfg; chain holds the absolute paths of the files
+// extending it, so that cycles can be detected.
+func loadFile(path string, cfg *Config, sources Sources, chain []string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return eris.Wrapf(err, "failed to read config file: %s", path)
+	}
+
+	// JSON is a subset of YAML, so both are parsed the same way, giving positions for each value
+	var doc yaml.Node
+
+	err = yaml.Unmarshal(raw, &doc)
+	if err != nil {
+		return diagnostic.New(path, raw, diagnostic.FromYAMLError(err))
+	}
+
+	if doc.Kind == 0 {
+		// Empty file
+		return nil
+	}
+
+	return loadNode(path, raw, &doc, cfg, sources, chain)
+}
+
+// loadNode loads the config held by node, parsed from raw, the content of the file at path.
+func loadNode(path string, raw []byte, node *yaml.Node, cfg *Config, sources Sources, chain []string) error {
+	d := &decoder{positions: make(map[string]*yaml.Node)}
+	d.walk(node, reflect.TypeFor[Config](), "")
+
+	extendsErr := d.extend(path, cfg, sources, chain)
+
+	// List items are added to those already loaded, so are offset by the number already present
+	offsets := map[string]int{
+		"nodeStyleRules": len(cfg.NodeStyleRules),
+		"edgeStyleRules": len(cfg.EdgeStyleRules),
+	}
+
+	d.decode(node, cfg)
+
+	located := make(map[string]*yaml.Node, len(d.positions))
+	for p, node := range d.positions {
+		p = offsetPath(p, offsets)
+		located[p] = node
+
+		// Structs are merged, so only single values and list items come from this file as a whole
+		if node.Kind == yaml.ScalarNode || strings.HasSuffix(p, "]") {
+			sources.Set(p, fmt.Sprintf("%s:%d", path, node.Line))
+		}
+	}
+
+	// Only problems with values from this file are reported; others belong to the files extended
+	for _, problem := range cfg.Validate() {
+		if node, ok := located[problem.Path]; ok {
+			d.addAt(node, problem.String())
+		}
+	}
+
+	return errors.Join(extendsErr, diagnostic.New(path, raw, d.problems))
+}
+
+// decode decodes doc into cfg, merging structs field by field and adding list items to those
+// already present. Values that can't be decoded are reported, but the rest are still decoded.
+func (d *decoder) decode(doc *yaml.Node, cfg *Config) {
+	nodeRules, edgeRules := cfg.NodeStyleRules, cfg.EdgeStyleRules
+	cfg.NodeStyleRules, cfg.EdgeStyleRules = nil, nil
+
+	err := doc.Decode(cfg)
+	if err != nil {
+		for _, problem := range diagnostic.FromYAMLError(err) {
+			problem.Column = d.column(problem.Line)
+			d.problems = append(d.problems, problem)
+		}
+	}
+
+	cfg.NodeStyleRules = append(nodeRules, cfg.NodeStyleRules...)
+	cfg.EdgeStyleRules = append(edgeRules, cfg.EdgeStyleRules...)
+	cfg.Extends = nil
+}
+
+// extend loads each config file listed by extends in the file at path into cfg, returning the
+// problems found in those files. Problems finding them are recorded against this file.
+func (d *decoder) extend(path string, cfg *Config, sources Sources, chain []string) error {
+	node, ok := d.positions["extends"]
+	if !ok {
+		return nil
+	}
+
+	var extends []string
+	if node.Decode(&extends) != nil {
+		// Reported when the whole file is decoded
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return eris.Wrapf(err, "failed to resolve config file path: %s", path)
+	}
+
+	chain = append(slices.Clone(chain), abs)
+
+	var errs []error
+
+	for i, base := range extends {
+		item := d.positions[fmt.Sprintf("extends[%d]", i)]
+
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+
+		if absBase, err := filepath.Abs(base); err == nil && slices.Contains(chain, absBase) {
+			d.addAt(item, fmt.Sprintf("extends[%d]: %s is already being loaded; extends must not form a cycle", i, base))
+
+			continue
+		}
+
+		if _, err := os.Stat(base); err != nil {
+			d.addAt(item, fmt.Sprintf("extends[%d]: config file %s not found", i, base))
+
+			continue
+		}
+
+		errs = append(errs, loadFile(base, cfg, sources, chain))
+	}
+
+	return errors.Join(errs...)
+}
+
+// offsetPath adjusts the index of a list item within path, where the list is one of those in
+// offsets, by the given offset.
+func offsetPath(path string, offsets map[string]int) string {
+	name, rest, ok := strings.Cut(path, "[")
+	if !ok || offsets[name] == 0 {
+		return path
+	}
+
+	index, rest, ok := strings.Cut(rest, "]")
+
+	i, err := strconv.Atoi(index)
+	if !ok || err != nil {
+		return path
+	}
+
+	return fmt.Sprintf("%s[%d]%s", name, i+offsets[name], rest)
+}
+
+// decoder checks the structure of a config document against the Config type, recording the
+// position of each value by its path and any unknown fields.
+type decoder struct {
+	positions map[string]*yaml.Node
+	problems  []diagnostic.Diagnostic
+}
+
+// walk checks node against the type t, where path identifies node within the document.
+func (d *decoder) walk(node *yaml.Node, t reflect.Type, path string) {
+	for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			return
+		}
+	}
+
+	if path != "" {
+		d.positions[path] = node
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		d.walkStruct(node, t, path)
+
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			d.walk(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			d.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// walkStruct checks each key of a mapping against the fields of the struct type t.
+func (d *decoder) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fields := make(map[string]reflect.Type, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+
+		fieldType, ok := fields[key.Value]
+		if !ok {
+			d.addAt(key, unknownField(key.Value, path, fields))
+
+			continue
+		}
+
+		d.walk(node.Content[i+1], fieldType, joinPath(path, key.Value))
+	}
+}
+
+// column returns the column of the only scalar value on the given line, or 0 if there isn't
+// exactly one. YAML decoding errors give only the line of the value that could not be decoded.
+func (d *decoder) column(line int) int {
+	result := 0
+
+	for _, node := range d.positions {
+		if node.Kind != yaml.ScalarNode || node.Line != line {
+			continue
+		}
+
+		if result != 0 {
+			return 0
+		}
+
+		result = node.Column
+	}
+
+	return result
+}
+
+// addAt records a problem at the position of node, if known.
+func (d *decoder) addAt(node *yaml.Node, message string) {
+	problem := diagnostic.Diagnostic{Message: message}
+	if node != nil {
+		problem.Line = node.Line
+		problem.Column = node.Column
+	}
+
+	d.problems = append(d.problems, problem)
+}
+
+// unknownField describes an unknown field, suggesting a known field that differs only by case.
+func unknownField(name string, path string, fields map[string]reflect.Type) string {
+	message := fmt.Sprintf("unknown field %q", name)
+	if path != "" {
+		message += " in " + path
+	}
+
+	for known := range fields {
+		if strings.EqualFold(known, name) {
+			return fmt.Sprintf("%s (did you mean %q?)", message, known)
+		}
+	}
+
+	return message
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
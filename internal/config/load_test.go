@@ -0,0 +1,216 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+func TestLoad_ValidJSON_DecodesValues(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+
+	// Act
+	err := Load(filepath.Join("testdata", "valid.json"), cfg, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.AutoColor).To(BeTrue())
+	g.Expect(cfg.NodeStyleRules).To(HaveLen(1))
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(12))
+	g.Expect(cfg.Graphviz.CallEdges.Color).To(Equal("gray40:red"))
+	// Defaults are kept when not overridden
+	g.Expect(cfg.Graphviz.CallEdges.Style).To(Equal("dashed"))
+}
+
+func TestLoad_EmptyFile_KeepsDefaults(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "empty.yaml")
+	g.Expect(os.WriteFile(path, nil, 0o600)).To(Succeed())
+
+	cfg := New()
+
+	// Act
+	err := Load(path, cfg, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(Equal(New()))
+}
+
+func TestLoad_InvalidFile_ReportsEveryProblemWithPosition(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := Load(filepath.Join("testdata", "invalid.yaml"), New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics).To(Equal([]diagnostic.Diagnostic{
+		{Line: 2, Column: 1, Message: `unknown field "colour"`},
+		{Line: 4, Column: 12, Message: `nodeStyleRules[0].match: failed to compile pattern "build[abc": ` +
+			"error parsing regexp: missing closing ]: `[abc$`"},
+		{Line: 5, Column: 16, Message: `nodeStyleRules[0].fillColor: invalid colour "rgb(1,2"`},
+		{Line: 7, Column: 5, Message: `unknown field "fillcolor" in nodeStyleRules[1] (did you mean "fillColor"?)`},
+		{Line: 9, Column: 12, Message: `edgeStyleRules[0].class: unsupported value "deps", must be one of dep, call, var`},
+		{Line: 11, Column: 13, Message: "cannot unmarshal !!str `big` into int"},
+	}))
+}
+
+func TestLoad_SyntaxError_ReportsLine(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "broken.yaml")
+	g.Expect(os.WriteFile(path, []byte("autoColor: true\nnodeStyleRules: [\n"), 0o600)).To(Succeed())
+
+	// Act
+	err := Load(path, New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].File).To(Equal(path))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics[0].Line).To(BeNumerically(">", 0))
+}
+
+func TestLoad_Extends_MergesFieldByFieldAndConcatenatesRules(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+
+	// Act
+	err := Load(filepath.Join("testdata", "extends", "child.yml"), cfg, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Extends).To(BeEmpty())
+	g.Expect(cfg.AutoColor).To(BeTrue())
+	g.Expect(cfg.HighlightColor).To(Equal("gold"))
+	g.Expect(cfg.Graphviz.Font).To(Equal("Helvetica"))
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(12))
+	g.Expect(cfg.Graphviz.TaskNodes.Color).To(Equal("gray40"))
+	// Defaults not overridden by either file are kept
+	g.Expect(cfg.Graphviz.CallEdges.Style).To(Equal("dashed"))
+	g.Expect(cfg.NodeStyleRules).To(HaveLen(2))
+	g.Expect(cfg.NodeStyleRules[0].Match).To(Equal("*:lint"))
+	g.Expect(cfg.NodeStyleRules[1].Match).To(Equal("deploy*"))
+}
+
+func TestLoad_Extends_RecordsSourceOfEachValue(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	base := filepath.Join("testdata", "extends", "base.yml")
+	child := filepath.Join("testdata", "extends", "child.yml")
+	sources := Sources{}
+
+	// Act
+	err := Load(child, New(), sources)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(sources.Lookup("autoColor")).To(Equal(base + ":1"))
+	g.Expect(sources.Lookup("highlightColor")).To(Equal(child + ":3"))
+	g.Expect(sources.Lookup("nodeStyleRules[0].fillColor")).To(Equal(base + ":6"))
+	g.Expect(sources.Lookup("nodeStyleRules[1].fillColor")).To(Equal(child + ":6"))
+	g.Expect(sources.Lookup("graphviz.fontSize")).To(Equal(child + ":8"))
+	g.Expect(sources.Lookup("graphviz.callEdges.style")).To(Equal(DefaultSource))
+}
+
+func TestLoad_ExtendsCycleAndMissingFile_ReportsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := Load(filepath.Join("testdata", "extends", "cycle-a.yml"), New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].File).To(Equal(filepath.Join("testdata", "extends", "cycle-b.yml")))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(2))
+	g.Expect(diags[0].Diagnostics[0].Line).To(Equal(2))
+	g.Expect(diags[0].Diagnostics[0].Message).To(ContainSubstring("must not form a cycle"))
+	g.Expect(diags[0].Diagnostics[1].Line).To(Equal(3))
+	g.Expect(diags[0].Diagnostics[1].Message).To(ContainSubstring("not found"))
+}
+
+func TestLoad_InvalidProfile_ReportsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	content := "profiles:\n  overview:\n    graphType: svg\n    focus: [\"build[\"]\n"
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	// Act
+	err := Load(path, New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(3))
+	g.Expect(diags[0].Diagnostics[0].Message).To(Equal("profiles.overview: an output is required"))
+	g.Expect(diags[0].Diagnostics[1].Message).To(HavePrefix(`profiles.overview.graphType: unsupported value "svg"`))
+	g.Expect(diags[0].Diagnostics[2].Message).To(HavePrefix("profiles.overview.focus[0]: failed to compile pattern"))
+}
+
+func TestLoad_InvalidRenderSettings_ReportsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "render.yaml")
+	content := "renderTimeout: -5s\nprofiles:\n  overview:\n    output: graph.dot\n    renderImage: svg,=graph.png\n"
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	// Act
+	err := Load(path, New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(2))
+	g.Expect(diags[0].Diagnostics[0].Message).To(HavePrefix(`renderTimeout: invalid duration "-5s"`))
+	g.Expect(diags[0].Diagnostics[1].Message).To(HavePrefix(`profiles.overview.renderImage: invalid image format ""`))
+}
+
+func TestLoad_InvalidLinkTemplate_ReportsPosition(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "links.yaml")
+	content := "links:\n  url: https://example.com/{{.Path}}\n  tooltips: true\n"
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	// Act
+	err := Load(path, New(), nil)
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics[0].Line).To(Equal(2))
+	g.Expect(diags[0].Diagnostics[0].Message).To(HavePrefix("links.url: invalid link template"))
+}
@@ -1,5 +1,31 @@
 package config
 
+// MermaidDirections are the supported Mermaid flowchart directions.
+var MermaidDirections = []string{"TD", "TB", "BT", "LR", "RL"}
+
+// MermaidThemes are the themes built into Mermaid.
+// https://mermaid.js.org/config/theming.html
+var MermaidThemes = []string{"default", "neutral", "dark", "forest", "base"}
+
+// MermaidShapes are the supported shapes of Mermaid task nodes.
+// https://mermaid.js.org/syntax/flowchart.html#node-shapes
+var MermaidShapes = []string{
+	"rect", "rounded", "stadium", "subroutine", "cylinder", "circle", "hexagon", "parallelogram", "trapezoid",
+	"rhombus",
+}
+
+// MermaidStyles are the Graphviz-like styles that can be given to Mermaid nodes, edges and
+// subgraphs, each drawn with CSS; several may be combined, separated by commas.
+var MermaidStyles = []string{"solid", "dashed", "dotted", "bold", "filled", "rounded", "invis"}
+
+// Default limits of platform-safe Mermaid output. The edge and text limits are Mermaid's own,
+// which GitHub and GitLab keep; beyond the node limit, diagrams are scaled until illegible.
+const (
+	DefaultMermaidMaxEdges    = 500
+	DefaultMermaidMaxTextSize = 50000
+	DefaultMermaidMaxNodes    = 100
+)
+
 // Mermaid holds configuration specific to Mermaid flowchart output.
 type Mermaid struct {
 	// Direction is the direction of the flowchart.
@@ -7,8 +33,85 @@ type Mermaid struct {
 	// Defaults to "TD" when not specified.
 	Direction string `json:"direction,omitempty" yaml:"direction,omitempty"`
 
+	// Theme is the Mermaid theme used, set with an init directive at the start of the output.
+	// Valid values: default, neutral, dark, forest, base. Only the base theme can be customised
+	// fully by ThemeVariables.
+	Theme string `json:"theme,omitempty" yaml:"theme,omitempty"`
+
+	// ThemeVariables are Mermaid theme variables, such as primaryColor or fontFamily, set with
+	// an init directive at the start of the output.
+	// https://mermaid.js.org/config/theming.html#theme-variables
+	ThemeVariables map[string]string `json:"themeVariables,omitempty" yaml:"themeVariables,omitempty"`
+
+	// Descriptions adds the description of each task to its label, below its name.
+	Descriptions bool `json:"descriptions,omitempty" yaml:"descriptions,omitempty"`
+
+	// TaskShape is the shape of task nodes. Valid values: rect, rounded, stadium, subroutine,
+	// cylinder, circle, hexagon, parallelogram, trapezoid, rhombus. Defaults to rect.
+	// https://mermaid.js.org/syntax/flowchart.html#node-shapes
+	TaskShape string `json:"taskShape,omitempty" yaml:"taskShape,omitempty"`
+
+	// TaskNodes holds style properties for task nodes in the Mermaid output.
+	TaskNodes *MermaidStyle `json:"taskNodes,omitempty" yaml:"taskNodes,omitempty"`
+
 	// VariableNodes holds style properties for variable nodes in the Mermaid output.
 	VariableNodes *MermaidStyle `json:"variableNodes,omitempty" yaml:"variableNodes,omitempty"`
+
+	// DependencyEdges is the presentation for dependency edges between tasks.
+	DependencyEdges *MermaidEdge `json:"dependencyEdges,omitempty" yaml:"dependencyEdges,omitempty"`
+
+	// CallEdges is the presentation for call edges between tasks.
+	CallEdges *MermaidEdge `json:"callEdges,omitempty" yaml:"callEdges,omitempty"`
+
+	// VariableEdges is the presentation for edges between variables and tasks.
+	VariableEdges *MermaidEdge `json:"variableEdges,omitempty" yaml:"variableEdges,omitempty"`
+
+	// Subgraphs is the presentation for the subgraphs drawn around each namespace when grouping
+	// by namespace.
+	Subgraphs *MermaidSubgraph `json:"subgraphs,omitempty" yaml:"subgraphs,omitempty"`
+
+	// SubgraphDirections sets the direction of the subgraphs of particular namespaces, keyed by
+	// namespace, overriding the Direction of Subgraphs. Valid values are as for Direction.
+	SubgraphDirections map[string]string `json:"subgraphDirections,omitempty" yaml:"subgraphDirections,omitempty"`
+
+	// PlatformSafe writes Markdown ready to paste into GitHub or GitLab, with the flowchart in a
+	// fenced mermaid block. A flowchart too big for them to render legibly, exceeding MaxEdges,
+	// MaxTextSize or MaxNodes, is split into an overview, with each top-level namespace collapsed
+	// to a single node, followed by a diagram for each top-level namespace.
+	PlatformSafe bool `json:"platformSafe,omitempty" yaml:"platformSafe,omitempty"`
+
+	// MaxEdges is the most edges a platform-safe diagram may have. Defaults to 500.
+	MaxEdges int `json:"maxEdges,omitempty" yaml:"maxEdges,omitempty"`
+
+	// MaxTextSize is the most characters a platform-safe diagram may have. Defaults to 50000.
+	MaxTextSize int `json:"maxTextSize,omitempty" yaml:"maxTextSize,omitempty"`
+
+	// MaxNodes is the most nodes a platform-safe diagram may have. Defaults to 100.
+	MaxNodes int `json:"maxNodes,omitempty" yaml:"maxNodes,omitempty"`
+}
+
+// MaxEdgesOrDefault returns the most edges a platform-safe diagram may have.
+func (m *Mermaid) MaxEdgesOrDefault() int {
+	return positiveOrDefault(m.MaxEdges, DefaultMermaidMaxEdges)
+}
+
+// MaxTextSizeOrDefault returns the most characters a platform-safe diagram may have.
+func (m *Mermaid) MaxTextSizeOrDefault() int {
+	return positiveOrDefault(m.MaxTextSize, DefaultMermaidMaxTextSize)
+}
+
+// MaxNodesOrDefault returns the most nodes a platform-safe diagram may have.
+func (m *Mermaid) MaxNodesOrDefault() int {
+	return positiveOrDefault(m.MaxNodes, DefaultMermaidMaxNodes)
+}
+
+// positiveOrDefault returns value if it is positive, or else the default.
+func positiveOrDefault(value int, def int) int {
+	if value > 0 {
+		return value
+	}
+
+	return def
 }
 
 // MermaidStyle holds CSS-like style properties for Mermaid classDef directives.
@@ -21,6 +124,43 @@ type MermaidStyle struct {
 
 	// Color is the text color.
 	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+
+	// Style is the style of the border, such as dashed or bold, drawn with CSS.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+}
+
+// MermaidEdge holds the presentation of a kind of edge in Mermaid output. Dashed and dotted
+// edges are drawn as dotted links, bold edges as thick links, and colours and widths with
+// linkStyle directives.
+type MermaidEdge struct {
+	// Color is the color of the edge.
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+
+	// Width is the width of the edge, in pixels.
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+
+	// Style is the style of the edge, such as solid, dashed, dotted or bold.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+}
+
+// MermaidSubgraph holds the presentation of the subgraphs drawn around namespaces in Mermaid
+// output.
+type MermaidSubgraph struct {
+	// Fill is the background fill color.
+	Fill string `json:"fill,omitempty" yaml:"fill,omitempty"`
+
+	// Stroke is the border color.
+	Stroke string `json:"stroke,omitempty" yaml:"stroke,omitempty"`
+
+	// Color is the color of the subgraph title.
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+
+	// Style is the style of the border, such as dashed or bold, drawn with CSS.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+
+	// Direction is the direction of the flowchart within each subgraph. Valid values are as for
+	// the Direction of the flowchart. Mermaid ignores it for subgraphs linked to nodes outside.
+	Direction string `json:"direction,omitempty" yaml:"direction,omitempty"`
 }
 
 func newMermaid() *Mermaid {
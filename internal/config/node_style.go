@@ -1,11 +1,23 @@
 package config
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
 // NodeStyleRule defines a style rule that is applied to task nodes whose names match the given pattern.
 // These rules work across all graph types (dot, mermaid, etc.).
 type NodeStyleRule struct {
 	// Match is the pattern used to match task names. Supports wildcards (* and ?).
 	Match string `json:"match,omitempty" yaml:"match,omitempty"`
 
+	// Name is an optional short name for the rule. Named rules are shown in the legend.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Description is an optional explanation of the rule, shown in the legend alongside Name.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
 	// Color is the color of the node border.
 	Color string `json:"color,omitempty" yaml:"color,omitempty"`
 
@@ -18,3 +30,15 @@ type NodeStyleRule struct {
 	// FontColor is the color of the label text.
 	FontColor string `json:"fontColor,omitempty" yaml:"fontColor,omitempty"`
 }
+
+// Key returns a short identifier derived from the content of the rule. Output naming rules by
+// their key, rather than their position, is unchanged when other rules come and go; identical
+// rules share a key.
+func (r NodeStyleRule) Key() string {
+	content := strings.Join(
+		[]string{r.Match, r.Name, r.Description, r.Color, r.FillColor, r.Style, r.FontColor},
+		"\x00")
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Profile is a named set of options for producing one output. A config with several profiles
+// produces several outputs from a single run, loading the Taskfile and building its graph once.
+// Options not set by a profile are taken from the rest of the config.
+type Profile struct {
+	// Output is the path of the file to write, or - to write to stdout. Required.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+
+	// GraphType is the type of graph to generate, overriding the GraphType of the config, even
+	// when set by --graph-type. Valid values: dot, mermaid, gantt.
+	GraphType string `json:"graphType,omitempty" yaml:"graphType,omitempty"`
+
+	// GanttTask is the task whose execution is simulated when GraphType is gantt.
+	GanttTask string `json:"ganttTask,omitempty" yaml:"ganttTask,omitempty"`
+
+	// Focus lists patterns of tasks to show, together with all their transitive dependencies
+	// and dependents. All tasks are shown if not specified.
+	Focus []string `json:"focus,omitempty" yaml:"focus,omitempty"`
+
+	// Exclude lists patterns of tasks to leave out of the graph, along with their edges.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+
+	// Highlight lists patterns of tasks to fill with the HighlightColor of the config.
+	Highlight []string `json:"highlight,omitempty" yaml:"highlight,omitempty"`
+
+	// GroupByNamespace overrides whether tasks in the same namespace are grouped together.
+	GroupByNamespace *bool `json:"groupByNamespace,omitempty" yaml:"groupByNamespace,omitempty"`
+
+	// RenderImage lists the images to render, separated by commas, such as svg,png. Each is an
+	// image format, optionally followed by =path giving the file to write; otherwise the image
+	// is written alongside the output, or in place of it if the output has the same extension.
+	RenderImage string `json:"renderImage,omitempty" yaml:"renderImage,omitempty"`
+}
+
+// Image is an image to render, as listed by RenderImage.
+type Image struct {
+	// Format is the image format, such as svg, png or png:cairo.
+	Format string
+
+	// Path is the file to write the image to, or empty to write it alongside the output.
+	Path string
+}
+
+// imageFormat matches an image format: a file type, optionally followed by the renderer and
+// formatter to use, as accepted by dot.
+var imageFormat = regexp.MustCompile(`^[A-Za-z0-9_]+(:[A-Za-z0-9_]+)*$`)
+
+// ParseImages returns the images listed by a RenderImage value.
+func ParseImages(value string) ([]Image, error) {
+	var result []Image
+
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		format, path, hasPath := strings.Cut(entry, "=")
+		format = strings.TrimSpace(format)
+		path = strings.TrimSpace(path)
+
+		if !imageFormat.MatchString(format) {
+			return nil, eris.Errorf("invalid image format %q, expected a file type such as svg or png", format)
+		}
+
+		if hasPath && path == "" {
+			return nil, eris.Errorf("missing path after %q", format+"=")
+		}
+
+		result = append(result, Image{Format: format, Path: path})
+	}
+
+	return result, nil
+}
+
+// FileType returns the file type of the image format, without any renderer or formatter.
+func (i Image) FileType() string {
+	fileType, _, _ := strings.Cut(i.Format, ":")
+
+	return fileType
+}
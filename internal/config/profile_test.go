@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseImages_ListOfFormats_ReturnsEachWithoutPath(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	images, err := ParseImages("svg, png:cairo,,pdf=docs/graph.pdf")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(Equal([]Image{
+		{Format: "svg"},
+		{Format: "png:cairo"},
+		{Format: "pdf", Path: "docs/graph.pdf"},
+	}))
+	g.Expect(images[1].FileType()).To(Equal("png"))
+}
+
+func TestParseImages_Empty_ReturnsNoImages(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	images, err := ParseImages("")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(BeEmpty())
+}
+
+func TestParseImages_InvalidEntries_ReturnError(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		value   string
+		message string
+	}{
+		"Path in format": {"../graph.svg", `invalid image format "../graph.svg"`},
+		"Missing format": {"=graph.svg", `invalid image format ""`},
+		"Missing path":   {"svg=", `missing path after "svg="`},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			_, err := ParseImages(c.value)
+
+			// Assert
+			g.Expect(err).To(MatchError(ContainSubstring(c.message)))
+		})
+	}
+}
+
+func TestRenderTimeoutOrDefault(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+
+	// Act & Assert
+	g.Expect(cfg.RenderTimeoutOrDefault()).To(Equal(DefaultRenderTimeout))
+
+	cfg.RenderTimeout = "90s"
+	g.Expect(cfg.RenderTimeoutOrDefault()).To(Equal(90 * time.Second))
+}
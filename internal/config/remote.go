@@ -0,0 +1,48 @@
+package config
+
+import "time"
+
+// DefaultRemoteTimeout is how long downloading remote includes may take when Remote.Timeout is
+// not set.
+const DefaultRemoteTimeout = 30 * time.Second
+
+// Remote holds configuration for resolving remote includes (Taskfiles included by URL).
+type Remote struct {
+	// CacheDir is the directory in which task-graph keeps the remote Taskfiles it downloads.
+	// Defaults to a task-graph directory in the user's cache directory. Copies cached by task
+	// in the .task directory alongside the root Taskfile are also used, but never changed.
+	CacheDir string `json:"cacheDir,omitempty" yaml:"cacheDir,omitempty"`
+
+	// Download allows remote includes to be downloaded when they aren't cached. Otherwise they
+	// are resolved only from the caches and Includes. Defaults to false.
+	Download bool `json:"download,omitempty" yaml:"download,omitempty"`
+
+	// Offline prevents downloading remote includes, even if Download is set.
+	Offline bool `json:"offline,omitempty" yaml:"offline,omitempty"`
+
+	// Timeout is how long downloading remote includes may take, such as 10s or 1m. Defaults to
+	// 30s.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// TrustedHosts are the hosts, including any port, whose remote Taskfiles are used when
+	// downloaded. A Taskfile from any other host is refused unless task has already trusted
+	// its content.
+	TrustedHosts []string `json:"trustedHosts,omitempty" yaml:"trustedHosts,omitempty"`
+
+	// Includes maps the URL of a remote include to a local file to use in its place.
+	Includes map[string]string `json:"includes,omitempty" yaml:"includes,omitempty"`
+
+	// Placeholders controls whether remote includes that can't be loaded are drawn as a
+	// placeholder node in the namespace of the include, instead of failing.
+	Placeholders bool `json:"placeholders,omitempty" yaml:"placeholders,omitempty"`
+}
+
+// TimeoutOrDefault returns how long downloading remote includes may take.
+func (r *Remote) TimeoutOrDefault() time.Duration {
+	timeout, err := time.ParseDuration(r.Timeout)
+	if err != nil || timeout <= 0 {
+		return DefaultRemoteTimeout
+	}
+
+	return timeout
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/rotisserie/eris"
+)
+
+// SchemaURL is where the JSON Schema for the config file is published.
+const SchemaURL = "https://raw.githubusercontent.com/theunrepentantgeek/task-graph/main/" +
+	"internal/config/task-graph.schema.json"
+
+// schema is the JSON Schema for the config file. It is generated from the config types and
+// their comments by the tests in this package; run `go test ./... -update` after changing them.
+//
+//go:embed task-graph.schema.json
+var schema []byte
+
+// Schema returns the JSON Schema describing the config file.
+func Schema() []byte {
+	return schema
+}
+
+// schemaDoc is the subset of the JSON Schema used to document the config file.
+type schemaDoc struct {
+	schemaObject
+
+	Defs map[string]schemaObject `json:"$defs"`
+}
+
+// schemaObject describes a config type.
+type schemaObject struct {
+	Description string                    `json:"description"`
+	Properties  map[string]schemaProperty `json:"properties"`
+}
+
+// schemaProperty describes a field of a config type.
+type schemaProperty struct {
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+}
+
+// readSchema parses the JSON Schema for the config file.
+func readSchema() (*schemaDoc, error) {
+	var result schemaDoc
+
+	err := json.Unmarshal(schema, &result)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to parse config schema")
+	}
+
+	return &result, nil
+}
+
+// properties returns the documented fields of the named config type.
+func (d *schemaDoc) properties(typeName string) map[string]schemaProperty {
+	if typeName == "Config" {
+		return d.Properties
+	}
+
+	return d.Defs[typeName].Properties
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sebdah/goldie/v2"
+)
+
+// schemaEnums lists the allowed values of fields restricted to a fixed set, keyed by
+// Type.Field.
+var schemaEnums = map[string][]string{
+	"Config.GraphType":          GraphTypes,
+	"Config.AutoColorMode":      AutoColorModes,
+	"Config.Renderer":           Renderers,
+	"Profile.GraphType":         GraphTypes,
+	"Mermaid.Direction":         MermaidDirections,
+	"Mermaid.Theme":             MermaidThemes,
+	"Mermaid.TaskShape":         MermaidShapes,
+	"MermaidSubgraph.Direction": MermaidDirections,
+	"Graphviz.RankDir":          GraphvizRankDirs,
+	"Graphviz.Splines":          GraphvizSplines,
+	"Graphviz.Layout":           GraphvizLayouts,
+	"Graphviz.NodeLabels":       GraphvizNodeLabels,
+	"EdgeStyleRule.Class":       EdgeClasses,
+}
+
+// schemaStyles lists the Graphviz styles allowed in style fields, keyed by Type.Field. Several
+// styles may be combined, separated by commas.
+var schemaStyles = map[string][]string{
+	"GraphvizNode.Style":    GraphvizNodeStyles,
+	"NodeStyleRule.Style":   GraphvizNodeStyles,
+	"GraphvizEdge.Style":    GraphvizEdgeStyles,
+	"GraphvizCluster.Style": GraphvizClusterStyles,
+	"EdgeStyleRule.Style":   GraphvizEdgeStyles,
+	"MermaidStyle.Style":    MermaidStyles,
+	"MermaidEdge.Style":     MermaidStyles,
+	"MermaidSubgraph.Style": MermaidStyles,
+}
+
+// TestSchema_MatchesConfigTypes regenerates the JSON Schema from the config types and their
+// comments, and checks that the published schema is up to date.
+func TestSchema_MatchesConfigTypes(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gen := &schemaGenerator{
+		comments: readDocComments(t),
+		defs:     make(map[string]any),
+	}
+
+	root := gen.object(reflect.TypeFor[Config]())
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaURL
+	root["title"] = "task-graph configuration"
+	root["$defs"] = gen.defs
+
+	// Act
+	data, err := json.MarshalIndent(root, "", "  ")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t, goldie.WithFixtureDir("."), goldie.WithNameSuffix(".json"))
+	gg.Assert(t, "task-graph.schema", append(data, '\n'))
+}
+
+func TestSchema_IsEmbedded(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	doc, err := readSchema()
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(doc.properties("Config")).To(HaveKey("nodeStyleRules"))
+	g.Expect(doc.properties("Graphviz")).To(HaveKey("font"))
+}
+
+// schemaGenerator builds a JSON Schema from Go types, using their comments as descriptions.
+type schemaGenerator struct {
+	// comments maps Type and Type.Field to the doc comment of each
+	comments map[string]string
+	// defs holds the schema of each struct type referenced
+	defs map[string]any
+}
+
+// object returns the schema of the struct type t.
+func (s *schemaGenerator) object(t reflect.Type) map[string]any {
+	defaults := reflect.Value{}
+	if t == reflect.TypeFor[Config]() {
+		defaults = reflect.ValueOf(New()).Elem()
+	}
+
+	properties := make(map[string]any)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		key := t.Name() + "." + field.Name
+		property := s.property(key, field.Type)
+		property["description"] = s.comments[key]
+
+		if defaults.IsValid() {
+			addDefault(property, defaults.Field(i))
+		}
+
+		properties[name] = property
+	}
+
+	result := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if doc := s.comments[t.Name()]; doc != "" {
+		result["description"] = doc
+	}
+
+	return result
+}
+
+// property returns the schema of a field of type t, identified by key.
+func (s *schemaGenerator) property(key string, t reflect.Type) map[string]any {
+	if values, ok := schemaEnums[key]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	if styles, ok := schemaStyles[key]; ok {
+		alternatives := strings.Join(styles, "|")
+
+		return map[string]any{
+			"anyOf": []any{
+				map[string]any{"enum": styles},
+				map[string]any{
+					"type":    "string",
+					"pattern": `^\s*(` + alternatives + `)(\s*,\s*(` + alternatives + `))*\s*$`,
+				},
+			},
+		}
+	}
+
+	return s.typeSchema(t)
+}
+
+// typeSchema returns the schema for values of type t.
+func (s *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := s.defs[t.Name()]; !ok {
+			s.defs[t.Name()] = nil // Reserve, in case of recursion
+			s.defs[t.Name()] = s.object(t)
+		}
+
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": s.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.typeSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// addDefault records the default value of a property, if it has one.
+func addDefault(property map[string]any, value reflect.Value) {
+	if value.IsZero() {
+		return
+	}
+
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return
+	}
+
+	var result any
+	if json.Unmarshal(data, &result) == nil {
+		property["default"] = result
+	}
+}
+
+// readDocComments returns the doc comments of the types and fields declared in this package,
+// keyed by Type and Type.Field, with line breaks removed.
+func readDocComments(t *testing.T) map[string]string {
+	t.Helper()
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(map[string]string)
+	fset := token.NewFileSet()
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ast.Inspect(parsed, func(n ast.Node) bool {
+			decl, ok := n.(*ast.GenDecl)
+			if !ok {
+				return true
+			}
+
+			for _, spec := range decl.Specs {
+				addTypeComments(result, decl, spec)
+			}
+
+			return false
+		})
+	}
+
+	return result
+}
+
+// addTypeComments records the doc comments of a struct type and its fields.
+func addTypeComments(comments map[string]string, decl *ast.GenDecl, spec ast.Spec) {
+	typeSpec, ok := spec.(*ast.TypeSpec)
+	if !ok {
+		return
+	}
+
+	structType, ok := typeSpec.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+
+	doc := typeSpec.Doc
+	if doc == nil {
+		doc = decl.Doc
+	}
+
+	comments[typeSpec.Name.Name] = commentText(doc)
+
+	for _, field := range structType.Fields.List {
+		for _, name := range field.Names {
+			comments[typeSpec.Name.Name+"."+name.Name] = commentText(field.Doc)
+		}
+	}
+}
+
+// commentText returns the text of a comment as a single line.
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
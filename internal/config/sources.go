@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSource describes values that were not set by any config file, environment variable
+// or flag.
+const DefaultSource = "default"
+
+// Sources records where each value of a Config was set, keyed by its path within the config
+// file, such as graphviz.fontSize or nodeStyleRules[2]. A source is a file and line, an
+// environment variable or a flag. Values set within a list item or map share the source of
+// the item unless recorded separately.
+type Sources map[string]string
+
+// Set records the source of the value at path. It does nothing if s is nil, so that sources
+// need only be tracked when wanted.
+func (s Sources) Set(path string, source string) {
+	if s == nil {
+		return
+	}
+
+	s[path] = source
+}
+
+// Lookup returns the source of the value at path, inherited from the nearest enclosing value
+// with a recorded source, or DefaultSource if there is none.
+func (s Sources) Lookup(path string) string {
+	for path != "" {
+		if source, ok := s[path]; ok {
+			return source
+		}
+
+		path = parentPath(path)
+	}
+
+	return DefaultSource
+}
+
+// MarshalYAML returns cfg as YAML, with the source of each value given in a comment on its line.
+func MarshalYAML(cfg *Config, sources Sources) ([]byte, error) {
+	var doc yaml.Node
+
+	err := doc.Encode(cfg)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to encode config")
+	}
+
+	annotate(&doc, "", sources)
+
+	data, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to marshal config as YAML")
+	}
+
+	return data, nil
+}
+
+// annotate adds the source of each scalar value within node as a line comment.
+func annotate(node *yaml.Node, path string, sources Sources) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			annotate(node.Content[i+1], joinPath(path, node.Content[i].Value), sources)
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			annotate(item, fmt.Sprintf("%s[%d]", path, i), sources)
+		}
+
+	case yaml.ScalarNode:
+		source := sources.Lookup(path)
+		if source != DefaultSource {
+			source = "from " + source
+		}
+
+		node.LineComment = "# " + source
+
+	default:
+		for _, child := range node.Content {
+			annotate(child, path, sources)
+		}
+	}
+}
+
+// parentPath returns the path of the value enclosing the value at path, or "" at the root.
+func parentPath(path string) string {
+	index := strings.LastIndexAny(path, ".[")
+	if index < 0 {
+		return ""
+	}
+
+	return path[:index]
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sebdah/goldie/v2"
+)
+
+func TestSources_Lookup_InheritsFromEnclosingValue(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	sources := Sources{
+		"nodeStyleRules[1]": "base.yml:4",
+		"graphviz.font":     "--font",
+	}
+
+	// Act & Assert
+	g.Expect(sources.Lookup("nodeStyleRules[1].fillColor")).To(Equal("base.yml:4"))
+	g.Expect(sources.Lookup("nodeStyleRules[0].fillColor")).To(Equal(DefaultSource))
+	g.Expect(sources.Lookup("graphviz.font")).To(Equal("--font"))
+	g.Expect(sources.Lookup("graphviz.fontSize")).To(Equal(DefaultSource))
+}
+
+func TestMarshalYAML_GivesSourceOfEachValue(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	cfg.AutoColor = true
+	cfg.NodeStyleRules = []NodeStyleRule{{Match: "build*", FillColor: "orange"}}
+	cfg.Graphviz.FontSize = 12
+
+	sources := Sources{
+		"autoColor":         "$TASK_GRAPH_AUTO_COLOR",
+		"nodeStyleRules[0]": "team.yml:7",
+		"graphviz.fontSize": "team.yml:12",
+	}
+
+	// Act
+	data, err := MarshalYAML(cfg, sources)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t)
+	gg.Assert(t, t.Name(), data)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// starterWidth is the width at which comments in the starter config are wrapped.
+const starterWidth = 100
+
+// WriteStarter writes a starter config file in YAML, with every option documented. Options with
+// a default are set to it; the rest are commented out, ready to be enabled.
+func WriteStarter(w io.Writer) error {
+	doc, err := readSchema()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	s := &starterWriter{w: bw, doc: doc}
+
+	s.line(0, true, "yaml-language-server: $schema="+SchemaURL)
+	s.line(0, false, "")
+	s.comment(0, doc.Description+
+		" Options set below show their defaults; remove the # from any other option to use it.")
+
+	s.object(reflect.ValueOf(New()).Elem(), 0, false)
+
+	return eris.Wrap(bw.Flush(), "failed to write starter config")
+}
+
+// starterWriter writes the starter config, tracking the first write error.
+type starterWriter struct {
+	w   io.Writer
+	doc *schemaDoc
+	err error
+}
+
+// object writes each field of the struct value v, with its description.
+func (s *starterWriter) object(v reflect.Value, indent int, commented bool) {
+	t := v.Type()
+	properties := s.doc.properties(t.Name())
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+
+		if i > 0 || indent == 0 {
+			s.line(0, false, "")
+		}
+
+		s.comment(indent, properties[name].Description)
+		s.field(name, properties[name], v.Field(i), indent, commented)
+	}
+}
+
+// field writes a single field, commenting it out if it has no value.
+func (s *starterWriter) field(
+	name string,
+	property schemaProperty,
+	v reflect.Value,
+	indent int,
+	commented bool,
+) {
+	switch {
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() != reflect.Struct:
+		s.line(indent, true, name+": "+scalar(reflect.New(v.Type().Elem()).Elem()))
+
+	case v.Kind() == reflect.Pointer:
+		value := v
+		if v.IsNil() {
+			value = reflect.New(v.Type().Elem())
+		}
+
+		s.line(indent, commented || v.IsNil(), name+":")
+		s.object(value.Elem(), indent+1, commented || v.IsNil())
+
+	case v.Kind() == reflect.Slice:
+		s.line(indent, true, name+":")
+
+		if v.Type().Elem().Kind() == reflect.Struct {
+			s.item(reflect.New(v.Type().Elem()).Elem(), indent+1)
+		} else {
+			s.line(indent+1, true, "- value")
+		}
+
+	case v.Kind() == reflect.Map && v.Type().Elem().Kind() == reflect.Struct:
+		s.line(indent, true, name+":")
+		s.line(indent+1, true, "name:")
+		s.object(reflect.New(v.Type().Elem()).Elem(), indent+2, true)
+
+	case v.Kind() == reflect.Map:
+		s.line(indent, true, name+":")
+		s.line(indent+1, true, "key: value")
+
+	default:
+		value := scalar(v)
+		if v.IsZero() && len(property.Enum) > 0 {
+			value = property.Enum[0]
+		}
+
+		s.line(indent, commented || v.IsZero(), name+": "+value)
+	}
+}
+
+// item writes an example list item for the struct value v, commented out.
+func (s *starterWriter) item(v reflect.Value, indent int) {
+	t := v.Type()
+	properties := s.doc.properties(t.Name())
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+
+		s.comment(indent+1, properties[name].Description)
+
+		prefix := "  "
+		if i == 0 {
+			prefix = "- "
+		}
+
+		s.line(indent, true, prefix+name+": "+scalar(v.Field(i)))
+	}
+}
+
+// comment writes text as comment lines, wrapped to starterWidth.
+func (s *starterWriter) comment(indent int, text string) {
+	var line strings.Builder
+
+	for word := range strings.FieldsSeq(text) {
+		// Allow for the indent, the leading "# " and the space before the word
+		if line.Len() > 0 && indent*2+line.Len()+len(word)+3 > starterWidth {
+			s.line(indent, true, line.String())
+			line.Reset()
+		}
+
+		if line.Len() > 0 {
+			line.WriteString(" ")
+		}
+
+		line.WriteString(word)
+	}
+
+	if line.Len() > 0 {
+		s.line(indent, true, line.String())
+	}
+}
+
+// line writes a single line at the given indent, commented out if required. Commented lines
+// keep their indent, so that removing the leading "# " enables them.
+func (s *starterWriter) line(indent int, commented bool, text string) {
+	if s.err != nil {
+		return
+	}
+
+	if commented {
+		text = "# " + text
+	}
+
+	if text != "" {
+		text = strings.Repeat("  ", indent) + text
+	}
+
+	_, s.err = io.WriteString(s.w, text+"\n")
+}
+
+// scalar returns the YAML for a scalar value.
+func scalar(v reflect.Value) string {
+	data, err := yaml.Marshal(v.Interface())
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
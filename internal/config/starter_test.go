@@ -0,0 +1,51 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sebdah/goldie/v2"
+)
+
+func TestWriteStarter_GivesExpectedResult(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteStarter(&buf)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t)
+	gg.Assert(t, t.Name(), buf.Bytes())
+}
+
+func TestWriteStarter_LoadsAsDefaults(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	g.Expect(WriteStarter(&buf)).To(Succeed())
+
+	path := filepath.Join(t.TempDir(), ".task-graph.yml")
+	g.Expect(os.WriteFile(path, buf.Bytes(), 0o600)).To(Succeed())
+
+	cfg := &Config{}
+
+	// Act
+	err := Load(path, cfg, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(Equal(New()))
+}
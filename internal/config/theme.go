@@ -0,0 +1,254 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in themes.
+const (
+	ThemeLight           = "light"
+	ThemeDark            = "dark"
+	ThemeMonochromePrint = "monochrome-print"
+	ThemeHighContrast    = "high-contrast"
+	ThemePresentation    = "presentation"
+)
+
+// BuiltinThemes are the names of the themes always available, in addition to any in the config.
+var BuiltinThemes = []string{ThemeLight, ThemeDark, ThemeMonochromePrint, ThemeHighContrast, ThemePresentation}
+
+// Theme is a named set of presentation settings for both Graphviz and Mermaid output. A theme
+// replaces the defaults, so any setting in the config overrides it.
+type Theme struct {
+	// Graphviz holds the Graphviz settings of the theme, such as fonts, colours and the
+	// presentation of nodes, edges and clusters.
+	Graphviz *Graphviz `json:"graphviz,omitempty" yaml:"graphviz,omitempty"`
+
+	// Mermaid holds the Mermaid settings of the theme, such as the Mermaid theme to build on and
+	// its theme variables.
+	Mermaid *Mermaid `json:"mermaid,omitempty" yaml:"mermaid,omitempty"`
+}
+
+// ThemeNames returns the names of every theme available: the built-in themes, followed by any
+// others defined in the config, in order of name.
+func (c *Config) ThemeNames() []string {
+	result := slices.Clone(BuiltinThemes)
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// LookupTheme returns the theme with the given name. Themes defined in the config take
+// precedence over built-in themes of the same name.
+func (c *Config) LookupTheme(name string) (Theme, bool) {
+	if theme, ok := c.Themes[name]; ok {
+		return theme, true
+	}
+
+	theme, ok := builtinThemes()[name]
+
+	return theme, ok
+}
+
+// ApplyTheme overrides the settings of cfg with those of theme. Settings are merged field by
+// field, so those the theme leaves unset keep their values. If sources is not nil, each value
+// set is recorded as coming from the theme.
+func ApplyTheme(cfg *Config, name string, theme Theme, sources Sources) error {
+	var node yaml.Node
+
+	err := node.Encode(theme)
+	if err != nil {
+		return eris.Wrapf(err, "failed to encode theme %q", name)
+	}
+
+	err = node.Decode(cfg)
+	if err != nil {
+		return eris.Wrapf(err, "failed to apply theme %q", name)
+	}
+
+	recordSources(&node, "", "theme "+name, sources)
+
+	return nil
+}
+
+// recordSources records source as the source of each scalar value within node.
+func recordSources(node *yaml.Node, path string, source string, sources Sources) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			recordSources(node.Content[i+1], joinPath(path, node.Content[i].Value), source, sources)
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			recordSources(item, fmt.Sprintf("%s[%d]", path, i), source, sources)
+		}
+
+	case yaml.ScalarNode:
+		sources.Set(path, source)
+
+	default:
+		for _, child := range node.Content {
+			recordSources(child, path, source, sources)
+		}
+	}
+}
+
+// builtinThemes returns the built-in themes, keyed by name. The light theme has the same
+// settings as the defaults, so that it can be chosen explicitly to override another theme.
+func builtinThemes() map[string]Theme {
+	return map[string]Theme{
+		ThemeLight: {
+			Graphviz: newGraphViz(),
+			Mermaid:  newMermaid(),
+		},
+		ThemeDark:            darkTheme(),
+		ThemeMonochromePrint: monochromePrintTheme(),
+		ThemeHighContrast:    highContrastTheme(),
+		ThemePresentation:    presentationTheme(),
+	}
+}
+
+// darkTheme has light text and lines on a dark background.
+func darkTheme() Theme {
+	const (
+		background = "#1e1e1e"
+		text       = "#e0e0e0"
+		muted      = "#a0a0a0"
+		surface    = "#2d2d2d"
+		variable   = "#3a3a3a"
+	)
+
+	return Theme{
+		Graphviz: &Graphviz{
+			Background:      background,
+			FontColor:       text,
+			DependencyEdges: &GraphvizEdge{Color: text},
+			CallEdges:       &GraphvizEdge{Color: "#6cb6ff"},
+			TaskNodes:       &GraphvizNode{Color: text, FillColor: surface, Style: "filled", FontColor: text},
+			VariableNodes:   &GraphvizNode{Color: muted, FillColor: variable, FontColor: text},
+			VariableEdges:   &GraphvizEdge{Color: "#7ccf7c"},
+			Clusters:        &GraphvizCluster{Color: "#808080", FontColor: text},
+		},
+		Mermaid: &Mermaid{
+			Theme:         "dark",
+			CallEdges:     &MermaidEdge{Color: "#6cb6ff"},
+			VariableNodes: &MermaidStyle{Fill: variable, Stroke: muted, Color: text},
+			VariableEdges: &MermaidEdge{Color: "#7ccf7c"},
+		},
+	}
+}
+
+// monochromePrintTheme uses only black on white, with line styles distinguishing edges, so
+// that graphs print well without colour.
+func monochromePrintTheme() Theme {
+	const (
+		ink   = "black"
+		paper = "white"
+		font  = "Times-Roman"
+	)
+
+	return Theme{
+		Graphviz: &Graphviz{
+			Font:            font,
+			Background:      paper,
+			FontColor:       ink,
+			DependencyEdges: &GraphvizEdge{Color: ink, Style: "solid"},
+			CallEdges:       &GraphvizEdge{Color: ink, Style: "dashed"},
+			TaskNodes:       &GraphvizNode{Color: ink, FontColor: ink},
+			VariableNodes:   &GraphvizNode{Color: ink, FillColor: "#eeeeee", FontColor: ink},
+			VariableEdges:   &GraphvizEdge{Color: ink, Style: "dotted"},
+			Clusters:        &GraphvizCluster{Color: ink, Style: "dashed", FontColor: ink},
+		},
+		Mermaid: &Mermaid{
+			Theme:           "neutral",
+			ThemeVariables:  map[string]string{"fontFamily": font},
+			TaskNodes:       &MermaidStyle{Stroke: ink, Color: ink},
+			VariableNodes:   &MermaidStyle{Fill: "#eeeeee", Stroke: ink, Color: ink},
+			DependencyEdges: &MermaidEdge{Color: ink, Style: "solid"},
+			CallEdges:       &MermaidEdge{Color: ink, Style: "dashed"},
+			VariableEdges:   &MermaidEdge{Color: ink, Style: "dotted"},
+			Subgraphs:       &MermaidSubgraph{Stroke: ink, Style: "dashed"},
+		},
+	}
+}
+
+// highContrastTheme uses bright, heavy lines and larger text on black, for accessibility.
+func highContrastTheme() Theme {
+	const (
+		background = "black"
+		text       = "white"
+		accent     = "yellow"
+		variable   = "cyan"
+	)
+
+	return Theme{
+		Graphviz: &Graphviz{
+			FontSize:        18,
+			Background:      background,
+			FontColor:       text,
+			DependencyEdges: &GraphvizEdge{Color: text, Width: 2},
+			CallEdges:       &GraphvizEdge{Color: accent, Width: 2},
+			TaskNodes:       &GraphvizNode{Color: text, FillColor: background, Style: "filled,bold", FontColor: text},
+			VariableNodes:   &GraphvizNode{Color: variable, FillColor: background, FontColor: variable},
+			VariableEdges:   &GraphvizEdge{Color: variable, Width: 2},
+			Clusters:        &GraphvizCluster{Color: text, Style: "bold", FontColor: text},
+		},
+		Mermaid: &Mermaid{
+			Theme: "base",
+			ThemeVariables: map[string]string{
+				"background":          "#000000",
+				"primaryColor":        "#000000",
+				"primaryTextColor":    "#ffffff",
+				"primaryBorderColor":  "#ffffff",
+				"lineColor":           "#ffffff",
+				"clusterBkg":          "#000000",
+				"clusterBorder":       "#ffffff",
+				"titleColor":          "#ffffff",
+				"edgeLabelBackground": "#000000",
+				"fontSize":            "18px",
+			},
+			TaskNodes:       &MermaidStyle{Style: "bold"},
+			VariableNodes:   &MermaidStyle{Fill: "#000000", Stroke: "#00ffff", Color: "#00ffff"},
+			DependencyEdges: &MermaidEdge{Width: 2},
+			CallEdges:       &MermaidEdge{Color: "#ffff00", Width: 2},
+			VariableEdges:   &MermaidEdge{Color: "#00ffff", Width: 2},
+		},
+	}
+}
+
+// presentationTheme uses large text and heavier lines, to stay legible on a projected slide.
+func presentationTheme() Theme {
+	const (
+		font = "Helvetica"
+		text = "#333333"
+	)
+
+	return Theme{
+		Graphviz: &Graphviz{
+			Font:            font,
+			FontSize:        24,
+			FontColor:       text,
+			DependencyEdges: &GraphvizEdge{Color: text, Width: 2},
+			CallEdges:       &GraphvizEdge{Width: 2},
+			TaskNodes:       &GraphvizNode{Color: text, FillColor: "#f5f5f5", Style: "filled", FontColor: text},
+			VariableEdges:   &GraphvizEdge{Width: 2},
+			Clusters:        &GraphvizCluster{Color: "#999999", FontColor: text},
+		},
+		Mermaid: &Mermaid{
+			Theme:           "default",
+			ThemeVariables:  map[string]string{"fontFamily": font, "fontSize": "24px"},
+			DependencyEdges: &MermaidEdge{Width: 2},
+			CallEdges:       &MermaidEdge{Width: 2},
+			VariableEdges:   &MermaidEdge{Width: 2},
+		},
+	}
+}
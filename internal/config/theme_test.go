@@ -0,0 +1,111 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestBuiltinThemes_AreValid(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range BuiltinThemes {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Arrange
+			cfg := New()
+			theme, ok := cfg.LookupTheme(name)
+			g.Expect(ok).To(BeTrue())
+
+			// Act
+			err := ApplyTheme(cfg, name, theme, nil)
+
+			// Assert
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cfg.Validate()).To(BeEmpty())
+		})
+	}
+}
+
+func TestApplyTheme_LightTheme_MatchesDefaults(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	theme, _ := cfg.LookupTheme(ThemeLight)
+
+	// Act
+	err := ApplyTheme(cfg, ThemeLight, theme, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg).To(Equal(New()))
+}
+
+func TestApplyTheme_MergesSettingsAndRecordsSources(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	sources := Sources{}
+	theme := Theme{
+		Graphviz: &Graphviz{
+			FontSize:  24,
+			TaskNodes: &GraphvizNode{FillColor: "gold"},
+		},
+	}
+
+	// Act
+	err := ApplyTheme(cfg, "custom", theme, sources)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(cfg.Graphviz.FontSize).To(Equal(24))
+	g.Expect(cfg.Graphviz.Font).To(Equal("Verdana"))
+	g.Expect(cfg.Graphviz.TaskNodes).To(Equal(&GraphvizNode{Color: "black", FillColor: "gold"}))
+	g.Expect(sources.Lookup("graphviz.fontSize")).To(Equal("theme custom"))
+	g.Expect(sources.Lookup("graphviz.font")).To(Equal(DefaultSource))
+}
+
+func TestLookupTheme_ConfigThemeReplacesBuiltinTheme(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	custom := Theme{Graphviz: &Graphviz{Background: "navy"}}
+	cfg := New()
+	cfg.Themes = map[string]Theme{ThemeDark: custom, "corporate": custom}
+
+	// Act
+	theme, ok := cfg.LookupTheme(ThemeDark)
+
+	// Assert
+	g.Expect(ok).To(BeTrue())
+	g.Expect(theme).To(Equal(custom))
+	g.Expect(cfg.ThemeNames()).To(Equal(append(BuiltinThemes, "corporate")))
+}
+
+func TestValidate_UnknownThemeAndInvalidThemeColour_ReportsEach(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := New()
+	cfg.Theme = "sepia"
+	cfg.Themes = map[string]Theme{
+		"corporate": {Graphviz: &Graphviz{Background: "#bluish"}},
+	}
+
+	// Act
+	problems := cfg.Validate()
+
+	// Assert
+	g.Expect(problems).To(HaveLen(2))
+	g.Expect(problems[0].Path).To(Equal("theme"))
+	g.Expect(problems[1].Path).To(Equal("themes.corporate.graphviz.background"))
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/htmllabel"
+	"github.com/theunrepentantgeek/task-graph/internal/links"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// Problem is an invalid value found when validating a Config.
+type Problem struct {
+	// Path identifies the value, using the names from the config file, such as
+	// nodeStyleRules[1].match.
+	Path string
+
+	// Message describes what is wrong with the value.
+	Message string
+}
+
+// String returns the problem prefixed by its path.
+func (p Problem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// Validate checks every pattern, colour and enumerated value in the config, so that mistakes
+// are found when the config is loaded rather than part way through rendering. All problems
+// are returned.
+func (c *Config) Validate() []Problem {
+	return c.validate().problems
+}
+
+// Warnings returns the doubtful values in the config that are not invalid, and so are used as
+// given: colours with names that task-graph doesn't know, but the renderer might.
+func (c *Config) Warnings() []Problem {
+	return c.validate().warnings
+}
+
+// validate checks the config, returning the validator holding the problems and warnings.
+func (c *Config) validate() *validator {
+	v := &validator{}
+
+	v.oneOf("graphType", c.GraphType, GraphTypes...)
+	v.oneOf("autoColorMode", c.AutoColorMode, AutoColorModes...)
+
+	for i, color := range c.AutoColorPalette {
+		v.color(fmt.Sprintf("autoColorPalette[%d]", i), color)
+	}
+
+	for _, ns := range slices.Sorted(maps.Keys(c.AutoColorPins)) {
+		v.color("autoColorPins."+ns, c.AutoColorPins[ns])
+	}
+
+	v.oneOf("renderer", c.Renderer, Renderers...)
+	v.duration("renderTimeout", c.RenderTimeout)
+	v.color("highlightColor", c.HighlightColor)
+	v.color("criticalPathColor", c.CriticalPathColor)
+
+	if c.Links != nil && c.Links.URL != "" {
+		if _, err := links.Parse(c.Links.URL); err != nil {
+			v.add("links.url", "%s", err)
+		}
+	}
+
+	if c.Remote != nil {
+		v.duration("remote.timeout", c.Remote.Timeout)
+	}
+
+	for i, rule := range c.NodeStyleRules {
+		path := fmt.Sprintf("nodeStyleRules[%d]", i)
+		v.pattern(path+".match", rule.Match)
+		v.color(path+".color", rule.Color)
+		v.color(path+".fillColor", rule.FillColor)
+		v.color(path+".fontColor", rule.FontColor)
+	}
+
+	for i, rule := range c.EdgeStyleRules {
+		path := fmt.Sprintf("edgeStyleRules[%d]", i)
+		v.pattern(path+".from", rule.From)
+		v.pattern(path+".to", rule.To)
+		v.oneOf(path+".class", rule.Class, EdgeClasses...)
+		v.color(path+".color", rule.Color)
+	}
+
+	v.oneOf("theme", c.Theme, c.ThemeNames()...)
+
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		theme := c.Themes[name]
+		v.graphviz("themes."+name+".graphviz", theme.Graphviz)
+		v.mermaid("themes."+name+".mermaid", theme.Mermaid)
+	}
+
+	v.graphviz("graphviz", c.Graphviz)
+	v.mermaid("mermaid", c.Mermaid)
+
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		v.profile("profiles."+name, c.Profiles[name])
+	}
+
+	return v
+}
+
+// ValidatePattern returns an error if pattern is not a valid task name pattern.
+func ValidatePattern(pattern string) error {
+	_, err := namespace.CompileMatchPattern(pattern)
+
+	return err
+}
+
+// ValidateDuration returns an error if value is not a positive duration, such as 30s or 2m.
+func ValidateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return eris.Errorf("invalid duration %q, expected a positive duration such as 30s or 2m", value)
+	}
+
+	return nil
+}
+
+// validator accumulates the problems and warnings found in a config.
+type validator struct {
+	problems []Problem
+	warnings []Problem
+}
+
+// add records a problem with the value at path.
+func (v *validator) add(path string, format string, args ...any) {
+	v.problems = append(v.problems, Problem{
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// color checks that value, if set, is a valid colour.
+func (v *validator) color(path string, value string) {
+	if value == "" {
+		return
+	}
+
+	err := ValidateColor(value)
+	switch {
+	case errors.Is(err, ErrUnknownColor):
+		v.warnings = append(v.warnings, Problem{Path: path, Message: err.Error()})
+	case err != nil:
+		v.add(path, "%s", err)
+	}
+}
+
+// pattern checks that value, if set, is a valid task name pattern.
+func (v *validator) pattern(path string, value string) {
+	if value == "" {
+		return
+	}
+
+	if err := ValidatePattern(value); err != nil {
+		v.add(path, "%s", err)
+	}
+}
+
+// oneOf checks that value, if set, is one of the allowed values.
+func (v *validator) oneOf(path string, value string, allowed ...string) {
+	if value == "" || slices.Contains(allowed, value) {
+		return
+	}
+
+	v.add(path, "unsupported value %q, must be one of %s", value, strings.Join(allowed, ", "))
+}
+
+// duration checks that value, if set, is a positive duration such as 30s.
+func (v *validator) duration(path string, value string) {
+	if value == "" {
+		return
+	}
+
+	if err := ValidateDuration(value); err != nil {
+		v.add(path, "%s", err)
+	}
+}
+
+func (v *validator) graphviz(path string, gv *Graphviz) {
+	if gv == nil {
+		return
+	}
+
+	v.color(path+".fontColor", gv.FontColor)
+	v.color(path+".background", gv.Background)
+	v.graphvizEdge(path+".dependencyEdges", gv.DependencyEdges)
+	v.graphvizEdge(path+".callEdges", gv.CallEdges)
+	v.graphvizEdge(path+".variableEdges", gv.VariableEdges)
+	v.graphvizNode(path+".taskNodes", gv.TaskNodes)
+	v.graphvizNode(path+".variableNodes", gv.VariableNodes)
+	v.oneOf(path+".rankDir", gv.RankDir, GraphvizRankDirs...)
+	v.oneOf(path+".splines", gv.Splines, GraphvizSplines...)
+	v.oneOf(path+".layout", gv.Layout, GraphvizLayouts...)
+	v.oneOf(path+".nodeLabels", gv.NodeLabels, GraphvizNodeLabels...)
+	v.nonNegative(path+".nodeSep", gv.NodeSep)
+	v.nonNegative(path+".rankSep", gv.RankSep)
+
+	if gv.LabelTemplate != "" {
+		if _, err := htmllabel.Parse(gv.LabelTemplate); err != nil {
+			v.add(path+".labelTemplate", "%s", err)
+		}
+	}
+
+	if cluster := gv.Clusters; cluster != nil {
+		v.color(path+".clusters.color", cluster.Color)
+		v.color(path+".clusters.fillColor", cluster.FillColor)
+		v.color(path+".clusters.fontColor", cluster.FontColor)
+	}
+}
+
+func (v *validator) mermaid(path string, m *Mermaid) {
+	if m == nil {
+		return
+	}
+
+	v.oneOf(path+".direction", m.Direction, MermaidDirections...)
+	v.oneOf(path+".theme", m.Theme, MermaidThemes...)
+	v.oneOf(path+".taskShape", m.TaskShape, MermaidShapes...)
+	v.mermaidStyle(path+".taskNodes", m.TaskNodes)
+	v.mermaidStyle(path+".variableNodes", m.VariableNodes)
+	v.mermaidEdge(path+".dependencyEdges", m.DependencyEdges)
+	v.mermaidEdge(path+".callEdges", m.CallEdges)
+	v.mermaidEdge(path+".variableEdges", m.VariableEdges)
+
+	if sg := m.Subgraphs; sg != nil {
+		v.color(path+".subgraphs.fill", sg.Fill)
+		v.color(path+".subgraphs.stroke", sg.Stroke)
+		v.color(path+".subgraphs.color", sg.Color)
+		v.oneOf(path+".subgraphs.direction", sg.Direction, MermaidDirections...)
+	}
+
+	for _, ns := range slices.Sorted(maps.Keys(m.SubgraphDirections)) {
+		v.oneOf(path+".subgraphDirections."+ns, m.SubgraphDirections[ns], MermaidDirections...)
+	}
+
+	v.nonNegative(path+".maxEdges", float64(m.MaxEdges))
+	v.nonNegative(path+".maxTextSize", float64(m.MaxTextSize))
+	v.nonNegative(path+".maxNodes", float64(m.MaxNodes))
+}
+
+func (v *validator) mermaidStyle(path string, style *MermaidStyle) {
+	if style == nil {
+		return
+	}
+
+	v.color(path+".fill", style.Fill)
+	v.color(path+".stroke", style.Stroke)
+	v.color(path+".color", style.Color)
+}
+
+func (v *validator) mermaidEdge(path string, edge *MermaidEdge) {
+	if edge == nil {
+		return
+	}
+
+	v.color(path+".color", edge.Color)
+}
+
+// nonNegative checks that value is not negative.
+func (v *validator) nonNegative(path string, value float64) {
+	if value < 0 {
+		v.add(path, "must not be negative, got %g", value)
+	}
+}
+
+func (v *validator) graphvizNode(path string, node *GraphvizNode) {
+	if node == nil {
+		return
+	}
+
+	v.color(path+".color", node.Color)
+	v.color(path+".fillColor", node.FillColor)
+	v.color(path+".fontColor", node.FontColor)
+}
+
+func (v *validator) graphvizEdge(path string, edge *GraphvizEdge) {
+	if edge == nil {
+		return
+	}
+
+	v.color(path+".color", edge.Color)
+}
+
+func (v *validator) profile(path string, profile Profile) {
+	if profile.Output == "" {
+		v.add(path, "an output is required")
+	}
+
+	v.oneOf(path+".graphType", profile.GraphType, GraphTypes...)
+
+	for i, pattern := range profile.Focus {
+		v.pattern(fmt.Sprintf("%s.focus[%d]", path, i), pattern)
+	}
+
+	for i, pattern := range profile.Exclude {
+		v.pattern(fmt.Sprintf("%s.exclude[%d]", path, i), pattern)
+	}
+
+	for i, pattern := range profile.Highlight {
+		v.pattern(fmt.Sprintf("%s.highlight[%d]", path, i), pattern)
+	}
+
+	if _, err := ParseImages(profile.RenderImage); err != nil {
+		v.add(path+".renderImage", "%s", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package diagnostic reports problems found in an input file, such as a config file or a
+// Taskfile. Each problem is located by line and column, and all the problems in a file are
+// reported together, each followed by the offending line.
+package diagnostic
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is a single problem found in a file.
+type Diagnostic struct {
+	// Line is the 1-based line of the problem, or 0 if not known.
+	Line int
+
+	// Column is the 1-based column of the problem, or 0 if not known.
+	Column int
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error is the set of problems found in a single file.
+type Error struct {
+	// File is the path of the file, or empty if the problems were not found in a file.
+	File string
+
+	// Source is the content of the file, used to show the offending lines.
+	Source []byte
+
+	// Diagnostics are the problems found.
+	Diagnostics []Diagnostic
+}
+
+// New returns an error for the given problems found in a file, or nil if there are none.
+// Problems are sorted by position.
+func New(file string, source []byte, diagnostics []Diagnostic) error {
+	if len(diagnostics) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(diagnostics)
+	slices.SortStableFunc(sorted, func(left, right Diagnostic) int {
+		return cmp.Or(
+			cmp.Compare(left.Line, right.Line),
+			cmp.Compare(left.Column, right.Column))
+	})
+
+	return &Error{
+		File:        file,
+		Source:      source,
+		Diagnostics: sorted,
+	}
+}
+
+// All returns every *Error found in the tree of errors wrapped by err, including those
+// combined with errors.Join, in order.
+func All(err error) []*Error {
+	if e, ok := err.(*Error); ok { //nolint:errorlint // Wrapped errors are walked below
+		return []*Error{e}
+	}
+
+	switch wrapped := err.(type) { //nolint:errorlint // Walking the tree of wrapped errors
+	case interface{ Unwrap() error }:
+		return All(wrapped.Unwrap())
+	case interface{ Unwrap() []error }:
+		var result []*Error
+		for _, e := range wrapped.Unwrap() {
+			result = append(result, All(e)...)
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
+
+// Error returns each problem on its own line, prefixed by its location.
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		lines = append(lines, e.location(d)+d.Message)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Write writes each problem to w, prefixed by its location and followed by the offending line
+// of the file with a marker under the column of the problem.
+func (e *Error) Write(w io.Writer) error {
+	var buf bytes.Buffer
+
+	lines := strings.Split(string(e.Source), "\n")
+
+	for _, d := range e.Diagnostics {
+		fmt.Fprintf(&buf, "%s%s\n", e.location(d), d.Message)
+
+		if d.Line < 1 || d.Line > len(lines) {
+			continue
+		}
+
+		number := strconv.Itoa(d.Line)
+		gutter := strings.Repeat(" ", len(number))
+		line := strings.TrimRight(lines[d.Line-1], "\r")
+
+		fmt.Fprintf(&buf, " %s | %s\n", number, line)
+
+		if d.Column > 0 {
+			fmt.Fprintf(&buf, " %s | %s^\n", gutter, indentTo(line, d.Column))
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+// location returns the prefix giving the location of a problem, or an empty string if there is
+// neither a file nor a line.
+func (e *Error) location(d Diagnostic) string {
+	var parts []string
+
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+
+	if d.Line > 0 {
+		parts = append(parts, strconv.Itoa(d.Line))
+		if d.Column > 0 {
+			parts = append(parts, strconv.Itoa(d.Column))
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return strings.Join(parts, ":") + ": "
+}
+
+// indentTo returns the whitespace to place a marker under the given column of line, keeping
+// any tabs so that the marker lines up.
+func indentTo(line string, column int) string {
+	var b strings.Builder
+
+	for i, r := range []rune(line) {
+		if i >= column-1 {
+			break
+		}
+
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	return b.String()
+}
+
+// FromYAMLError returns the problems reported by a YAML parser error. An error for several
+// values that could not be decoded gives a problem for each.
+func FromYAMLError(err error) []Diagnostic {
+	var result []Diagnostic
+
+	for _, line := range strings.Split(err.Error(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "yaml: unmarshal errors:" {
+			continue
+		}
+
+		result = append(result, FromYAMLMessage(line))
+	}
+
+	return result
+}
+
+// yamlLinePrefix matches the location prefix of YAML parser messages.
+var yamlLinePrefix = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// FromYAMLMessage returns the problem described by a YAML parser message, which may be prefixed
+// by the line number.
+func FromYAMLMessage(message string) Diagnostic {
+	match := yamlLinePrefix.FindStringSubmatch(message)
+	if match == nil {
+		return Diagnostic{Message: strings.TrimPrefix(message, "yaml: ")}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return Diagnostic{
+		Line:    line,
+		Message: message[len(match[0]):],
+	}
+}
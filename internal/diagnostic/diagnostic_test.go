@@ -0,0 +1,100 @@
+package diagnostic
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/rotisserie/eris"
+	"github.com/sebdah/goldie/v2"
+)
+
+const source = "graphType: dot\n" +
+	"nodeStyleRules:\n" +
+	"\t- match: \"build[\"\n" +
+	"    fillColor: bluish\n"
+
+func TestWrite_ShowsOffendingLines(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	diags := All(New("config.yaml", []byte(source), []Diagnostic{
+		{Line: 4, Column: 16, Message: "unknown colour \"bluish\""},
+		{Line: 3, Column: 11, Message: "invalid pattern \"build[\""},
+		{Line: 1, Message: "line without a column"},
+		{Message: "problem without a position"},
+	}))
+	g.Expect(diags).To(HaveLen(1))
+
+	var buf bytes.Buffer
+
+	// Act
+	err := diags[0].Write(&buf)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t)
+	gg.Assert(t, "write", buf.Bytes())
+}
+
+func TestNew_NoDiagnostics_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := New("config.yaml", nil, nil)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestAll_FindsWrappedAndJoinedErrors(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	first := New("a.yaml", nil, []Diagnostic{{Line: 1, Message: "first"}})
+	second := New("", nil, []Diagnostic{{Message: "second"}})
+
+	err := errors.Join(eris.Wrap(first, "failed to load"), errors.New("other"), second)
+
+	// Act
+	diags := All(err)
+
+	// Assert
+	g.Expect(diags).To(Equal([]*Error{first.(*Error), second.(*Error)}))
+}
+
+func TestFromYAMLError_SplitsEachProblem(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	err := errors.New("yaml: unmarshal errors:\n  line 3: cannot unmarshal !!str `x` into int\n  line 7: bad")
+
+	// Act
+	diags := FromYAMLError(err)
+
+	// Assert
+	g.Expect(diags).To(Equal([]Diagnostic{
+		{Line: 3, Message: "cannot unmarshal !!str `x` into int"},
+		{Line: 7, Message: "bad"},
+	}))
+}
+
+func TestFromYAMLMessage_WithoutLine_KeepsMessage(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	diag := FromYAMLMessage("yaml: control characters are not allowed")
+
+	// Assert
+	g.Expect(diag).To(Equal(Diagnostic{
+		Message: "control characters are not allowed",
+	}))
+}
@@ -2,11 +2,10 @@ package dot
 
 import (
 	"context"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"io"
+	"time"
 
-	"github.com/rotisserie/eris"
+	"github.com/theunrepentantgeek/task-graph/internal/render"
 )
 
 // FindExecutable returns the path to the dot executable.
@@ -14,61 +13,56 @@ import (
 // If dotPath is a directory, the dot executable is looked up within that directory.
 // If dotPath is empty, dot is looked up on the system PATH.
 func FindExecutable(dotPath string) (string, error) {
-	if dotPath == "" {
-		path, err := exec.LookPath("dot")
-		if err != nil {
-			return "", eris.Wrap(err, "dot executable not found on PATH")
-		}
+	return render.FindExecutable("dot", "dotPath", dotPath)
+}
 
-		return path, nil
-	}
+// Renderer runs the dot executable to render DOT source as images.
+type Renderer struct {
+	// Executable is the path to the dot executable.
+	Executable string
 
-	info, err := os.Stat(dotPath)
-	if err != nil {
-		return "", eris.Wrapf(err, "dotPath not found: %s", dotPath)
-	}
+	// Layout is the layout engine, passed to dot as -K if not empty (e.g. "neato", "fdp").
+	Layout string
 
-	if info.IsDir() {
-		return findInDirectory(dotPath)
-	}
+	// Timeout is how long dot may run before it is stopped; no limit if zero.
+	Timeout time.Duration
 
-	return dotPath, nil
+	// Stdout receives any image without a path.
+	Stdout io.Writer
 }
 
-// findInDirectory checks for the presence of a dot executable in the specified directory.
-// directory is expected to be a valid directory path.
-// If a dot executable is found, its path is returned.
-func findInDirectory(directory string) (string, error) {
-	candidate := filepath.Join(directory, "dot")
+var _ render.Renderer = &Renderer{}
 
-	_, statErr := os.Stat(candidate)
-	if statErr == nil {
-		return candidate, nil
+// Render runs dot once, sending it the DOT source on stdin, to render each of the images; the
+// format of each is passed to dot as -T. If dot fails, each problem it reports is located at
+// the line of the source it complains about.
+func (r *Renderer) Render(ctx context.Context, source []byte, images []render.Image) error {
+	var args []string
+	if r.Layout != "" {
+		args = append(args, "-K"+r.Layout)
 	}
 
-	// Try with .exe extension on Windows
-	candidateExe := candidate + ".exe"
-
-	_, statErr = os.Stat(candidateExe)
-	if statErr == nil {
-		return candidateExe, nil
+	// dot gives each -o to the first -T still without one, not to the nearest, so every image
+	// with a path goes before any written to stdout
+	for _, image := range images {
+		if image.Path != "" {
+			args = append(args, "-T"+image.Format, "-o"+image.Path)
+		}
 	}
 
-	return "", eris.Errorf(
-		"dot executable not found in directory: %s",
-		directory)
-}
-
-// RenderImage runs the dot executable to render dotFile to imageFile using the given fileType.
-// The fileType is passed to dot as -T<fileType> (e.g. "png", "svg").
-func RenderImage(ctx context.Context, dotExecutable, dotFile, imageFile, fileType string) error {
-	//nolint:gosec // dotExecutable is resolved from a trusted config path or system PATH
-	cmd := exec.CommandContext(ctx, dotExecutable, "-T"+fileType, dotFile, "-o", imageFile)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return eris.Wrapf(err, "dot command failed: %s", string(output))
+	for _, image := range images {
+		if image.Path == "" {
+			args = append(args, "-T"+image.Format)
+		}
 	}
 
-	return nil
+	return render.Run(ctx, render.Command{
+		Name:       "dot",
+		Executable: r.Executable,
+		Args:       args,
+		Source:     source,
+		SourceName: "generated DOT",
+		Timeout:    r.Timeout,
+		Stdout:     r.Stdout,
+	})
 }
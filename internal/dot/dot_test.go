@@ -1,12 +1,18 @@
 package dot
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+	"github.com/theunrepentantgeek/task-graph/internal/render"
 )
 
 // TestFindExecutable_EmptyDotPath_FindsOnPath tests that FindExecutable
@@ -133,36 +139,131 @@ func TestFindExecutable_EmptyDotPathNoDot_ReturnsError(t *testing.T) {
 	g.Expect(result).To(BeEmpty())
 }
 
-func TestRenderImage_NonExistentExecutable_ReturnsError(t *testing.T) {
+func TestRender_NonExistentExecutable_ReturnsError(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	// Act: pass a non-existent executable path
-	err := RenderImage(context.Background(), "/nonexistent/dot", "input.dot", "output.png", "png")
+	// Arrange
+	r := &Renderer{Executable: "/nonexistent/dot"}
+
+	// Act
+	err := r.Render(context.Background(), []byte("digraph {}"), []render.Image{{Format: "png", Path: "output.png"}})
 
 	// Assert
-	g.Expect(err).To(HaveOccurred())
 	g.Expect(err).To(MatchError(ContainSubstring("dot command failed")))
 }
 
-func TestRenderImage_ExecutableFails_ReturnsError(t *testing.T) {
+func TestRender_SendsSourceOnStdinWithAnOutputPerImage(t *testing.T) {
 	t.Parallel()
 	g := NewWithT(t)
 
-	// Arrange: use /bin/false which always exits with an error
-	falseExe := "/bin/false"
+	// Arrange
+	dir := t.TempDir()
+	exe := fakeDot(t, dir, `echo "$@" > "`+filepath.Join(dir, "args")+`"; cat`)
 
-	_, statErr := os.Stat(falseExe)
-	if statErr != nil {
-		t.Skip("/bin/false not available, skipping")
-	}
+	var stdout bytes.Buffer
+
+	r := &Renderer{Executable: exe, Layout: "fdp", Stdout: &stdout}
 
 	// Act
-	err := RenderImage(context.Background(), falseExe, "input.dot", "output.png", "png")
+	err := r.Render(context.Background(), []byte("digraph {}"), []render.Image{
+		{Format: "png", Path: "graph.png"},
+		{Format: "svg"},
+	})
 
 	// Assert
-	g.Expect(err).To(HaveOccurred())
-	g.Expect(err).To(MatchError(ContainSubstring("dot command failed")))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(args)).To(Equal("-Kfdp -Tpng -ograph.png -Tsvg\n"))
+	g.Expect(stdout.String()).To(Equal("digraph {}"))
+}
+
+func TestRender_StdoutImageListedFirst_PairsEachPathWithItsFormat(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	exe := fakeDot(t, dir, `echo "$@" > "`+filepath.Join(dir, "args")+`"`)
+
+	r := &Renderer{Executable: exe, Stdout: &bytes.Buffer{}}
+
+	// Act
+	err := r.Render(context.Background(), []byte("digraph {}"), []render.Image{
+		{Format: "svg"},
+		{Format: "png", Path: "x.png"},
+		{Format: "pdf", Path: "x.pdf"},
+	})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(args)).To(Equal("-Tpng -ox.png -Tpdf -ox.pdf -Tsvg\n"))
+}
+
+func TestRender_DotFails_ReportsStderrWithOffendingLine(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	exe := fakeDot(t, t.TempDir(), `echo "Error: <stdin>: syntax error in line 2 near '->'" >&2; exit 1`)
+
+	r := &Renderer{Executable: exe}
+
+	// Act
+	err := r.Render(context.Background(), []byte("digraph {\n  a -> -> b\n}\n"), []render.Image{{Format: "svg"}})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("dot command failed (exit status 1)")))
+
+	problems := diagnostic.All(err)
+	g.Expect(problems).To(HaveLen(1))
+
+	var buf bytes.Buffer
+	g.Expect(problems[0].Write(&buf)).To(Succeed())
+	g.Expect(buf.String()).To(Equal(
+		"generated DOT:2: Error: syntax error in line 2 near '->'\n" +
+			" 2 |   a -> -> b\n"))
+}
+
+func TestRender_DotTakesTooLong_ReturnsTimeoutError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	exe := fakeDot(t, t.TempDir(), "exec sleep 5")
+
+	r := &Renderer{Executable: exe, Timeout: 50 * time.Millisecond}
+
+	// Act
+	err := r.Render(context.Background(), []byte("digraph {}"), []render.Image{{Format: "svg"}})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("dot did not finish rendering within 50ms")))
+}
+
+// fakeDot writes a shell script standing in for dot, running the given commands, and returns its
+// path. Tests using it are skipped on Windows.
+func fakeDot(t *testing.T, dir string, commands string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake dot is a shell script, skipping on Windows")
+	}
+
+	path := filepath.Join(dir, "dot")
+
+	//nolint:gosec // The script must be executable
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+commands+"\n"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
 }
 
 // findDotOnPath is a helper for tests that need to check if dot is on PATH.
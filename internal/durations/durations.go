@@ -0,0 +1,195 @@
+// Package durations loads measured task execution times and applies them to a graph.
+// Durations can be read from a timestamped `task --verbose` log, or from a simple JSON or
+// CSV file mapping task names to durations.
+package durations
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// Load reads task durations from the given file. The format is chosen by file extension:
+// .json for a JSON object of task name to duration, .csv for rows of task name and
+// duration, and anything else is treated as a `task --verbose` log.
+func Load(path string) (map[string]time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open durations file: %s", path)
+	}
+
+	defer f.Close()
+
+	var result map[string]time.Duration
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		result, err = ReadJSON(f)
+	case ".csv":
+		result, err = ReadCSV(f)
+	default:
+		result, err = ReadTaskLog(f)
+	}
+
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read durations file: %s", path)
+	}
+
+	return result, nil
+}
+
+// ReadJSON reads a JSON object mapping task names to durations. Each duration may be either
+// a string in Go duration syntax (e.g. "1m30s") or a number of seconds.
+func ReadJSON(r io.Reader) (map[string]time.Duration, error) {
+	var raw map[string]any
+
+	err := json.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to decode JSON durations")
+	}
+
+	result := make(map[string]time.Duration, len(raw))
+
+	for name, value := range raw {
+		var d time.Duration
+
+		switch v := value.(type) {
+		case string:
+			d, err = parseDuration(v)
+			if err != nil {
+				return nil, eris.Wrapf(err, "invalid duration for task %q", name)
+			}
+		case float64:
+			d = secondsToDuration(v)
+		default:
+			return nil, eris.Errorf("invalid duration for task %q: expected string or number", name)
+		}
+
+		result[name] = d
+	}
+
+	return result, nil
+}
+
+// ReadCSV reads rows of task name and duration. Each duration may be either in Go duration
+// syntax (e.g. "1m30s") or a number of seconds. An optional header row is skipped.
+func ReadCSV(r io.Reader) (map[string]time.Duration, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	result := make(map[string]time.Duration)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to read CSV durations")
+		}
+
+		if line == 1 && isHeader(record) {
+			continue
+		}
+
+		d, err := parseDuration(record[1])
+		if err != nil {
+			return nil, eris.Wrapf(err, "invalid duration for task %q on line %d", record[0], line)
+		}
+
+		result[record[0]] = d
+	}
+
+	return result, nil
+}
+
+// Apply sets the Duration of each node in the graph that has a measured duration.
+// Returns the sorted names of any tasks that were not found in the graph.
+//
+// Measured durations are wall-clock times from when a task starts to when it finishes, as
+// `task --verbose` logs them, so they include the time taken by the dependencies the task
+// waits for and by the tasks it calls. Each node is given only its own time: its measured
+// duration less that of its slowest dependency (dependencies run in parallel) and of each task
+// it calls (calls run in turn). Adding up durations along a chain of tasks, as the critical
+// path and Gantt chart do, then counts no time twice.
+func Apply(gr *graph.Graph, durations map[string]time.Duration) []string {
+	var unmatched []string
+
+	for name, d := range durations {
+		node, ok := gr.Node(name)
+		if !ok {
+			unmatched = append(unmatched, name)
+
+			continue
+		}
+
+		node.Duration = ownTime(node, d, durations)
+	}
+
+	slices.Sort(unmatched)
+
+	return unmatched
+}
+
+// ownTime returns the time node spent running its own commands, given its measured duration
+// and those of the tasks it depends on and calls. It is never negative, as runs of the same
+// task can vary in length.
+func ownTime(node *graph.Node, measured time.Duration, durations map[string]time.Duration) time.Duration {
+	var deps, calls time.Duration
+
+	for _, edge := range node.Edges() {
+		d := durations[edge.To().ID()]
+
+		switch edge.Class() {
+		case graph.EdgeClassVar:
+			// Variables take no time
+		case graph.EdgeClassCall:
+			calls += d
+		default:
+			deps = max(deps, d)
+		}
+	}
+
+	return max(measured-deps-calls, 0)
+}
+
+// parseDuration parses s as a Go duration, falling back to a number of seconds.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	d, err := time.ParseDuration(s)
+	if err == nil {
+		return d, nil
+	}
+
+	seconds, floatErr := strconv.ParseFloat(s, 64)
+	if floatErr != nil {
+		return 0, eris.Wrapf(err, "failed to parse duration %q", s)
+	}
+
+	return secondsToDuration(seconds), nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// isHeader returns true if the given CSV record looks like a header row.
+func isHeader(record []string) bool {
+	_, err := parseDuration(record[1])
+
+	return err != nil && (strings.EqualFold(record[0], "task") || strings.EqualFold(record[0], "name"))
+}
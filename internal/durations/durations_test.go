@@ -0,0 +1,172 @@
+package durations
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+var expectedDurations = map[string]time.Duration{
+	"build": 90 * time.Second,
+	"lint":  12500 * time.Millisecond,
+}
+
+func TestLoad_SelectsFormatByExtension(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"durations.json", "durations.csv", "task.log"} {
+		t.Run(file, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			result, err := Load(filepath.Join("testdata", file))
+
+			// Assert
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(Equal(expectedDurations))
+		})
+	}
+}
+
+func TestLoad_MissingFile_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Load(filepath.Join("testdata", "missing.json"))
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestReadJSON_InvalidDuration_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := ReadJSON(strings.NewReader(`{"build": "soon"}`))
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("build")))
+}
+
+func TestReadCSV_WithoutHeader_ReadsAllRows(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	result, err := ReadCSV(strings.NewReader("build,2s\ntest,500ms\n"))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(map[string]time.Duration{
+		"build": 2 * time.Second,
+		"test":  500 * time.Millisecond,
+	}))
+}
+
+func TestReadTaskLog_RepeatedRuns_KeepsLongest(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	log := `10:00:00 task: "build" started
+10:00:02 task: "build" finished
+10:00:03 task: "build" started
+10:00:08 task: "build" finished
+`
+
+	// Act
+	result, err := ReadTaskLog(strings.NewReader(log))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(HaveKeyWithValue("build", 5*time.Second))
+}
+
+func TestReadTaskLog_WithoutTimestamps_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	log := `task: "build" started
+task: "build" finished
+`
+
+	// Act
+	_, err := ReadTaskLog(strings.NewReader(log))
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("no timestamps")))
+}
+
+func TestApply_SetsDurationsAndReportsUnmatched(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	build := gr.AddNode("build")
+
+	// Act
+	unmatched := Apply(gr, map[string]time.Duration{
+		"build":   time.Second,
+		"release": time.Minute,
+		"deploy":  time.Minute,
+	})
+
+	// Assert
+	g.Expect(build.Duration).To(Equal(time.Second))
+	g.Expect(unmatched).To(Equal([]string{"deploy", "release"}))
+}
+
+func TestApply_SubtractsDependenciesAndCalls(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange: release waits for build and lint in parallel, then calls publish
+	gr := graph.New()
+	release := gr.AddNode("release")
+	build := gr.AddNode("build")
+	lint := gr.AddNode("lint")
+	publish := gr.AddNode("publish")
+
+	release.AddEdge(build)
+	release.AddEdge(lint)
+	release.AddEdge(publish).SetClass(graph.EdgeClassCall)
+
+	// Act
+	Apply(gr, map[string]time.Duration{
+		"release": 100 * time.Second,
+		"build":   60 * time.Second,
+		"lint":    20 * time.Second,
+		"publish": 30 * time.Second,
+	})
+
+	// Assert: release itself took 100s - 60s (slowest dependency) - 30s (call)
+	g.Expect(release.Duration).To(Equal(10 * time.Second))
+	g.Expect(build.Duration).To(Equal(60 * time.Second))
+	g.Expect(publish.Duration).To(Equal(30 * time.Second))
+}
+
+func TestApply_ShorterThanDependencies_GivesZero(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	ci := gr.AddNode("ci")
+	ci.AddEdge(gr.AddNode("build"))
+
+	// Act
+	Apply(gr, map[string]time.Duration{"ci": time.Second, "build": 2 * time.Second})
+
+	// Assert
+	g.Expect(ci.Duration).To(BeZero())
+}
@@ -0,0 +1,126 @@
+package durations
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// taskEventRe matches the lines `task --verbose` writes as each task starts and finishes,
+// capturing any prefix (expected to hold a timestamp), the task name and the event.
+var taskEventRe = regexp.MustCompile(`^(.*?)\s*task: "([^"]+)" (started|finished)\s*$`)
+
+// ansiEscapeRe matches the terminal colour codes task uses for its log output.
+var ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// timestampLayouts are the timestamp formats recognised at the start of each log line.
+// These cover CI log timestamps (e.g. GitHub Actions) and common tools such as `ts`.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	time.DateTime,
+	time.Stamp,
+	time.TimeOnly,
+}
+
+// ReadTaskLog reads the output of `task --verbose` and measures the time between each
+// `task: "name" started` and `task: "name" finished` line. Task itself does not timestamp
+// its output, so each line must be prefixed by a timestamp, as added by most CI systems or
+// by piping through a tool such as `ts`. When a task runs more than once, the longest run
+// is used.
+func ReadTaskLog(r io.Reader) (map[string]time.Duration, error) {
+	timer := newRunTimer()
+	events := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := ansiEscapeRe.ReplaceAllString(scanner.Text(), "")
+
+		match := taskEventRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		events++
+
+		at, ok := parseTimestamp(match[1])
+		if !ok {
+			continue
+		}
+
+		if match[3] == "started" {
+			timer.start(match[2], at)
+		} else {
+			timer.finish(match[2], at)
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read task log")
+	}
+
+	if events > 0 && len(timer.longest) == 0 {
+		return nil, eris.New(
+			"task log has no timestamps; prefix each line with a timestamp (e.g. pipe through `ts`)")
+	}
+
+	return timer.longest, nil
+}
+
+// runTimer pairs up the start and finish of each task run, tracking the longest run of each.
+type runTimer struct {
+	started map[string][]time.Time
+	longest map[string]time.Duration
+}
+
+func newRunTimer() *runTimer {
+	return &runTimer{
+		started: make(map[string][]time.Time),
+		longest: make(map[string]time.Duration),
+	}
+}
+
+// start records the start of a run of the named task.
+func (t *runTimer) start(name string, at time.Time) {
+	t.started[name] = append(t.started[name], at)
+}
+
+// finish records the end of a run of the named task, pairing it with the earliest
+// outstanding start. Finishes without a matching start are ignored.
+func (t *runTimer) finish(name string, at time.Time) {
+	if len(t.started[name]) == 0 {
+		return
+	}
+
+	d := at.Sub(t.started[name][0])
+	t.started[name] = t.started[name][1:]
+
+	if d < 0 {
+		// Time-only timestamps wrap around at midnight
+		d += 24 * time.Hour
+	}
+
+	t.longest[name] = max(t.longest[name], d)
+}
+
+// parseTimestamp attempts to parse the given log line prefix as a timestamp.
+func parseTimestamp(prefix string) (time.Time, bool) {
+	prefix = strings.TrimSpace(prefix)
+	prefix = strings.TrimSuffix(strings.TrimPrefix(prefix, "["), "]")
+
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, prefix)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,130 @@
+// Package edgestyle selects edges using config.EdgeStyleRule criteria and resolves the
+// combined presentation for each edge. It is shared by the graphviz and mermaid rendering
+// packages so that both interpret the rules identically.
+package edgestyle
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+var patternCache sync.Map // map[string]*regexp.Regexp
+
+// Style is the combined presentation of an edge after applying all matching rules.
+// Empty fields indicate that no rule specified a value.
+type Style struct {
+	Color string
+	Style string
+	Width int
+	Label string
+}
+
+// IsEmpty returns true if no presentation has been specified.
+func (s Style) IsEmpty() bool {
+	return s == Style{}
+}
+
+// Resolve returns the combined style of all rules that match the given edge.
+// Rules are applied in order; in case of conflicts, the last matching rule wins.
+func Resolve(
+	rules []config.EdgeStyleRule,
+	edge *graph.Edge,
+) (Style, error) {
+	var result Style
+
+	for _, rule := range rules {
+		ok, err := Matches(rule, edge)
+		if err != nil {
+			return Style{}, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		result.apply(rule)
+	}
+
+	return result, nil
+}
+
+// Matches returns true if the given edge satisfies every selection criterion of the rule.
+func Matches(
+	rule config.EdgeStyleRule,
+	edge *graph.Edge,
+) (bool, error) {
+	if rule.Class != "" && rule.Class != edge.Class() {
+		return false, nil
+	}
+
+	fromID := edge.From().ID()
+	toID := edge.To().ID()
+
+	if rule.CrossesNamespace && namespace.Namespace(fromID) == namespace.Namespace(toID) {
+		return false, nil
+	}
+
+	ok, err := matchesPattern(rule.From, fromID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return matchesPattern(rule.To, toID)
+}
+
+// apply overlays the presentation from the given rule onto the style.
+func (s *Style) apply(rule config.EdgeStyleRule) {
+	if rule.Color != "" {
+		s.Color = rule.Color
+	}
+
+	if rule.Style != "" {
+		s.Style = rule.Style
+	}
+
+	if rule.Width > 0 {
+		s.Width = rule.Width
+	}
+
+	if rule.Label != "" {
+		s.Label = rule.Label
+	}
+}
+
+// matchesPattern returns true if id matches pattern. An empty pattern matches everything.
+func matchesPattern(pattern string, id string) (bool, error) {
+	if pattern == "" {
+		return true, nil
+	}
+
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	return re.MatchString(id), nil
+}
+
+// compilePattern compiles the given glob-style pattern, caching the result.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if value, ok := patternCache.Load(pattern); ok {
+		if re, ok := value.(*regexp.Regexp); ok {
+			return re, nil
+		}
+	}
+
+	re, err := namespace.CompileMatchPattern(pattern)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to compile edge match pattern %q", pattern)
+	}
+
+	patternCache.Store(pattern, re)
+
+	return re, nil
+}
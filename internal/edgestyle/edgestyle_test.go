@@ -0,0 +1,192 @@
+package edgestyle
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+//
+// Matches tests.
+//
+
+func TestMatches_VariousRules_ReturnsExpectedResult(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		rule     config.EdgeStyleRule
+		from     string
+		to       string
+		class    string
+		expected bool
+	}{
+		"empty rule matches everything": {
+			rule:     config.EdgeStyleRule{},
+			from:     "build",
+			to:       "test",
+			expected: true,
+		},
+		"from pattern matches": {
+			rule:     config.EdgeStyleRule{From: "internal:*"},
+			from:     "internal:build",
+			to:       "test",
+			expected: true,
+		},
+		"from pattern does not match": {
+			rule:     config.EdgeStyleRule{From: "internal:*"},
+			from:     "build",
+			to:       "internal:test",
+			expected: false,
+		},
+		"to pattern matches": {
+			rule:     config.EdgeStyleRule{To: "test*"},
+			from:     "build",
+			to:       "test:unit",
+			expected: true,
+		},
+		"to pattern does not match": {
+			rule:     config.EdgeStyleRule{To: "test*"},
+			from:     "build",
+			to:       "lint",
+			expected: false,
+		},
+		"class matches": {
+			rule:     config.EdgeStyleRule{Class: graph.EdgeClassCall},
+			from:     "build",
+			to:       "test",
+			class:    graph.EdgeClassCall,
+			expected: true,
+		},
+		"class does not match": {
+			rule:     config.EdgeStyleRule{Class: graph.EdgeClassCall},
+			from:     "build",
+			to:       "test",
+			class:    graph.EdgeClassDep,
+			expected: false,
+		},
+		"crosses namespace when namespaces differ": {
+			rule:     config.EdgeStyleRule{CrossesNamespace: true},
+			from:     "internal:build",
+			to:       "test",
+			expected: true,
+		},
+		"crosses namespace when namespaces are the same": {
+			rule:     config.EdgeStyleRule{CrossesNamespace: true},
+			from:     "internal:build",
+			to:       "internal:test",
+			expected: false,
+		},
+		"leaves namespace": {
+			rule:     config.EdgeStyleRule{From: "internal:*", CrossesNamespace: true},
+			from:     "internal:build",
+			to:       "release:publish",
+			expected: true,
+		},
+		"all criteria required": {
+			rule:     config.EdgeStyleRule{From: "internal:*", Class: graph.EdgeClassDep},
+			from:     "internal:build",
+			to:       "test",
+			class:    graph.EdgeClassCall,
+			expected: false,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Arrange
+			edge := graph.NewNode(c.from).AddEdge(graph.NewNode(c.to))
+			edge.SetClass(c.class)
+
+			// Act
+			result, err := Matches(c.rule, edge)
+
+			// Assert
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(result).To(Equal(c.expected))
+		})
+	}
+}
+
+func TestMatches_InvalidPattern_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rule := config.EdgeStyleRule{To: "[unclosed"}
+
+	// Act
+	_, err := Matches(rule, edge)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("failed to compile edge match pattern")))
+}
+
+//
+// Resolve tests.
+//
+
+func TestResolve_NoMatchingRules_ReturnsEmptyStyle(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rules := []config.EdgeStyleRule{
+		{From: "lint", Color: "red"},
+	}
+
+	// Act
+	style, err := Resolve(rules, edge)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(style.IsEmpty()).To(BeTrue())
+}
+
+func TestResolve_MultipleMatchingRules_LastRuleWins(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rules := []config.EdgeStyleRule{
+		{Color: "red", Style: "dashed", Width: 2},
+		{To: "test", Color: "blue", Label: "checks"},
+	}
+
+	// Act
+	style, err := Resolve(rules, edge)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(style).To(Equal(Style{
+		Color: "blue",
+		Style: "dashed",
+		Width: 2,
+		Label: "checks",
+	}))
+}
+
+func TestResolve_InvalidPattern_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rules := []config.EdgeStyleRule{
+		{From: "[unclosed", Color: "red"},
+	}
+
+	// Act
+	_, err := Resolve(rules, edge)
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+}
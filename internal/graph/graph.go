@@ -88,8 +88,8 @@ func (g *Graph) ReachableFrom(
 }
 
 // FilterNodes returns a new graph containing only the nodes present in the keep
-// set, along with the edges between them. Node metadata (Kind, Label and Description)
-// is preserved.
+// set, along with the edges between them. Node metadata (Kind, Label, Description, source
+// location, Commands, RunOnce, Duration and Wave) is preserved.
 func (g *Graph) FilterNodes(keep map[string]bool) *Graph {
 	result := New()
 
@@ -98,10 +98,7 @@ func (g *Graph) FilterNodes(keep map[string]bool) *Graph {
 			continue
 		}
 
-		newNode := result.AddNode(id)
-		newNode.Kind = node.Kind
-		newNode.Label = node.Label
-		newNode.Description = node.Description
+		copyNodeMetadata(result.AddNode(id), node)
 	}
 
 	for id, node := range g.nodes {
@@ -115,6 +112,47 @@ func (g *Graph) FilterNodes(keep map[string]bool) *Graph {
 	return result
 }
 
+// AddGraph adds copies of all the nodes and edges of other to this graph, with the given prefix
+// added to the ID of each node. Node metadata is preserved as for FilterNodes. Existing nodes
+// with the same (prefixed) IDs are overwritten.
+func (g *Graph) AddGraph(other *Graph, prefix string) {
+	for id, node := range other.nodes {
+		copyNodeMetadata(g.AddNode(prefix+id), node)
+	}
+
+	for id, node := range other.nodes {
+		fromNode := g.nodes[prefix+id]
+
+		for _, edge := range node.Edges() {
+			newEdge := fromNode.AddEdge(g.nodes[prefix+edge.To().ID()])
+			newEdge.SetClass(edge.Class())
+
+			if edge.Label() != "" {
+				newEdge.SetLabel(edge.Label())
+			}
+		}
+	}
+}
+
+// copyNodeMetadata copies everything except the ID and edges from src to dst.
+func copyNodeMetadata(dst *Node, src *Node) {
+	dst.Kind = src.Kind
+	dst.Label = src.Label
+	dst.Description = src.Description
+	dst.File = src.File
+	dst.Line = src.Line
+	dst.Commands = src.Commands
+	dst.Aliases = src.Aliases
+	dst.Sources = src.Sources
+	dst.Generates = src.Generates
+	dst.Internal = src.Internal
+	dst.Prompt = src.Prompt
+	dst.Placeholder = src.Placeholder
+	dst.RunOnce = src.RunOnce
+	dst.Duration = src.Duration
+	dst.Wave = src.Wave
+}
+
 func (g *Graph) createScanningQueue(seeds map[string]bool) []string {
 	queue := make([]string, 0, len(seeds))
 
@@ -376,3 +376,37 @@ func TestGraph_FilterNodes_EdgeLabel_IsPreserved(t *testing.T) {
 	g.Expect(resA.Edges()).To(gomega.HaveLen(1))
 	g.Expect(resA.Edges()[0].Label()).To(gomega.Equal("next"))
 }
+
+// AddGraph tests
+
+func TestGraph_AddGraph_CopiesPrefixedNodesAndEdges(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	other := New()
+	build := other.AddNode("build")
+	build.Description = "Build it"
+	build.RunOnce = true
+	build.AddEdge(other.AddNode("generate")).SetClass(EdgeClassDep)
+
+	graph := New()
+	graph.AddNode("build")
+
+	// Act
+	graph.AddGraph(other, "api:")
+
+	// Assert
+	g.Expect(graph.nodes).To(gomega.HaveLen(3))
+
+	node, ok := graph.Node("api:build")
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(node.Description).To(gomega.Equal("Build it"))
+	g.Expect(node.RunOnce).To(gomega.BeTrue())
+	g.Expect(node.Edges()).To(gomega.HaveLen(1))
+	g.Expect(node.Edges()[0].To().ID()).To(gomega.Equal("api:generate"))
+	g.Expect(node.Edges()[0].Class()).To(gomega.Equal(EdgeClassDep))
+
+	original, _ := graph.Node("build")
+	g.Expect(original.Edges()).To(gomega.BeEmpty())
+}
@@ -1,5 +1,7 @@
 package graph
 
+import "time"
+
 // NodeKind represents the type of a node in the graph.
 type NodeKind string
 
@@ -30,6 +32,46 @@ type Node struct {
 	// Description returns the description of the node.
 	Description string
 
+	// File is the path of the Taskfile defining the task, or "" if unknown.
+	File string
+
+	// Line is the line on which the task is defined within File, or zero if unknown.
+	Line int
+
+	// Commands summarises the commands run by the task, one entry per command.
+	Commands []string
+
+	// Aliases are the other names by which the task can be run.
+	Aliases []string
+
+	// Sources is the number of source globs the task checks to decide whether it is up to date.
+	Sources int
+
+	// Generates is the number of globs of files the task generates.
+	Generates int
+
+	// Internal is true if the task is internal, and so can't be run directly.
+	Internal bool
+
+	// Prompt is true if the task asks for confirmation before it runs.
+	Prompt bool
+
+	// Placeholder is true if the node stands in for a remote include that couldn't be loaded,
+	// rather than being a real task.
+	Placeholder bool
+
+	// RunOnce is true if the task runs at most once however many other tasks need it
+	// (`run: once`), rather than each time it is needed.
+	RunOnce bool
+
+	// Duration is the measured time the task spends running its own commands, excluding the
+	// tasks it depends on and calls, or zero if unknown.
+	Duration time.Duration
+
+	// Wave is the 1-based execution wave assigned to the task by schedule analysis, or zero if
+	// the task has not been analysed. Tasks in the same wave can run concurrently.
+	Wave int
+
 	// Edges holds the outgoing edges from this node to other nodes in the graph.
 	edges []*Edge
 }
@@ -69,3 +111,18 @@ func (n *Node) DisplayLabel() string {
 
 	return n.id
 }
+
+// DisplayDuration returns the duration of the node formatted for display, rounded to a
+// precision appropriate for its magnitude. Returns "" if the duration is unknown.
+func (n *Node) DisplayDuration() string {
+	switch {
+	case n.Duration <= 0:
+		return ""
+	case n.Duration >= time.Minute:
+		return n.Duration.Round(time.Second).String()
+	case n.Duration >= time.Second:
+		return n.Duration.Round(100 * time.Millisecond).String()
+	default:
+		return n.Duration.Round(time.Millisecond).String()
+	}
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 )
@@ -102,3 +103,34 @@ func TestNode_DisplayLabel_WithExplicitLabel_ReturnsLabel(t *testing.T) {
 
 	g.Expect(node.DisplayLabel()).To(gomega.Equal("My Task"))
 }
+
+func TestNode_DisplayDuration_FormatsByMagnitude(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		duration time.Duration
+		expected string
+	}{
+		"unknown":      {duration: 0, expected: ""},
+		"milliseconds": {duration: 250 * time.Millisecond, expected: "250ms"},
+		"seconds":      {duration: 2345 * time.Millisecond, expected: "2.3s"},
+		"minutes":      {duration: 90*time.Second + 400*time.Millisecond, expected: "1m30s"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Arrange
+			node := NewNode("task")
+			node.Duration = c.duration
+
+			// Act
+			result := node.DisplayDuration()
+
+			// Assert
+			g.Expect(result).To(gomega.Equal(c.expected))
+		})
+	}
+}
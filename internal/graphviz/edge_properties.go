@@ -1,6 +1,10 @@
 package graphviz
 
-import "github.com/theunrepentantgeek/task-graph/internal/config"
+import (
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/edgestyle"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
 
 type edgeProperties struct {
 	properties
@@ -8,7 +12,7 @@ type edgeProperties struct {
 
 func newEdgeProperties() edgeProperties {
 	return edgeProperties{
-		properties: make(map[string]string),
+		properties: newProperties(),
 	}
 }
 
@@ -27,3 +31,25 @@ func (p edgeProperties) AddAttributes(
 		p.Addf("penwidth", "%d", cfg.Width)
 	}
 }
+
+// AddStyleRuleAttributes adds the attributes from all the given EdgeStyleRules that select
+// the given edge to the properties map. Later rules take precedence over earlier ones.
+func (p edgeProperties) AddStyleRuleAttributes(
+	edge *graph.Edge,
+	rules []config.EdgeStyleRule,
+) error {
+	style, err := edgestyle.Resolve(rules, edge)
+	if err != nil {
+		return err
+	}
+
+	p.AddIfNotEmpty("color", style.Color)
+	p.AddIfNotEmpty("style", style.Style)
+	p.AddIfNotEmpty("label", style.Label)
+
+	if style.Width > 0 {
+		p.Addf("penwidth", "%d", style.Width)
+	}
+
+	return nil
+}
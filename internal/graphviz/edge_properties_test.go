@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
 )
 
 func TestNewEdgeProperties_Default_ReturnsEmptyProperties(t *testing.T) {
@@ -16,8 +17,8 @@ func TestNewEdgeProperties_Default_ReturnsEmptyProperties(t *testing.T) {
 	p := newEdgeProperties()
 
 	// Assert
-	g.Expect(p.properties).NotTo(BeNil())
-	g.Expect(p.properties).To(BeEmpty())
+	g.Expect(p.values).NotTo(BeNil())
+	g.Expect(p.values).To(BeEmpty())
 }
 
 //
@@ -35,7 +36,7 @@ func TestEdgePropertiesAddAttributes_WithNilConfig_LeavesPropertiesEmpty(t *test
 	p.AddAttributes(nil)
 
 	// Assert
-	g.Expect(p.properties).To(BeEmpty())
+	g.Expect(p.values).To(BeEmpty())
 }
 
 func TestEdgePropertiesAddAttributes_WhenColorConfigured_SetsColorAttribute(t *testing.T) {
@@ -52,7 +53,7 @@ func TestEdgePropertiesAddAttributes_WhenColorConfigured_SetsColorAttribute(t *t
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
 }
 
 func TestEdgePropertiesAddAttributes_WhenWidthConfigured_SetsPenwidthAttribute(t *testing.T) {
@@ -69,7 +70,7 @@ func TestEdgePropertiesAddAttributes_WhenWidthConfigured_SetsPenwidthAttribute(t
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("penwidth", "3"))
+	g.Expect(p.values).To(HaveKeyWithValue("penwidth", "3"))
 }
 
 func TestEdgePropertiesAddAttributes_WhenStyleConfigured_SetsStyleAttribute(t *testing.T) {
@@ -86,7 +87,7 @@ func TestEdgePropertiesAddAttributes_WhenStyleConfigured_SetsStyleAttribute(t *t
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("style", "dashed"))
+	g.Expect(p.values).To(HaveKeyWithValue("style", "dashed"))
 }
 
 func TestEdgePropertiesAddAttributes_WhenMultipleAttributesConfigured_SetsAllAttributes(t *testing.T) {
@@ -105,9 +106,9 @@ func TestEdgePropertiesAddAttributes_WhenMultipleAttributesConfigured_SetsAllAtt
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "blue"))
-	g.Expect(p.properties).To(HaveKeyWithValue("penwidth", "2"))
-	g.Expect(p.properties).To(HaveKeyWithValue("style", "bold"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("penwidth", "2"))
+	g.Expect(p.values).To(HaveKeyWithValue("style", "bold"))
 }
 
 func TestEdgePropertiesAddAttributes_WhenWidthNotPositive_OmitsPenwidth(t *testing.T) {
@@ -126,7 +127,55 @@ func TestEdgePropertiesAddAttributes_WhenWidthNotPositive_OmitsPenwidth(t *testi
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).NotTo(HaveKey("penwidth"))
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "green"))
-	g.Expect(p.properties).To(HaveKeyWithValue("style", "solid"))
+	g.Expect(p.values).NotTo(HaveKey("penwidth"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "green"))
+	g.Expect(p.values).To(HaveKeyWithValue("style", "solid"))
+}
+
+//
+// AddStyleRuleAttributes tests.
+//
+
+func TestEdgePropertiesAddStyleRuleAttributes_WhenRuleMatches_OverridesAttributes(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	p := newEdgeProperties()
+	p.Add("color", "black")
+
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rules := []config.EdgeStyleRule{
+		{To: "test", Color: "red", Width: 2, Label: "verifies"},
+	}
+
+	// Act
+	err := p.AddStyleRuleAttributes(edge, rules)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("penwidth", "2"))
+	g.Expect(p.values).To(HaveKeyWithValue("label", "verifies"))
+}
+
+func TestEdgePropertiesAddStyleRuleAttributes_WhenRuleDoesNotMatch_LeavesPropertiesUnchanged(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	p := newEdgeProperties()
+	p.Add("color", "black")
+
+	edge := graph.NewNode("build").AddEdge(graph.NewNode("test"))
+	rules := []config.EdgeStyleRule{
+		{Class: graph.EdgeClassCall, Color: "red"},
+	}
+
+	// Act
+	err := p.AddStyleRuleAttributes(edge, rules)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.values).To(Equal(map[string]string{"color": "black"}))
 }
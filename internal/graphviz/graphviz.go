@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
 
 	"github.com/rotisserie/eris"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
 	"github.com/theunrepentantgeek/task-graph/internal/graph"
 	"github.com/theunrepentantgeek/task-graph/internal/graphns"
+	"github.com/theunrepentantgeek/task-graph/internal/htmllabel"
 	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
 	"github.com/theunrepentantgeek/task-graph/internal/safe"
 )
@@ -61,11 +65,19 @@ func WriteTo(
 	iw := indentwriter.New()
 	root := iw.Add("digraph {")
 
+	if cfg != nil && cfg.Graphviz != nil {
+		writeDefaultsTo(root, cfg.Graphviz)
+	}
+
 	err := writeAllNodesTo(root, taskNodes, cfg, reg)
 	if err != nil {
 		return err
 	}
 
+	if cfg != nil && cfg.Graphviz != nil && cfg.Graphviz.RankByWave && !cfg.GroupByNamespace {
+		writeWaveRanksTo(root, taskNodes, reg)
+	}
+
 	if len(varNodes) > 0 {
 		err = writeVariableNodesTo(root, varNodes, cfg, reg)
 		if err != nil {
@@ -73,6 +85,10 @@ func WriteTo(
 		}
 	}
 
+	if cfg != nil && cfg.Legend {
+		writeLegendTo(root, cfg, len(varNodes) > 0, reg)
+	}
+
 	iw.Add("}")
 
 	_, err = iw.WriteTo(w, indent)
@@ -83,6 +99,47 @@ func WriteTo(
 	return nil
 }
 
+// writeDefaultsTo writes the layout, fonts and colours applying to the whole graph, if any.
+func writeDefaultsTo(root *indentwriter.Line, gv *config.Graphviz) {
+	text := newProperties()
+	text.AddIfNotEmpty("fontname", gv.Font)
+	text.AddIfNotEmpty("fontcolor", gv.FontColor)
+
+	if gv.FontSize > 0 {
+		text.Addf("fontsize", "%d", gv.FontSize)
+	}
+
+	graphProps := text.Clone()
+	graphProps.AddIfNotEmpty("bgcolor", gv.Background)
+	graphProps.AddIfNotEmpty("rankdir", gv.RankDir)
+	graphProps.AddIfNotEmpty("splines", gv.Splines)
+
+	if gv.NodeSep > 0 {
+		graphProps.Add("nodesep", strconv.FormatFloat(gv.NodeSep, 'f', -1, 64))
+	}
+
+	if gv.RankSep > 0 {
+		graphProps.Add("ranksep", strconv.FormatFloat(gv.RankSep, 'f', -1, 64))
+	}
+
+	if gv.Concentrate {
+		graphProps.Add("concentrate", "true")
+	}
+
+	if graphProps.Len() == 0 {
+		return
+	}
+
+	graphProps.WriteTo("graph", root)
+
+	if text.Len() > 0 {
+		text.WriteTo("node", root)
+		text.WriteTo("edge", root)
+	}
+
+	root.Add("")
+}
+
 func writeAllNodesTo(
 	root *indentwriter.Line,
 	nodes []*graph.Node,
@@ -143,6 +200,10 @@ func writeNamespaceSubgraphTo(
 	subgraph := parent.Addf("subgraph %s {", reg.IDWithPrefix("cluster_", ns))
 	subgraph.Addf("label=%q", ns)
 
+	if cfg != nil && cfg.Graphviz != nil {
+		writeClusterStyleTo(subgraph, cfg.Graphviz.Clusters)
+	}
+
 	// Write nodes directly in this namespace, then child subgraphs, with blank lines between items
 	err := writeNodesTo(subgraph, nsToNodes[ns], cfg, reg)
 	if err != nil {
@@ -161,6 +222,32 @@ func writeNamespaceSubgraphTo(
 	return nil
 }
 
+// writeClusterStyleTo writes the attributes of a namespace cluster, as configured.
+func writeClusterStyleTo(subgraph *indentwriter.Line, cluster *config.GraphvizCluster) {
+	if cluster == nil {
+		return
+	}
+
+	props := newProperties()
+	props.AddIfNotEmpty("color", cluster.Color)
+	props.AddIfNotEmpty("fillcolor", cluster.FillColor)
+	props.AddIfNotEmpty("style", cluster.Style)
+	props.AddIfNotEmpty("fontname", cluster.Font)
+	props.AddIfNotEmpty("fontcolor", cluster.FontColor)
+
+	if cluster.FontSize > 0 {
+		props.Addf("fontsize", "%d", cluster.FontSize)
+	}
+
+	if props.ContainsKey("fillcolor") && !props.ContainsKey("style") {
+		props.Add("style", "filled")
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(props.values)) {
+		subgraph.Addf("%s=%q", key, props.values[key])
+	}
+}
+
 // writeNodesTo writes all nodes and their edges to the graphviz output.
 func writeNodesTo(
 	root *indentwriter.Line,
@@ -191,7 +278,10 @@ func writeNodeTo(
 	}
 
 	for _, edge := range node.Edges() {
-		writeEdgeTo(root, edge, cfg, reg)
+		err = writeEdgeTo(root, edge, cfg, reg)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Finish with a blank line for readability
@@ -206,9 +296,47 @@ func writeNodeDefinitionTo(
 	cfg *config.Config,
 	reg *safe.Registry,
 ) error {
+	if cfg != nil && cfg.Graphviz != nil && cfg.Graphviz.NodeLabels == config.NodeLabelsHTML {
+		return writeHTMLNodeDefinitionTo(root, node, cfg, reg)
+	}
+
 	return writeNodeDefinitionWithShapeTo(root, node, cfg, reg, "Mrecord", applyNodeConfig)
 }
 
+// writeHTMLNodeDefinitionTo writes a task node labelled with an HTML-like table. The table draws
+// the node, so its fill and border colours are passed to the label template rather than applied
+// to the node itself.
+func writeHTMLNodeDefinitionTo(
+	root *indentwriter.Line,
+	node *graph.Node,
+	cfg *config.Config,
+	reg *safe.Registry,
+) error {
+	props := newNodeProperties()
+
+	err := applyNodeConfig(&props, node, cfg)
+	if err != nil {
+		return err
+	}
+
+	data := htmllabel.New(node, props.values["fillcolor"], props.values["color"])
+
+	label, err := htmllabel.Render(cfg.Graphviz.LabelTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	props.Remove("fillcolor")
+	props.Remove("style")
+	props.Add("shape", "plain")
+	props.AddHTML("label", label)
+
+	id := fmt.Sprintf("\"%s\"", reg.ID(node.ID()))
+	props.WriteTo(id, root)
+
+	return nil
+}
+
 func applyNodeConfig(props *nodeProperties, node *graph.Node, cfg *config.Config) error {
 	if cfg == nil || cfg.Graphviz == nil {
 		return nil
@@ -216,6 +344,11 @@ func applyNodeConfig(props *nodeProperties, node *graph.Node, cfg *config.Config
 
 	props.AddAttributes(cfg.Graphviz.TaskNodes)
 
+	err := props.AddLinkAttributes(node, cfg.Links)
+	if err != nil {
+		return err
+	}
+
 	for _, rule := range cfg.NodeStyleRules {
 		err := props.AddStyleRuleAttributes(node.ID(), rule)
 		if err != nil {
@@ -231,7 +364,7 @@ func writeEdgeTo(
 	edge *graph.Edge,
 	cfg *config.Config,
 	reg *safe.Registry,
-) {
+) error {
 	props := newEdgeProperties()
 
 	if edge.Label() != "" {
@@ -239,15 +372,13 @@ func writeEdgeTo(
 	}
 
 	if cfg != nil && cfg.Graphviz != nil {
-		switch edge.Class() {
-		case graph.EdgeClassDep:
-			props.AddAttributes(cfg.Graphviz.DependencyEdges)
-		case graph.EdgeClassCall:
-			props.AddAttributes(cfg.Graphviz.CallEdges)
-		case graph.EdgeClassVar:
-			props.AddAttributes(cfg.Graphviz.VariableEdges)
-		default:
-			// Nothing
+		props.AddAttributes(edgeClassConfig(cfg.Graphviz, edge.Class()))
+	}
+
+	if cfg != nil {
+		err := props.AddStyleRuleAttributes(edge, cfg.EdgeStyleRules)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -257,6 +388,23 @@ func writeEdgeTo(
 			reg.ID(edge.From().ID()),
 			reg.ID(edge.To().ID())),
 		root)
+
+	return nil
+}
+
+// edgeClassConfig returns the configured presentation for edges of the given class,
+// or nil if the class has no specific presentation.
+func edgeClassConfig(gv *config.Graphviz, class string) *config.GraphvizEdge {
+	switch class {
+	case graph.EdgeClassDep:
+		return gv.DependencyEdges
+	case graph.EdgeClassCall:
+		return gv.CallEdges
+	case graph.EdgeClassVar:
+		return gv.VariableEdges
+	default:
+		return nil
+	}
 }
 
 func writeVariableNodesTo(
@@ -272,7 +420,10 @@ func writeVariableNodesTo(
 		}
 
 		for _, edge := range node.Edges() {
-			writeEdgeTo(root, edge, cfg, reg)
+			err = writeEdgeTo(root, edge, cfg, reg)
+			if err != nil {
+				return err
+			}
 		}
 
 		root.Add("")
@@ -290,6 +441,31 @@ func writeVariableNodesTo(
 	return nil
 }
 
+// writeWaveRanksTo writes a rank=same group for each execution wave, so that tasks which can
+// run concurrently are laid out side by side. Tasks without a wave are left unconstrained.
+func writeWaveRanksTo(
+	root *indentwriter.Line,
+	nodes []*graph.Node,
+	reg *safe.Registry,
+) {
+	waves := make(map[int][]*graph.Node)
+	for _, node := range nodes {
+		if node.Wave > 0 {
+			waves[node.Wave] = append(waves[node.Wave], node)
+		}
+	}
+
+	for _, wave := range slices.Sorted(maps.Keys(waves)) {
+		rank := root.Addf("{ rank=same // wave %d", wave)
+
+		for _, node := range waves[wave] {
+			rank.Addf("\"%s\"", reg.ID(node.ID()))
+		}
+
+		root.Add("}")
+	}
+}
+
 func writeVariableNodeDefinitionTo(
 	root *indentwriter.Line,
 	node *graph.Node,
@@ -311,6 +487,7 @@ func writeNodeDefinitionWithShapeTo(
 
 	rec := newRecord()
 	rec.add(node.DisplayLabel())
+	rec.add(node.DisplayDuration())
 	rec.addWrapped(margin, node.Description)
 
 	props := newNodeProperties()
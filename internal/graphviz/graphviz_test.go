@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 	"github.com/sebdah/goldie/v2"
@@ -560,3 +561,291 @@ func buildGraphWithVariables(t *testing.T) *graph.Graph {
 
 	return gr
 }
+
+// TestWriteTo_WithEdgeStyleRules_AppliesMatchingStyles verifies that edges selected by
+// EdgeStyleRules pick up the configured colour, style, width and label.
+func TestWriteTo_WithEdgeStyleRules_AppliesMatchingStyles(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildNamespacedGraph(t)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{From: "cmd:*", CrossesNamespace: true, Color: "red", Width: 3, Label: "cross-namespace"},
+		{Class: graph.EdgeClassDep, To: "*golden", Style: "dotted"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "namespace_graph_with_edge_style_rules", buf.Bytes())
+}
+
+func TestWriteTo_WithInvalidEdgeStyleRulePattern_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{From: "[invalid", Color: "red"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("failed to compile edge match pattern")))
+}
+
+func TestWriteTo_WithLegend_WritesLegend(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildGraphWithVariables(t)
+
+	cfg := config.New()
+	cfg.Legend = true
+	cfg.NodeStyleRules = []config.NodeStyleRule{
+		{Match: "build", Name: "Entry points", Description: "Tasks run by CI", FillColor: "gold"},
+		{Match: "test", FontColor: "blue"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "sample_graph_with_legend", buf.Bytes())
+}
+
+func TestWriteTo_WithLegendAndNoVariables_OmitsVariableEntries(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.Legend = true
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("Legend"))
+	g.Expect(buf.String()).To(gomega.ContainSubstring("dependency"))
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("variable"))
+}
+
+func TestWriteNodeDefinitionTo_WithDuration_IncludesDurationInLabel(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	node := graph.NewNode("build")
+	node.Duration = 2500 * time.Millisecond
+
+	iw := indentwriter.New()
+	root := iw.Add("digraph {")
+
+	// Act
+	err := writeNodeDefinitionTo(root, node, config.New(), safe.NewRegistry())
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	root.Add("}")
+
+	_, err = iw.WriteTo(&buf, "  ")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(buf.String()).To(gomega.ContainSubstring(`label="{build | 2.5s}"`))
+}
+
+func TestWriteTo_WithRankByWave_WritesRankSameGroups(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	gr.AddNode("build").Wave = 2
+	gr.AddNode("generate").Wave = 1
+	gr.AddNode("lint").Wave = 1
+	gr.AddNode("docs")
+
+	cfg := config.New()
+	cfg.Graphviz.RankByWave = true
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	output := buf.String()
+	g.Expect(output).To(gomega.ContainSubstring("{ rank=same // wave 1\n    \"generate\"\n    \"lint\"\n  }"))
+	g.Expect(output).To(gomega.ContainSubstring("{ rank=same // wave 2\n    \"build\"\n  }"))
+	g.Expect(output).NotTo(gomega.ContainSubstring("wave 3"))
+}
+
+func TestWriteTo_WithRankByWaveAndGroupByNamespace_OmitsRanks(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	gr.AddNode("cmd:build").Wave = 1
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.Graphviz.RankByWave = true
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("rank=same"))
+}
+
+func TestWriteTo_WithTheme_WritesBackgroundFontsAndClusterStyle(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildNamespacedGraph(t)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+
+	theme, ok := cfg.LookupTheme(config.ThemeDark)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(config.ApplyTheme(cfg, config.ThemeDark, theme, nil)).To(gomega.Succeed())
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "namespace_graph_dark_theme", buf.Bytes())
+}
+
+func TestWriteTo_WithGraphAndClusterSettings_WritesAttributes(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildNamespacedGraph(t)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.Graphviz.RankDir = "LR"
+	cfg.Graphviz.Splines = "ortho"
+	cfg.Graphviz.NodeSep = 0.5
+	cfg.Graphviz.RankSep = 1.25
+	cfg.Graphviz.Concentrate = true
+	cfg.Graphviz.Clusters = &config.GraphvizCluster{
+		FillColor: "#f0f0f0",
+		Font:      "Helvetica-Bold",
+		FontSize:  20,
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "namespace_graph_with_graph_settings", buf.Bytes())
+}
+
+func TestWriteTo_WithHTMLNodeLabels_WritesHTMLTables(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	alpha, _ := gr.Node("alpha")
+	alpha.Description = "Builds <everything> & more"
+	alpha.Aliases = []string{"a", "first"}
+	alpha.Sources = 3
+	alpha.Generates = 1
+	alpha.Internal = true
+
+	beta, _ := gr.Node("beta")
+	beta.Prompt = true
+
+	cfg := config.New()
+	cfg.Graphviz.NodeLabels = config.NodeLabelsHTML
+	cfg.NodeStyleRules = []config.NodeStyleRule{
+		{Match: "alpha", FillColor: "#a6cee3", Style: "filled"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "sample_graph_with_html_labels", buf.Bytes())
+}
+
+func TestWriteTo_WithLabelTemplate_UsesTemplate(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.Graphviz.NodeLabels = config.NodeLabelsHTML
+	cfg.Graphviz.LabelTemplate = `<TABLE><TR><TD>{{.Name}}</TD></TR></TABLE>`
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring(`label=<<TABLE><TR><TD>alpha</TD></TR></TABLE>>`))
+	g.Expect(buf.String()).To(gomega.ContainSubstring(`shape="plain"`))
+}
@@ -0,0 +1,126 @@
+package graphviz
+
+import (
+	"fmt"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/safe"
+)
+
+// legendName is used to derive the names of all legend elements before they are made safe,
+// keeping them distinct from the IDs of tasks and namespaces.
+const (
+	legendName   = "#legend"
+	legendPrefix = legendName + ":"
+)
+
+// legendEdgeClasses lists the edge classes shown in the legend, in display order.
+var legendEdgeClasses = []struct {
+	class string
+	label string
+}{
+	{class: graph.EdgeClassDep, label: "dependency"},
+	{class: graph.EdgeClassCall, label: "call"},
+	{class: graph.EdgeClassVar, label: "variable reference"},
+}
+
+// writeLegendTo writes a cluster explaining the node shapes, node colours and edge styles
+// used in the graph. Variable entries are only included when the graph contains variables.
+func writeLegendTo(
+	root *indentwriter.Line,
+	cfg *config.Config,
+	includeVariables bool,
+	reg *safe.Registry,
+) {
+	gv := cfg.Graphviz
+	if gv == nil {
+		gv = &config.Graphviz{}
+	}
+
+	cluster := root.Addf("subgraph %s {", reg.IDWithPrefix("cluster_", legendName))
+	cluster.Add(`label="Legend"`)
+
+	writeLegendNodeTo(cluster, reg.IDWithPrefix(legendPrefix, "task"), "Mrecord", "task", "",
+		func(props nodeProperties) { props.AddAttributes(gv.TaskNodes) })
+
+	if includeVariables {
+		writeLegendNodeTo(cluster, reg.IDWithPrefix(legendPrefix, "variable"), "record", "variable", "",
+			func(props nodeProperties) { props.AddAttributes(gv.VariableNodes) })
+	}
+
+	for _, rule := range cfg.NodeStyleRules {
+		if rule.Name == "" {
+			continue
+		}
+
+		id := reg.IDWithPrefix(legendPrefix, "rule:"+rule.Key())
+		writeLegendNodeTo(cluster, id, "Mrecord", rule.Name, rule.Description,
+			func(props nodeProperties) { props.AddRuleAttributes(rule) })
+	}
+
+	for _, entry := range legendEdgeClasses {
+		if entry.class == graph.EdgeClassVar && !includeVariables {
+			continue
+		}
+
+		writeLegendEdgeTo(cluster, entry.class, entry.label, edgeClassConfig(gv, entry.class), reg)
+	}
+
+	root.Add("}")
+}
+
+// writeLegendNodeTo writes a single example node into the legend.
+func writeLegendNodeTo(
+	cluster *indentwriter.Line,
+	id string,
+	shape string,
+	name string,
+	description string,
+	configure func(nodeProperties),
+) {
+	margin := min((len(description)+20)/2, 40)
+
+	rec := newRecord()
+	rec.add(name)
+	rec.addWrapped(margin, description)
+
+	props := newNodeProperties()
+	props.Add("shape", shape)
+	props.Add("label", rec.String())
+
+	configure(props)
+
+	if props.ContainsKey("fillcolor") && !props.ContainsKey("style") {
+		props.Add("style", "filled")
+	}
+
+	props.WriteTo(fmt.Sprintf("\"%s\"", id), cluster)
+}
+
+// writeLegendEdgeTo writes an example edge into the legend, drawn from a text node naming
+// the edge class to a small point.
+func writeLegendEdgeTo(
+	cluster *indentwriter.Line,
+	class string,
+	label string,
+	edgeCfg *config.GraphvizEdge,
+	reg *safe.Registry,
+) {
+	fromID := reg.IDWithPrefix(legendPrefix+"edge:", class)
+	toID := reg.IDWithPrefix(legendPrefix+"edge-end:", class)
+
+	from := newNodeProperties()
+	from.Add("shape", "plaintext")
+	from.Add("label", label)
+	from.WriteTo(fmt.Sprintf("\"%s\"", fromID), cluster)
+
+	to := newNodeProperties()
+	to.Add("shape", "point")
+	to.WriteTo(fmt.Sprintf("\"%s\"", toID), cluster)
+
+	props := newEdgeProperties()
+	props.AddAttributes(edgeCfg)
+	props.WriteTo(fmt.Sprintf("\"%s\" -> \"%s\"", fromID, toID), cluster)
+}
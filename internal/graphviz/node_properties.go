@@ -6,6 +6,8 @@ import (
 	"github.com/rotisserie/eris"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/links"
 	"github.com/theunrepentantgeek/task-graph/internal/namespace"
 )
 
@@ -21,7 +23,7 @@ type nodeProperties struct {
 
 func newNodeProperties() nodeProperties {
 	return nodeProperties{
-		properties: make(map[string]string),
+		properties: newProperties(),
 	}
 }
 
@@ -65,10 +67,45 @@ func (p nodeProperties) AddStyleRuleAttributes(
 		return nil
 	}
 
+	p.AddRuleAttributes(rule)
+
+	return nil
+}
+
+// AddLinkAttributes adds the tooltip and link for the given node to the properties map, as
+// configured.
+func (p nodeProperties) AddLinkAttributes(
+	node *graph.Node,
+	cfg *config.Links,
+) error {
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.Tooltips {
+		p.Add("tooltip", escapeString(links.Tooltip(node)))
+	}
+
+	url, err := links.URL(cfg.URL, node)
+	if err != nil {
+		return err
+	}
+
+	if url != "" {
+		p.Add("URL", escapeString(url))
+		p.AddIfNotEmpty("target", escapeString(cfg.Target))
+	}
+
+	return nil
+}
+
+// AddRuleAttributes adds the attributes from the given NodeStyleRule to the properties map,
+// regardless of the rule's pattern.
+func (p nodeProperties) AddRuleAttributes(
+	rule config.NodeStyleRule,
+) {
 	p.AddIfNotEmpty("color", rule.Color)
 	p.AddIfNotEmpty("fillcolor", rule.FillColor)
 	p.AddIfNotEmpty("style", rule.Style)
 	p.AddIfNotEmpty("fontcolor", rule.FontColor)
-
-	return nil
 }
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
 )
 
 func TestNewNodeProperties_Default_ReturnsEmptyProperties(t *testing.T) {
@@ -16,8 +17,8 @@ func TestNewNodeProperties_Default_ReturnsEmptyProperties(t *testing.T) {
 	p := newNodeProperties()
 
 	// Assert
-	g.Expect(p.properties).NotTo(BeNil())
-	g.Expect(p.properties).To(BeEmpty())
+	g.Expect(p.values).NotTo(BeNil())
+	g.Expect(p.values).To(BeEmpty())
 }
 
 //
@@ -35,7 +36,7 @@ func TestNodePropertiesAddAttributes_WithNilConfig_LeavesPropertiesEmpty(t *test
 	p.AddAttributes(nil)
 
 	// Assert
-	g.Expect(p.properties).To(BeEmpty())
+	g.Expect(p.values).To(BeEmpty())
 }
 
 func TestNodePropertiesAddAttributes_WhenColorConfigured_SetsColorAttribute(t *testing.T) {
@@ -52,7 +53,7 @@ func TestNodePropertiesAddAttributes_WhenColorConfigured_SetsColorAttribute(t *t
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
 }
 
 func TestNodePropertiesAddAttributes_WhenColorNotConfigured_OmitsColorAttribute(t *testing.T) {
@@ -69,7 +70,7 @@ func TestNodePropertiesAddAttributes_WhenColorNotConfigured_OmitsColorAttribute(
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).NotTo(HaveKey("color"))
+	g.Expect(p.values).NotTo(HaveKey("color"))
 }
 
 func TestNodePropertiesAddAttributes_WhenFillColorConfigured_SetsFillColorAttribute(t *testing.T) {
@@ -86,7 +87,7 @@ func TestNodePropertiesAddAttributes_WhenFillColorConfigured_SetsFillColorAttrib
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("fillcolor", "lightyellow"))
+	g.Expect(p.values).To(HaveKeyWithValue("fillcolor", "lightyellow"))
 }
 
 func TestNodePropertiesAddAttributes_WhenFillColorNotConfigured_OmitsFillColorAttribute(t *testing.T) {
@@ -101,7 +102,7 @@ func TestNodePropertiesAddAttributes_WhenFillColorNotConfigured_OmitsFillColorAt
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).NotTo(HaveKey("fillcolor"))
+	g.Expect(p.values).NotTo(HaveKey("fillcolor"))
 }
 
 func TestNodePropertiesAddAttributes_WhenStyleConfigured_SetsStyleAttribute(t *testing.T) {
@@ -118,7 +119,7 @@ func TestNodePropertiesAddAttributes_WhenStyleConfigured_SetsStyleAttribute(t *t
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("style", "filled"))
+	g.Expect(p.values).To(HaveKeyWithValue("style", "filled"))
 }
 
 func TestNodePropertiesAddAttributes_WhenStyleNotConfigured_OmitsStyleAttribute(t *testing.T) {
@@ -133,7 +134,7 @@ func TestNodePropertiesAddAttributes_WhenStyleNotConfigured_OmitsStyleAttribute(
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).NotTo(HaveKey("style"))
+	g.Expect(p.values).NotTo(HaveKey("style"))
 }
 
 func TestNodePropertiesAddAttributes_WhenFontColorConfigured_SetsFontColorAttribute(t *testing.T) {
@@ -150,7 +151,7 @@ func TestNodePropertiesAddAttributes_WhenFontColorConfigured_SetsFontColorAttrib
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("fontcolor", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("fontcolor", "blue"))
 }
 
 func TestNodePropertiesAddAttributes_WhenFontColorNotConfigured_OmitsFontColorAttribute(t *testing.T) {
@@ -165,7 +166,7 @@ func TestNodePropertiesAddAttributes_WhenFontColorNotConfigured_OmitsFontColorAt
 	p.AddAttributes(cfg)
 
 	// Assert
-	g.Expect(p.properties).NotTo(HaveKey("fontcolor"))
+	g.Expect(p.values).NotTo(HaveKey("fontcolor"))
 }
 
 //
@@ -188,7 +189,7 @@ func TestNodePropertiesAddStyleRuleAttributes_WhenPatternMatchesExactName_Applie
 
 	// Assert
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
 }
 
 func TestNodePropertiesAddStyleRuleAttributes_WhenPatternDoesNotMatch_LeavesPropertiesUnchanged(t *testing.T) {
@@ -207,7 +208,7 @@ func TestNodePropertiesAddStyleRuleAttributes_WhenPatternDoesNotMatch_LeavesProp
 
 	// Assert
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(p.properties).NotTo(HaveKey("color"))
+	g.Expect(p.values).NotTo(HaveKey("color"))
 }
 
 func TestNodePropertiesAddStyleRuleAttributes_WhenPatternUsesWildcard_MatchesMultipleNames(t *testing.T) {
@@ -245,9 +246,9 @@ func TestNodePropertiesAddStyleRuleAttributes_WhenPatternUsesWildcard_MatchesMul
 			g.Expect(err).NotTo(HaveOccurred())
 
 			if c.matches {
-				g.Expect(p.properties).To(HaveKeyWithValue("color", "red"))
+				g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
 			} else {
-				g.Expect(p.properties).NotTo(HaveKey("color"))
+				g.Expect(p.values).NotTo(HaveKey("color"))
 			}
 		})
 	}
@@ -272,10 +273,10 @@ func TestNodePropertiesAddStyleRuleAttributes_WhenRuleHasAllFields_AppliesAllAtt
 
 	// Assert
 	g.Expect(err).NotTo(HaveOccurred())
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "red"))
-	g.Expect(p.properties).To(HaveKeyWithValue("fillcolor", "lightyellow"))
-	g.Expect(p.properties).To(HaveKeyWithValue("style", "filled"))
-	g.Expect(p.properties).To(HaveKeyWithValue("fontcolor", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("fillcolor", "lightyellow"))
+	g.Expect(p.values).To(HaveKeyWithValue("style", "filled"))
+	g.Expect(p.values).To(HaveKeyWithValue("fontcolor", "blue"))
 }
 
 func TestNodePropertiesAddStyleRuleAttributes_LastRuleWins_WhenMultipleRulesMatch(t *testing.T) {
@@ -295,5 +296,54 @@ func TestNodePropertiesAddStyleRuleAttributes_LastRuleWins_WhenMultipleRulesMatc
 	g.Expect(err).NotTo(HaveOccurred())
 
 	// Assert
-	g.Expect(p.properties).To(HaveKeyWithValue("color", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "blue"))
+}
+
+//
+// AddLinkAttributes tests.
+//
+
+func TestNodePropertiesAddLinkAttributes_WhenConfigured_SetsTooltipURLAndTarget(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	p := newNodeProperties()
+	node := graph.NewNode("build")
+	node.Description = `Build "all"`
+	node.File = "Taskfile.yml"
+	node.Line = 7
+	cfg := &config.Links{
+		URL:      "https://example.com/{{.File}}#L{{.Line}}",
+		Target:   "_blank",
+		Tooltips: true,
+	}
+
+	// Act
+	err := p.AddLinkAttributes(node, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.values).To(HaveKeyWithValue("tooltip", `Build \"all\"`))
+	g.Expect(p.values).To(HaveKeyWithValue("URL", "https://example.com/Taskfile.yml#L7"))
+	g.Expect(p.values).To(HaveKeyWithValue("target", "_blank"))
+}
+
+func TestNodePropertiesAddLinkAttributes_WithoutSourceLocation_OmitsURLAndTarget(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	p := newNodeProperties()
+	cfg := &config.Links{
+		URL:    "https://example.com/{{.File}}",
+		Target: "_blank",
+	}
+
+	// Act
+	err := p.AddLinkAttributes(graph.NewNode("build"), cfg)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.values).To(BeEmpty())
 }
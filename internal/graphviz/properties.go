@@ -10,10 +10,21 @@ import (
 )
 
 // properties represents the properties of a node or edge in the Graphviz output.
-type properties map[string]string
+type properties struct {
+	// values holds the properties written as quoted strings.
+	values map[string]string
+
+	// html holds the properties written as HTML-like labels, between < and > instead of in
+	// quotes.
+	// https://graphviz.org/doc/info/shapes.html#html
+	html map[string]string
+}
 
 func newProperties() properties {
-	return make(map[string]string)
+	return properties{
+		values: make(map[string]string),
+		html:   make(map[string]string),
+	}
 }
 
 // Add adds a property with the given key and value to the properties map.
@@ -21,7 +32,8 @@ func (p properties) Add(
 	key string,
 	value string,
 ) {
-	p[key] = value
+	p.values[key] = value
+	delete(p.html, key)
 }
 
 // AddIfNotEmpty adds a property with the given key and value to the properties map only if the value is not empty.
@@ -52,7 +64,7 @@ func (p properties) AddWrapped(
 	value string,
 ) {
 	lines := indentwriter.WordWrap(value, width)
-	p[key] = strings.Join(lines, "\\n")
+	p.Add(key, strings.Join(lines, "\\n"))
 }
 
 // AddWrappedf adds a property with the given key and value to the properties map.
@@ -66,11 +78,54 @@ func (p properties) AddWrappedf(
 	p.AddWrapped(key, width, fmt.Sprintf(format, args...))
 }
 
+// stringEscaper escapes text for use within a quoted Graphviz string, keeping line breaks.
+var stringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+// escapeString returns text escaped for use as the value of a property.
+func escapeString(text string) string {
+	return stringEscaper.Replace(text)
+}
+
+// AddHTML adds a property whose value is an HTML-like label, written between < and > instead of
+// in quotes.
+// https://graphviz.org/doc/info/shapes.html#html
+func (p properties) AddHTML(
+	key string,
+	html string,
+) {
+	p.html[key] = html
+	delete(p.values, key)
+}
+
 // ContainsKey returns true if the properties map contains the given key.
 func (p properties) ContainsKey(key string) bool {
-	_, ok := p[key]
+	_, ok := p.values[key]
+	_, html := p.html[key]
+
+	return ok || html
+}
+
+// Len returns the number of properties.
+func (p properties) Len() int {
+	return len(p.values) + len(p.html)
+}
+
+// Clone returns a copy of the properties, which can be changed independently.
+func (p properties) Clone() properties {
+	return properties{
+		values: maps.Clone(p.values),
+		html:   maps.Clone(p.html),
+	}
+}
 
-	return ok
+// Remove removes the property with the given key, if present.
+func (p properties) Remove(key string) {
+	delete(p.values, key)
+	delete(p.html, key)
 }
 
 // WriteTo writes the properties to the given indentwriter.Line in the format expected by Graphviz.
@@ -78,7 +133,7 @@ func (p properties) WriteTo(
 	label string,
 	root *indentwriter.Line,
 ) {
-	if len(p) == 0 {
+	if p.Len() == 0 {
 		// No properties to write, so just write the node label.
 		root.Add(label)
 
@@ -87,12 +142,16 @@ func (p properties) WriteTo(
 
 	nested := root.Addf("%s [", label)
 
-	keys := slices.Collect(maps.Keys(p))
+	keys := slices.Collect(maps.Keys(p.values))
+	keys = slices.AppendSeq(keys, maps.Keys(p.html))
 	slices.Sort(keys)
 
 	for _, key := range keys {
-		value := p[key]
-		nested.Addf("%s=\"%s\"", key, value)
+		if html, ok := p.html[key]; ok {
+			nested.Addf("%s=<%s>", key, html)
+		} else {
+			nested.Addf("%s=\"%s\"", key, p.values[key])
+		}
 	}
 
 	root.Add("]")
@@ -17,8 +17,8 @@ func TestNewProperties_EmptyMap_ReturnsEmptyMap(t *testing.T) {
 	p := newProperties()
 
 	// Assert
-	g.Expect(p).NotTo(BeNil())
-	g.Expect(p).To(BeEmpty())
+	g.Expect(p.values).NotTo(BeNil())
+	g.Expect(p.values).To(BeEmpty())
 }
 
 func TestAdd_NewKey_AddsValue(t *testing.T) {
@@ -32,7 +32,7 @@ func TestAdd_NewKey_AddsValue(t *testing.T) {
 	p.Add("color", "red")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("color", "red"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "red"))
 }
 
 func TestAdd_ExistingKey_OverwritesPreviousValue(t *testing.T) {
@@ -41,13 +41,13 @@ func TestAdd_ExistingKey_OverwritesPreviousValue(t *testing.T) {
 
 	// Arrange
 	p := newProperties()
-	p["color"] = "red"
+	p.values["color"] = "red"
 
 	// Act
 	p.Add("color", "blue")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("color", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "blue"))
 }
 
 func TestAddf_NewKey_AddsFormattedValue(t *testing.T) {
@@ -61,7 +61,7 @@ func TestAddf_NewKey_AddsFormattedValue(t *testing.T) {
 	p.Addf("color", "%s-%d", "blue", 7)
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("color", "blue-7"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "blue-7"))
 }
 
 func TestAddf_ExistingKey_OverwritesPreviousValue(t *testing.T) {
@@ -70,13 +70,13 @@ func TestAddf_ExistingKey_OverwritesPreviousValue(t *testing.T) {
 
 	// Arrange
 	p := newProperties()
-	p["color"] = "red"
+	p.values["color"] = "red"
 
 	// Act
 	p.Addf("color", "%s", "blue")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("color", "blue"))
+	g.Expect(p.values).To(HaveKeyWithValue("color", "blue"))
 }
 
 func TestAddWrapped_TextExceedsWidth_WrapsWithEmbeddedNewlines(t *testing.T) {
@@ -90,7 +90,7 @@ func TestAddWrapped_TextExceedsWidth_WrapsWithEmbeddedNewlines(t *testing.T) {
 	p.AddWrapped("description", 4, "abc def ghi")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("description", "abc \\ndef \\nghi"))
+	g.Expect(p.values).To(HaveKeyWithValue("description", "abc \\ndef \\nghi"))
 }
 
 func TestAddWrapped_EmptyValue_StoresEmptyString(t *testing.T) {
@@ -104,7 +104,7 @@ func TestAddWrapped_EmptyValue_StoresEmptyString(t *testing.T) {
 	p.AddWrapped("note", 0, "")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("note", ""))
+	g.Expect(p.values).To(HaveKeyWithValue("note", ""))
 }
 
 func TestAddWrappedf_TextExceedsWidth_WrapsWithEmbeddedNewlines(t *testing.T) {
@@ -118,7 +118,7 @@ func TestAddWrappedf_TextExceedsWidth_WrapsWithEmbeddedNewlines(t *testing.T) {
 	p.AddWrappedf("description", 4, "%s", "abc def ghi")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("description", "abc \\ndef \\nghi"))
+	g.Expect(p.values).To(HaveKeyWithValue("description", "abc \\ndef \\nghi"))
 }
 
 func TestAddWrappedf_TextWithinWidth_StoresSingleLine(t *testing.T) {
@@ -132,7 +132,7 @@ func TestAddWrappedf_TextWithinWidth_StoresSingleLine(t *testing.T) {
 	p.AddWrappedf("summary", 40, "%s", "short text")
 
 	// Assert
-	g.Expect(p).To(HaveKeyWithValue("summary", "short text"))
+	g.Expect(p.values).To(HaveKeyWithValue("summary", "short text"))
 }
 
 func TestWriteTo_UnsortedKeys_WritesKeysInAscendingOrder(t *testing.T) {
@@ -140,11 +140,10 @@ func TestWriteTo_UnsortedKeys_WritesKeysInAscendingOrder(t *testing.T) {
 	g := NewWithT(t)
 
 	// Arrange
-	props := properties{
-		"beta":  "two",
-		"alpha": "one",
-		"gamma": "three",
-	}
+	props := newProperties()
+	props.Add("beta", "two")
+	props.Add("alpha", "one")
+	props.Add("gamma", "three")
 
 	iw := indentwriter.New()
 	root := iw.Add("root")
@@ -181,3 +180,50 @@ func TestWriteTo_NoProperties_WritesLabelWithoutBlock(t *testing.T) {
 	g.Expect(err).NotTo(HaveOccurred())
 	g.Expect(buf.String()).To(Equal("root\n  node\n"))
 }
+
+func TestWriteTo_HTMLProperty_WritesUnquotedLabel(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	props := newProperties()
+	props.AddHTML("label", "<B>build</B>")
+
+	iw := indentwriter.New()
+	root := iw.Add("root")
+
+	// Act
+	props.WriteTo("node", root)
+
+	buf := bytes.Buffer{}
+	_, err := iw.WriteTo(&buf, "  ")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal("root\n  node [\n    label=<<B>build</B>>\n  ]\n"))
+}
+
+func TestWriteTo_AddReplacesHTMLProperty_WritesQuotedValue(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	props := newProperties()
+	props.AddHTML("label", "<B>build</B>")
+	props.Add("label", "build")
+	props.AddHTML("tooltip", "<I>tip</I>")
+	props.Remove("tooltip")
+
+	iw := indentwriter.New()
+	root := iw.Add("root")
+
+	// Act
+	props.WriteTo("node", root)
+
+	buf := bytes.Buffer{}
+	_, err := iw.WriteTo(&buf, "  ")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal("root\n  node [\n    label=\"build\"\n  ]\n"))
+}
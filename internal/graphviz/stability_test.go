@@ -0,0 +1,38 @@
+package graphviz
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/autocolor"
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/stability"
+)
+
+// TestWriteTo_AddingTasks_KeepsExistingLines verifies that adding tasks only adds lines to the
+// output: the IDs, colours and legend entries of the other tasks are unchanged.
+func TestWriteTo_AddingTasks_KeepsExistingLines(t *testing.T) {
+	t.Parallel()
+
+	// Arrange
+	write := func(t *testing.T, gr *graph.Graph) []byte {
+		t.Helper()
+		g := gomega.NewWithT(t)
+
+		cfg := config.New()
+		cfg.Legend = true
+		cfg.NodeStyleRules = autocolor.GenerateRules(gr)
+
+		var buf bytes.Buffer
+
+		g.Expect(WriteTo(&buf, gr, cfg)).To(gomega.Succeed())
+
+		return buf.Bytes()
+	}
+
+	// Act & Assert
+	stability.Check(t, write)
+}
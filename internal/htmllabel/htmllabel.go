@@ -0,0 +1,165 @@
+// Package htmllabel builds Graphviz HTML-like labels for task nodes, laid out by a template, so
+// that nodes can show more about each task than a record label can.
+// https://graphviz.org/doc/info/shapes.html#html
+package htmllabel
+
+import (
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// DefaultTemplate lays out a task as a rounded table, with a band in the colour of the node, its
+// name in bold (after icons for internal tasks and tasks that prompt), and rows for its
+// description, aliases, and the number of sources and generated files.
+const DefaultTemplate = `<TABLE BORDER="1" CELLBORDER="0" CELLSPACING="0" CELLPADDING="4" STYLE="rounded"` +
+	`{{with .BorderColor}} COLOR="{{.}}"{{end}}>` +
+	`{{with .Color}}<TR><TD BGCOLOR="{{.}}" HEIGHT="6"></TD></TR>{{end}}` +
+	`<TR><TD>{{if .Internal}}&#128274; {{end}}{{if .Prompt}}&#9888; {{end}}<B>{{.Name}}</B>` +
+	`{{with .Duration}} ({{.}}){{end}}</TD></TR>` +
+	`{{with .Description}}<TR><TD>{{.}}</TD></TR>{{end}}` +
+	`{{with .Aliases}}<TR><TD><I>aliases: {{join . ", "}}</I></TD></TR>{{end}}` +
+	`{{if or .Sources .Generates}}<TR><TD><FONT POINT-SIZE="10">sources: {{.Sources}}, ` +
+	`generates: {{.Generates}}</FONT></TD></TR>{{end}}` +
+	`</TABLE>`
+
+// Data are the values available to a label template. Text is escaped for use in an HTML-like
+// label, so it can be used as is.
+type Data struct {
+	// Name is the display label of the task.
+	Name string
+
+	// Namespace is the namespace of the task, or "" if it has none.
+	Namespace string
+
+	// Description is the description of the task, wrapped onto lines separated by <BR/>.
+	Description string
+
+	// Duration is the measured duration of the task, or "" if unknown.
+	Duration string
+
+	// Aliases are the other names of the task.
+	Aliases []string
+
+	// Sources is the number of source globs of the task.
+	Sources int
+
+	// Generates is the number of globs of files generated by the task.
+	Generates int
+
+	// Internal is true if the task is internal.
+	Internal bool
+
+	// Prompt is true if the task asks for confirmation before it runs.
+	Prompt bool
+
+	// Color is the fill colour of the node, such as the colour of its namespace, or "" if none.
+	Color string
+
+	// BorderColor is the colour of the border of the node, or "" if none.
+	BorderColor string
+}
+
+// escaper replaces the characters with special meaning in HTML-like labels with entities.
+var escaper = strings.NewReplacer(
+	`&`, `&amp;`,
+	`<`, `&lt;`,
+	`>`, `&gt;`,
+	`"`, `&quot;`,
+	`'`, `&#39;`,
+)
+
+// Escape returns text with the characters that have special meaning in an HTML-like label
+// replaced by entities.
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// New returns the label data for node, drawn with the given fill and border colours.
+func New(node *graph.Node, color string, borderColor string) Data {
+	margin := min((len(node.Description)+20)/2, 40)
+
+	description := indentwriter.WordWrap(node.Description, margin)
+	for i, line := range description {
+		description[i] = Escape(strings.TrimSpace(line))
+	}
+
+	aliases := make([]string, 0, len(node.Aliases))
+	for _, alias := range node.Aliases {
+		aliases = append(aliases, Escape(alias))
+	}
+
+	return Data{
+		Name:        Escape(node.DisplayLabel()),
+		Namespace:   Escape(namespace.Namespace(node.ID())),
+		Description: strings.Join(description, "<BR/>"),
+		Duration:    Escape(node.DisplayDuration()),
+		Aliases:     aliases,
+		Sources:     node.Sources,
+		Generates:   node.Generates,
+		Internal:    node.Internal,
+		Prompt:      node.Prompt,
+		Color:       Escape(color),
+		BorderColor: Escape(borderColor),
+	}
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+var templateCache sync.Map // map[string]*template.Template
+
+// Parse parses a label template, checking that it only refers to the available Data.
+func Parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("label").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid label template %q", text)
+	}
+
+	err = tmpl.Execute(&strings.Builder{}, Data{})
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid label template %q", text)
+	}
+
+	return tmpl, nil
+}
+
+// Render returns the HTML-like label for data, laid out by the given template, or by
+// DefaultTemplate if it is empty.
+func Render(text string, data Data) (string, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	value, ok := templateCache.Load(text)
+	if !ok {
+		tmpl, err := Parse(text)
+		if err != nil {
+			return "", err
+		}
+
+		templateCache.Store(text, tmpl)
+		value = tmpl
+	}
+
+	tmpl, ok := value.(*template.Template)
+	if !ok {
+		return "", eris.New("cached label template is not a template")
+	}
+
+	var b strings.Builder
+
+	err := tmpl.Execute(&b, data)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to build label for task %s", data.Name)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,67 @@
+package htmllabel
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestEscape_ReplacesSpecialCharacters(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	result := Escape(`<a href="x">Tom & Jerry's</a>`)
+
+	// Assert
+	g.Expect(result).To(Equal("&lt;a href=&quot;x&quot;&gt;Tom &amp; Jerry&#39;s&lt;/a&gt;"))
+}
+
+func TestNew_EscapesTextAndWrapsDescription(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	node := graph.NewNode("docs:<build>")
+	node.Description = "Build the documentation site & publish it to the web"
+	node.Aliases = []string{"d&b"}
+
+	// Act
+	data := New(node, "#ff0000", "")
+
+	// Assert
+	g.Expect(data.Name).To(Equal("docs:&lt;build&gt;"))
+	g.Expect(data.Namespace).To(Equal("docs"))
+	g.Expect(data.Description).To(Equal("Build the documentation site &amp;<BR/>publish it to the web"))
+	g.Expect(data.Aliases).To(Equal([]string{"d&amp;b"}))
+	g.Expect(data.Color).To(Equal("#ff0000"))
+}
+
+func TestRender_WithTemplate_UsesTemplate(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	node := graph.NewNode("build")
+	node.Aliases = []string{"b", "compile"}
+
+	// Act
+	label, err := Render(`<B>{{.Name}}</B> {{join .Aliases "/"}}`, New(node, "", ""))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(label).To(Equal("<B>build</B> b/compile"))
+}
+
+func TestParse_UnknownField_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Parse(`<B>{{.Title}}</B>`)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("invalid label template")))
+}
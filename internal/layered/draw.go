@@ -0,0 +1,146 @@
+package layered
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	// arrowLength and arrowWidth are the size of arrowheads, in points, for an edge of width 1.
+	arrowLength = 10.0
+	arrowWidth  = 7.0
+
+	// cornerRadius is the radius of rounded corners, in points.
+	cornerRadius = 6.0
+)
+
+// anchor is how text is aligned with the position it is drawn at.
+type anchor int
+
+const (
+	anchorMiddle anchor = iota
+	anchorStart
+)
+
+// canvas is a surface that a diagram can be drawn on.
+type canvas interface {
+	// rect draws a rectangle, with rounded corners if radius is positive
+	rect(r rect, radius float64, fill color.RGBA, line lineStyle)
+	// polyline draws a line through the points
+	polyline(points []point, line lineStyle)
+	// polygon fills the area enclosed by the points
+	polygon(points []point, fill color.RGBA)
+	// text draws text with its baseline at p
+	text(p point, text string, c color.RGBA, align anchor)
+}
+
+// drawDiagram draws the diagram on the canvas: clusters, then edges, then nodes on top.
+func drawDiagram(d *diagram, c canvas) {
+	s := d.Settings
+
+	c.rect(rect{Width: d.Width, Height: d.Height}, 0, s.background, lineStyle{})
+
+	for _, cluster := range d.Clusters {
+		drawCluster(c, cluster, s)
+	}
+
+	for _, edge := range d.Edges {
+		drawEdge(c, edge, s)
+	}
+
+	for _, node := range d.Nodes {
+		drawNode(c, node, s)
+	}
+}
+
+// drawCluster draws a cluster with its label centred at the top.
+func drawCluster(c canvas, cluster clusterShape, s settings) {
+	if cluster.Style.Hidden {
+		return
+	}
+
+	radius := 0.0
+	if cluster.Style.Rounded {
+		radius = cornerRadius
+	}
+
+	c.rect(cluster.rect, radius, cluster.Style.Fill, cluster.Style.Line)
+
+	baseline := point{X: cluster.X + cluster.Width/2, Y: cluster.Y + clusterPad + s.fontSize}
+	c.text(baseline, cluster.Label, cluster.Style.Text, anchorMiddle)
+}
+
+// drawEdge draws an edge with an arrowhead at its target, and its label if it has one.
+func drawEdge(c canvas, edge edgeShape, s settings) {
+	points := edge.Points
+	if len(points) < 2 {
+		return
+	}
+
+	tip := points[len(points)-1]
+	from := points[len(points)-2]
+
+	dx, dy := tip.X-from.X, tip.Y-from.Y
+	length := math.Hypot(dx, dy)
+
+	if length == 0 {
+		return
+	}
+
+	ux, uy := dx/length, dy/length
+	scale := math.Sqrt(edge.Style.Width)
+	arrowLen := math.Min(arrowLength*scale, length)
+	half := arrowWidth * scale / 2
+
+	base := point{X: tip.X - ux*arrowLen, Y: tip.Y - uy*arrowLen}
+
+	line := make([]point, len(points))
+	copy(line, points)
+	line[len(line)-1] = base
+
+	c.polyline(line, edge.Style)
+	c.polygon([]point{
+		tip,
+		{X: base.X - uy*half, Y: base.Y + ux*half},
+		{X: base.X + uy*half, Y: base.Y - ux*half},
+	}, edge.Style.Color)
+
+	if edge.Label != "" {
+		p := edge.labelPosition()
+		c.text(point{X: p.X, Y: p.Y + s.fontSize/3}, edge.Label, s.text, anchorStart)
+	}
+}
+
+// drawNode draws a node as a record: its title, then any details below a dividing line.
+func drawNode(c canvas, node nodeShape, s settings) {
+	if node.Style.Hidden {
+		return
+	}
+
+	radius := 0.0
+	if node.Style.Rounded {
+		radius = cornerRadius
+	}
+
+	c.rect(node.rect, radius, node.Style.Fill, node.Style.Line)
+
+	lineHeight := s.fontSize * 1.25
+	centre := node.X + node.Width/2
+	y := node.Y + s.fontSize*0.4 + s.fontSize
+
+	c.text(point{X: centre, Y: y}, node.Title, node.Style.Text, anchorMiddle)
+
+	if len(node.Details) == 0 {
+		return
+	}
+
+	divider := y + s.fontSize*0.4
+	c.polyline([]point{{X: node.X, Y: divider}, {X: node.X + node.Width, Y: divider}}, node.Style.Line)
+
+	y += s.fontSize * 0.4
+
+	for _, line := range node.Details {
+		y += lineHeight
+		c.text(point{X: centre, Y: y}, line, node.Style.Text, anchorMiddle)
+	}
+}
@@ -0,0 +1,247 @@
+package layered
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// group is the vertices of a namespace, drawn in a column of their own: the vertices directly
+// within the namespace side by side with the columns of nested namespaces. When grouping by
+// namespace, each group other than the root is drawn as a cluster.
+type group struct {
+	name     string
+	depth    int
+	children []*group
+	members  []*vertex
+	// left and width are the horizontal extent of the column, including padding
+	left  float64
+	width float64
+	// own is the width of the vertices directly within the group
+	own float64
+	key float64
+}
+
+// namespaceOf returns the namespace whose group holds the node with the given ID: its own
+// namespace when grouping by namespace, otherwise the root.
+func (l *layouter) namespaceOf(id string) string {
+	if !l.settings.grouped {
+		return ""
+	}
+
+	return namespace.Namespace(id)
+}
+
+// groupFor returns the group of the namespace, creating it and its parents if needed.
+func (l *layouter) groupFor(ns string) *group {
+	if g, ok := l.groups[ns]; ok {
+		return g
+	}
+
+	g := &group{name: ns}
+	l.groups[ns] = g
+
+	if ns != "" {
+		parent := l.groupFor(namespace.Parent(ns))
+		parent.children = append(parent.children, g)
+		g.depth = parent.depth + 1
+	}
+
+	return g
+}
+
+// commonGroup returns the innermost group containing both a and b.
+func (l *layouter) commonGroup(a *group, b *group) *group {
+	ancestors := make(map[string]bool)
+	for ns := a.name; ; ns = namespace.Parent(ns) {
+		ancestors[ns] = true
+
+		if ns == "" {
+			break
+		}
+	}
+
+	ns := b.name
+	for !ancestors[ns] {
+		ns = namespace.Parent(ns)
+	}
+
+	return l.groups[ns]
+}
+
+// placeColumns sizes every column and sets the horizontal position of every vertex.
+func (l *layouter) placeColumns() {
+	l.root.measure(l.settings.nodeSep, l.ranks)
+	l.root.place(0, l.settings.nodeSep, l.ranks)
+}
+
+// pad returns the padding on each side of the group; only clusters are padded.
+func (g *group) pad() float64 {
+	if g.depth == 0 {
+		return 0
+	}
+
+	return clusterPad
+}
+
+// measure sets the width of the group and its children: the widest rank of its own vertices,
+// beside the columns of its children.
+func (g *group) measure(sep float64, ranks int) {
+	widths := make([]float64, ranks)
+	counts := make([]int, ranks)
+
+	for _, v := range g.members {
+		widths[v.rank] += v.width
+		counts[v.rank]++
+	}
+
+	g.own = 0
+	for r, w := range widths {
+		if counts[r] > 0 {
+			g.own = math.Max(g.own, w+sep*float64(counts[r]-1))
+		}
+	}
+
+	parts := 0
+	g.width = g.own
+
+	if g.own > 0 {
+		parts++
+	}
+
+	for _, child := range g.children {
+		child.measure(sep, ranks)
+		g.width += child.width
+		parts++
+	}
+
+	if parts > 1 {
+		g.width += sep * float64(parts-1)
+	}
+
+	g.width += 2 * g.pad()
+}
+
+// place positions the group with its left edge at left, centring the vertices of each rank
+// within its own column.
+func (g *group) place(left float64, sep float64, ranks int) {
+	g.left = left
+	x := left + g.pad()
+
+	if g.own > 0 {
+		rows := make([][]*vertex, ranks)
+		for _, v := range g.members {
+			rows[v.rank] = append(rows[v.rank], v)
+		}
+
+		for _, row := range rows {
+			width := sep * float64(len(row)-1)
+			for _, v := range row {
+				width += v.width
+			}
+
+			cursor := x + (g.own-width)/2
+			for _, v := range row {
+				v.x = cursor + v.width/2
+				cursor += v.width + sep
+			}
+		}
+
+		x += g.own + sep
+	}
+
+	for _, child := range g.children {
+		child.place(x, sep, ranks)
+		x += child.width + sep
+	}
+}
+
+// sort orders the vertices of the group, and its children, by their keys. Each child is keyed
+// by the mean key of the vertices within it.
+func (g *group) sort() {
+	slices.SortStableFunc(g.members, func(a, b *vertex) int {
+		return cmp.Compare(a.key, b.key)
+	})
+
+	sum, count := g.totalKey()
+	g.key = sum / math.Max(count, 1)
+
+	for _, child := range g.children {
+		child.sort()
+	}
+
+	slices.SortStableFunc(g.children, func(a, b *group) int {
+		return cmp.Compare(a.key, b.key)
+	})
+}
+
+// totalKey returns the sum of the keys of the vertices within the group and its children, and
+// how many there are.
+func (g *group) totalKey() (float64, float64) {
+	sum, count := 0.0, float64(len(g.members))
+
+	for _, v := range g.members {
+		sum += v.key
+	}
+
+	for _, child := range g.children {
+		s, c := child.totalKey()
+		sum += s
+		count += c
+	}
+
+	return sum, count
+}
+
+// maxDepth returns the depth of the most deeply nested group within g.
+func (g *group) maxDepth() int {
+	result := g.depth
+	for _, child := range g.children {
+		result = max(result, child.maxDepth())
+	}
+
+	return result
+}
+
+// clusters adds the clusters of the groups within g to result, each before those nested within
+// it, and returns the area covered by the nodes and clusters within g.
+func (g *group) clusters(l *layouter, style shapeStyle, result *[]clusterShape) (rect, bool) {
+	index := len(*result)
+	if g.depth > 0 {
+		*result = append(*result, clusterShape{Label: g.name, Style: style})
+	}
+
+	top, bottom := math.Inf(1), math.Inf(-1)
+
+	for _, v := range g.members {
+		if v.node != nil {
+			top = math.Min(top, v.y-v.height/2)
+			bottom = math.Max(bottom, v.y+v.height/2)
+		}
+	}
+
+	for _, child := range g.children {
+		if area, ok := child.clusters(l, style, result); ok {
+			top = math.Min(top, area.Y)
+			bottom = math.Max(bottom, area.Y+area.Height)
+		}
+	}
+
+	if math.IsInf(top, 1) {
+		return rect{}, false
+	}
+
+	if g.depth == 0 {
+		return rect{X: g.left, Y: top, Width: g.width, Height: bottom - top}, true
+	}
+
+	top -= clusterPad + l.clusterHeader()
+	bottom += clusterPad
+
+	area := rect{X: g.left, Y: top, Width: g.width, Height: bottom - top}
+	(*result)[index].rect = area
+
+	return area, true
+}
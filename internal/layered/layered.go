@@ -0,0 +1,75 @@
+// Package layered draws a graph as an SVG or PNG image without Graphviz, for when the dot
+// executable isn't available. Tasks are laid out in ranks (a Sugiyama-style layered layout):
+// cycles are broken by reversing edges, each task is placed in the rank below the lowest task
+// that points to it, edges spanning several ranks pass through dummy vertices, and each rank is
+// ordered by the barycentre of its neighbours to reduce crossings. When grouping by namespace,
+// each namespace is drawn as a cluster in a column of its own.
+//
+// Node and edge styling follows the Graphviz config, including NodeStyleRules and
+// EdgeStyleRules. The layout is always top to bottom, HTML-like labels are drawn as records,
+// and edges from a task to itself are not drawn.
+package layered
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// Formats are the image formats that can be drawn.
+var Formats = []string{"svg", "png"}
+
+// SaveTo draws the graph as an image of the given format, writing it to the given file path.
+func SaveTo(
+	filePath string,
+	gr *graph.Graph,
+	cfg *config.Config,
+	format string,
+) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return eris.Wrapf(err, "failed to create file: %s", filePath)
+	}
+
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+
+	err = WriteTo(bw, gr, cfg, format)
+	if err != nil {
+		return err
+	}
+
+	return eris.Wrapf(bw.Flush(), "failed to write file: %s", filePath)
+}
+
+// WriteTo draws the graph as an image of the given format, svg or png, writing it to w.
+func WriteTo(
+	w io.Writer,
+	gr *graph.Graph,
+	cfg *config.Config,
+	format string,
+) error {
+	if gr == nil {
+		return eris.New("layered: graph is nil")
+	}
+
+	d, err := layout(gr, cfg)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "svg":
+		return writeSVG(w, d)
+	case "png":
+		return writePNG(w, d)
+	default:
+		return eris.Errorf("the built-in renderer can't draw %s images, only svg or png", format)
+	}
+}
@@ -0,0 +1,226 @@
+package layered
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestWriteTo_SVG_GivesExpectedResult(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.NodeStyleRules = []config.NodeStyleRule{
+		{Match: "test:*", FillColor: "#a6cee3"},
+	}
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{CrossesNamespace: true, Color: "purple"},
+	}
+
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteTo(&buf, buildSampleGraph(t), cfg, "svg")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(Succeed())
+
+	gg.Assert(t, "sample_graph", buf.Bytes())
+}
+
+func TestWriteTo_PNG_WritesImage(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteTo(&buf, buildSampleGraph(t), config.New(), "png")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	img, err := png.Decode(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(img.Bounds().Dx()).To(BeNumerically(">", 100))
+	g.Expect(img.Bounds().Dy()).To(BeNumerically(">", 100))
+}
+
+func TestWriteTo_UnsupportedFormat_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := WriteTo(&bytes.Buffer{}, buildSampleGraph(t), config.New(), "pdf")
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("can't draw pdf images")))
+}
+
+func TestSaveTo_WritesFileToDisk(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join(t.TempDir(), "graph.svg")
+
+	// Act
+	err := SaveTo(path, buildSampleGraph(t), config.New(), "svg")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring("<svg"))
+}
+
+func TestLayout_PlacesEachTaskBelowTasksPointingToIt(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	d, err := layout(buildSampleGraph(t), config.New())
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	top := make(map[string]float64)
+	for _, node := range d.Nodes {
+		top[node.Title] = node.Y
+	}
+
+	g.Expect(top["ci"]).To(BeNumerically("<", top["test"]))
+	g.Expect(top["test"]).To(BeNumerically("<", top["test:unit"]))
+	g.Expect(top["test:unit"]).To(BeNumerically("<", top["build"]))
+}
+
+func TestLayout_WithCycle_DrawsEveryEdge(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	a := gr.AddNode("a")
+	b := gr.AddNode("b")
+	a.AddEdge(b)
+	b.AddEdge(a)
+	a.AddEdge(a)
+
+	// Act
+	d, err := layout(gr, config.New())
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.Edges).To(HaveLen(2))
+}
+
+func TestLayout_GroupedByNamespace_KeepsClustersApart(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := buildSampleGraph(t)
+	lint := gr.AddNode("lint:go")
+	lint.AddEdge(gr.AddNode("lint:yaml"))
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+
+	// Act
+	d, err := layout(gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clusters := make(map[string]rect)
+	for _, c := range d.Clusters {
+		clusters[c.Label] = c.rect
+	}
+
+	g.Expect(clusters).To(HaveKey("test"))
+	g.Expect(clusters).To(HaveKey("lint"))
+
+	test, lintArea := clusters["test"], clusters["lint"]
+	apart := test.X+test.Width <= lintArea.X || lintArea.X+lintArea.Width <= test.X
+	g.Expect(apart).To(BeTrue())
+}
+
+func buildSampleGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+
+	gr := graph.New()
+
+	ci := gr.AddNode("ci")
+	build := gr.AddNode("build")
+	build.Description = "Build the executable & its assets"
+	test := gr.AddNode("test")
+	unit := gr.AddNode("test:unit")
+	golden := gr.AddNode("test:golden")
+
+	ci.AddEdge(build)
+	ci.AddEdge(test).SetLabel("then")
+	test.AddEdge(unit)
+	test.AddEdge(golden)
+	unit.AddEdge(build).SetClass(graph.EdgeClassCall)
+	golden.AddEdge(build)
+
+	return gr
+}
+
+// BenchmarkWriteTo_PNG_LargeGraph guards against drawing costing time in proportion to the
+// size of the whole image for every shape, which made large graphs take minutes.
+func BenchmarkWriteTo_PNG_LargeGraph(b *testing.B) {
+	b.ReportAllocs()
+
+	gr := buildLargeGraph(b, 400)
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+
+	for b.Loop() {
+		err := WriteTo(io.Discard, gr, cfg, "png")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// buildLargeGraph returns a graph of the given number of tasks spread across ten namespaces,
+// each depending on up to two earlier tasks.
+func buildLargeGraph(tb testing.TB, size int) *graph.Graph {
+	tb.Helper()
+
+	gr := graph.New()
+	nodes := make([]*graph.Node, 0, size)
+
+	for i := range size {
+		node := gr.AddNode(fmt.Sprintf("ns%d:task%d", i%10, i))
+		for _, step := range []int{1, 7} {
+			if i >= step*3 {
+				node.AddEdge(nodes[i-step*3])
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return gr
+}
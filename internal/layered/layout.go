@@ -0,0 +1,503 @@
+package layered
+
+import (
+	"math"
+	"slices"
+
+	"golang.org/x/image/font"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/graphns"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+)
+
+const (
+	// pointsPerInch converts Graphviz distances, given in inches, to points.
+	pointsPerInch = 72
+
+	// margin is the space around the diagram, in points.
+	margin = 12.0
+
+	// clusterPad is the space between the edge of a cluster and its contents, in points.
+	clusterPad = 8.0
+
+	// orderingPasses is the number of times vertices are reordered to reduce edge crossings.
+	orderingPasses = 8
+)
+
+// point is a position in the diagram, in points from the top left.
+type point struct {
+	X, Y float64
+}
+
+// rect is an area of the diagram, given by its top left corner and size.
+type rect struct {
+	X, Y, Width, Height float64
+}
+
+// nodeShape is a node placed in the diagram.
+type nodeShape struct {
+	rect
+	// Title is the first line of the label
+	Title string
+	// Details are the lines of the label below the title, if any
+	Details []string
+	Style   shapeStyle
+}
+
+// edgeShape is an edge routed through the diagram, from its source to its target.
+type edgeShape struct {
+	Points []point
+	Label  string
+	Style  lineStyle
+}
+
+// clusterShape is the box drawn around the tasks of a namespace.
+type clusterShape struct {
+	rect
+	Label string
+	Style shapeStyle
+}
+
+// diagram is a graph laid out for drawing. Clusters come before their nested clusters, so
+// that they can be drawn in order.
+type diagram struct {
+	Width, Height float64
+	Settings      settings
+	Clusters      []clusterShape
+	Edges         []edgeShape
+	Nodes         []nodeShape
+}
+
+// vertex is a node of the graph, or a point on a long edge, placed in a rank of the layout.
+type vertex struct {
+	// node is the node drawn, or nil for a dummy vertex on an edge spanning several ranks
+	node   *graph.Node
+	rank   int
+	width  float64
+	height float64
+	// x and y are the centre of the vertex
+	x, y  float64
+	group *group
+	// key is the barycentre of the neighbours of the vertex, used to order each rank
+	key     float64
+	title   string
+	details []string
+}
+
+// chain is the vertices an edge passes through, from the upper rank to the lower.
+type chain struct {
+	edge     *graph.Edge
+	vertices []*vertex
+	// reversed is true if the edge points upwards, to break a cycle
+	reversed bool
+}
+
+// layouter performs a layered layout of a graph.
+type layouter struct {
+	settings   settings
+	face       font.Face
+	vertices   []*vertex
+	byID       map[string]*vertex
+	chains     []*chain
+	neighbours map[*vertex][]*vertex
+	ranks      int
+	root       *group
+	groups     map[string]*group
+}
+
+// layout arranges the graph in ranks, with every edge pointing down where possible, and orders
+// the vertices of each rank to reduce crossings.
+func layout(gr *graph.Graph, cfg *config.Config) (*diagram, error) {
+	s := newSettings(cfg)
+
+	face, err := newFace(s.fontSize)
+	if err != nil {
+		return nil, err
+	}
+
+	defer face.Close()
+
+	l := &layouter{
+		settings:   s,
+		face:       face,
+		byID:       make(map[string]*vertex),
+		neighbours: make(map[*vertex][]*vertex),
+		groups:     make(map[string]*group),
+	}
+
+	l.root = l.groupFor("")
+
+	for _, node := range graphns.CollectSortedNodes(gr) {
+		l.addVertex(node)
+	}
+
+	l.rankVertices()
+	l.order()
+	l.placeRanks()
+
+	return l.diagram(cfg)
+}
+
+// addVertex adds the vertex for node, sized to fit its label.
+func (l *layouter) addVertex(node *graph.Node) {
+	title := node.DisplayLabel()
+	if d := node.DisplayDuration(); d != "" {
+		title += " (" + d + ")"
+	}
+
+	v := &vertex{node: node, title: title}
+
+	if node.Description != "" {
+		width := min((len(node.Description)+20)/2, 40)
+		v.details = indentwriter.WordWrap(node.Description, width)
+	}
+
+	lineHeight := l.lineHeight()
+	textWidth := measure(l.face, v.title)
+
+	for _, line := range v.details {
+		textWidth = math.Max(textWidth, measure(l.face, line))
+	}
+
+	v.width = math.Max(textWidth+l.settings.fontSize*1.2, 0.75*pointsPerInch)
+	v.height = float64(1+len(v.details))*lineHeight + l.settings.fontSize*0.8
+
+	if len(v.details) > 0 {
+		v.height += l.settings.fontSize * 0.4
+	}
+
+	v.group = l.groupFor(l.namespaceOf(node.ID()))
+	v.group.members = append(v.group.members, v)
+
+	l.vertices = append(l.vertices, v)
+	l.byID[node.ID()] = v
+}
+
+// lineHeight is the height of a line of text, in points.
+func (l *layouter) lineHeight() float64 {
+	return l.settings.fontSize * 1.25
+}
+
+// rankVertices assigns each vertex to a rank, so that edges point down from one rank to a lower
+// one, reversing edges that would otherwise form a cycle. Edges spanning several ranks are
+// broken up by dummy vertices, one in each rank they pass through.
+func (l *layouter) rankVertices() {
+	reversed := l.findBackEdges()
+	predecessors := make(map[*vertex][]*vertex)
+
+	for _, v := range l.vertices {
+		for _, edge := range v.node.Edges() {
+			upper, lower, ok := l.ends(edge, reversed)
+			if ok {
+				predecessors[lower] = append(predecessors[lower], upper)
+			}
+		}
+	}
+
+	ranked := make(map[*vertex]bool)
+
+	var rank func(v *vertex) int
+
+	rank = func(v *vertex) int {
+		if ranked[v] {
+			return v.rank
+		}
+
+		for _, p := range predecessors[v] {
+			v.rank = max(v.rank, rank(p)+1)
+		}
+
+		ranked[v] = true
+		l.ranks = max(l.ranks, v.rank+1)
+
+		return v.rank
+	}
+
+	for _, v := range l.vertices {
+		rank(v)
+	}
+
+	for _, v := range slices.Clone(l.vertices) {
+		for _, edge := range v.node.Edges() {
+			upper, lower, ok := l.ends(edge, reversed)
+			if ok {
+				l.addChain(edge, upper, lower, reversed[edge])
+			}
+		}
+	}
+}
+
+// findBackEdges returns the edges that close a cycle, found by a depth first search in order of
+// node ID. Reversing them leaves a graph without cycles.
+func (l *layouter) findBackEdges() map[*graph.Edge]bool {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[*vertex]int)
+	result := make(map[*graph.Edge]bool)
+
+	var visit func(v *vertex)
+
+	visit = func(v *vertex) {
+		state[v] = visiting
+
+		for _, edge := range v.node.Edges() {
+			to, ok := l.byID[edge.To().ID()]
+			if !ok || to == v {
+				continue
+			}
+
+			switch state[to] {
+			case visiting:
+				result[edge] = true
+			case unvisited:
+				visit(to)
+			}
+		}
+
+		state[v] = visited
+	}
+
+	for _, v := range l.vertices {
+		if state[v] == unvisited {
+			visit(v)
+		}
+	}
+
+	return result
+}
+
+// ends returns the upper and lower vertices of an edge, once back edges are reversed, or false
+// for an edge that loops back to its own node, which is not drawn.
+func (l *layouter) ends(edge *graph.Edge, reversed map[*graph.Edge]bool) (*vertex, *vertex, bool) {
+	from, okFrom := l.byID[edge.From().ID()]
+	to, okTo := l.byID[edge.To().ID()]
+
+	if !okFrom || !okTo || from == to {
+		return nil, nil, false
+	}
+
+	if reversed[edge] {
+		return to, from, true
+	}
+
+	return from, to, true
+}
+
+// addChain adds the vertices an edge passes through from upper to lower, including a dummy
+// vertex in each rank between them.
+func (l *layouter) addChain(edge *graph.Edge, upper *vertex, lower *vertex, reversed bool) {
+	c := &chain{edge: edge, vertices: []*vertex{upper}, reversed: reversed}
+	g := l.commonGroup(upper.group, lower.group)
+
+	for r := upper.rank + 1; r < lower.rank; r++ {
+		dummy := &vertex{rank: r, width: l.settings.nodeSep, group: g}
+		g.members = append(g.members, dummy)
+		l.vertices = append(l.vertices, dummy)
+		c.vertices = append(c.vertices, dummy)
+	}
+
+	c.vertices = append(c.vertices, lower)
+
+	for i := 1; i < len(c.vertices); i++ {
+		a, b := c.vertices[i-1], c.vertices[i]
+		l.neighbours[a] = append(l.neighbours[a], b)
+		l.neighbours[b] = append(l.neighbours[b], a)
+	}
+
+	l.chains = append(l.chains, c)
+}
+
+// order reorders the vertices of each rank, and the groups within each group, by the barycentre
+// of their neighbours, placing them after each pass.
+func (l *layouter) order() {
+	for range orderingPasses {
+		l.placeColumns()
+
+		for _, v := range l.vertices {
+			v.key = v.x
+
+			if n := l.neighbours[v]; len(n) > 0 {
+				sum := 0.0
+				for _, other := range n {
+					sum += other.x
+				}
+
+				v.key = sum / float64(len(n))
+			}
+		}
+
+		l.root.sort()
+	}
+
+	l.placeColumns()
+}
+
+// placeRanks sets the vertical position of each vertex, leaving room between ranks for the
+// labels and padding of nested clusters.
+func (l *layouter) placeRanks() {
+	heights := make([]float64, l.ranks)
+	for _, v := range l.vertices {
+		heights[v.rank] = math.Max(heights[v.rank], v.height)
+	}
+
+	gap := l.settings.rankSep
+	if l.settings.grouped {
+		gap += float64(l.root.maxDepth()) * (l.clusterHeader() + 2*clusterPad)
+	}
+
+	tops := make([]float64, l.ranks)
+
+	y := 0.0
+	for r := range heights {
+		tops[r] = y
+		y += heights[r] + gap
+	}
+
+	for _, v := range l.vertices {
+		v.y = tops[v.rank] + heights[v.rank]/2
+	}
+}
+
+// clusterHeader is the height of the label at the top of a cluster, in points.
+func (l *layouter) clusterHeader() float64 {
+	return l.lineHeight()
+}
+
+// diagram returns the shapes to draw, positioned with a margin around them all.
+func (l *layouter) diagram(cfg *config.Config) (*diagram, error) {
+	d := &diagram{Settings: l.settings}
+
+	if l.settings.grouped {
+		l.root.clusters(l, clusterStyle(cfg, l.settings), &d.Clusters)
+	}
+
+	for _, v := range l.vertices {
+		if v.node == nil {
+			continue
+		}
+
+		style, err := nodeStyle(v.node, cfg, l.settings)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Nodes = append(d.Nodes, nodeShape{
+			rect:    v.bounds(),
+			Title:   v.title,
+			Details: v.details,
+			Style:   style,
+		})
+	}
+
+	for _, c := range l.chains {
+		style, label, hidden, err := edgeStyle(c.edge, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hidden {
+			d.Edges = append(d.Edges, edgeShape{Points: c.route(), Label: label, Style: style})
+		}
+	}
+
+	d.fit(l.face)
+
+	return d, nil
+}
+
+// bounds returns the area covered by the vertex.
+func (v *vertex) bounds() rect {
+	return rect{X: v.x - v.width/2, Y: v.y - v.height/2, Width: v.width, Height: v.height}
+}
+
+// route returns the points of the edge from its source to its target: from the bottom of the
+// upper vertex, through any dummy vertices, to the top of the lower one.
+func (c *chain) route() []point {
+	result := make([]point, 0, len(c.vertices))
+
+	for i, v := range c.vertices {
+		p := point{X: v.x, Y: v.y}
+
+		switch i {
+		case 0:
+			p.Y += v.height / 2
+		case len(c.vertices) - 1:
+			p.Y -= v.height / 2
+		}
+
+		result = append(result, p)
+	}
+
+	if c.reversed {
+		slices.Reverse(result)
+	}
+
+	return result
+}
+
+// fit moves every shape so that the diagram starts at the margin, and sizes the diagram to
+// hold them all.
+func (d *diagram) fit(face font.Face) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	extend := func(r rect) {
+		minX, minY = math.Min(minX, r.X), math.Min(minY, r.Y)
+		maxX, maxY = math.Max(maxX, r.X+r.Width), math.Max(maxY, r.Y+r.Height)
+	}
+
+	for _, n := range d.Nodes {
+		extend(n.rect)
+	}
+
+	for _, c := range d.Clusters {
+		extend(c.rect)
+	}
+
+	for _, e := range d.Edges {
+		if e.Label != "" {
+			mid := e.labelPosition()
+			extend(rect{X: mid.X, Y: mid.Y - d.Settings.fontSize, Width: measure(face, e.Label) + 4})
+		}
+	}
+
+	if math.IsInf(minX, 1) {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+
+	dx, dy := margin-minX, margin-minY
+
+	for i := range d.Nodes {
+		d.Nodes[i].X += dx
+		d.Nodes[i].Y += dy
+	}
+
+	for i := range d.Clusters {
+		d.Clusters[i].X += dx
+		d.Clusters[i].Y += dy
+	}
+
+	for i := range d.Edges {
+		for j := range d.Edges[i].Points {
+			d.Edges[i].Points[j].X += dx
+			d.Edges[i].Points[j].Y += dy
+		}
+	}
+
+	d.Width = maxX - minX + 2*margin
+	d.Height = maxY - minY + 2*margin
+}
+
+// labelPosition returns where the label of the edge is drawn: beside the middle of its route.
+func (e edgeShape) labelPosition() point {
+	i := len(e.Points) / 2
+	a, b := e.Points[i-1], e.Points[i]
+
+	return point{X: (a.X+b.X)/2 + 4, Y: (a.Y + b.Y) / 2}
+}
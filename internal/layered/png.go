@@ -0,0 +1,249 @@
+package layered
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/rotisserie/eris"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// pngScale is the number of pixels per point in PNG output, giving 96 pixels per inch as
+// Graphviz does.
+const pngScale = 96.0 / pointsPerInch
+
+// cornerSegments is the number of straight segments used to draw each rounded corner.
+const cornerSegments = 6
+
+// pngCanvas draws a diagram onto an image.
+type pngCanvas struct {
+	img  *image.RGBA
+	face font.Face
+}
+
+// writePNG writes the diagram to w as a PNG image.
+func writePNG(w io.Writer, d *diagram) error {
+	face, err := newFace(d.Settings.fontSize * pngScale)
+	if err != nil {
+		return err
+	}
+
+	defer face.Close()
+
+	width := int(math.Ceil(d.Width * pngScale))
+	height := int(math.Ceil(d.Height * pngScale))
+
+	c := &pngCanvas{
+		img:  image.NewRGBA(image.Rect(0, 0, width, height)),
+		face: face,
+	}
+
+	drawDiagram(d, c)
+
+	err = png.Encode(w, c.img)
+
+	return eris.Wrap(err, "failed to write PNG")
+}
+
+func (c *pngCanvas) rect(r rect, radius float64, fill color.RGBA, line lineStyle) {
+	outline := rectOutline(r, radius)
+
+	c.polygon(outline, fill)
+
+	if line.Width > 0 && line.Color.A > 0 {
+		c.polyline(append(outline, outline[0]), line)
+	}
+}
+
+func (c *pngCanvas) polyline(points []point, line lineStyle) {
+	if line.Width <= 0 || line.Color.A == 0 {
+		return
+	}
+
+	half := line.Width / 2
+
+	// Each segment is filled on its own, as the bounds of a long edge can cover much of the image
+	for _, segment := range dashes(points, line.Dash) {
+		a, b := segment[0], segment[1]
+
+		dx, dy := b.X-a.X, b.Y-a.Y
+		length := math.Hypot(dx, dy)
+
+		if length == 0 {
+			continue
+		}
+
+		// Extend each segment by half the width, so that corners are filled
+		ux, uy := dx/length*half, dy/length*half
+		a = point{X: a.X - ux, Y: a.Y - uy}
+		b = point{X: b.X + ux, Y: b.Y + uy}
+
+		c.fill([][]point{{
+			{X: a.X - uy, Y: a.Y + ux},
+			{X: b.X - uy, Y: b.Y + ux},
+			{X: b.X + uy, Y: b.Y - ux},
+			{X: a.X + uy, Y: a.Y - ux},
+		}}, line.Color)
+	}
+}
+
+func (c *pngCanvas) polygon(points []point, fill color.RGBA) {
+	if fill.A == 0 || len(points) < 3 {
+		return
+	}
+
+	c.fill([][]point{points}, fill)
+}
+
+func (c *pngCanvas) text(p point, text string, col color.RGBA, align anchor) {
+	x := p.X * pngScale
+	if align == anchorMiddle {
+		x -= fixedToFloat(font.MeasureString(c.face, text)) / 2
+	}
+
+	d := font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: c.face,
+		Dot:  fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(p.Y * pngScale * 64)},
+	}
+
+	d.DrawString(text)
+}
+
+// fill paints the area of the closed paths, given in points, with colour col. Only the pixels
+// within the bounds of the paths are rasterized, so that each shape costs time in proportion to
+// its own size rather than that of the whole image.
+func (c *pngCanvas) fill(paths [][]point, col color.RGBA) {
+	bounds := pixelBounds(paths).Intersect(c.img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+
+	z := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	x0, y0 := float64(bounds.Min.X), float64(bounds.Min.Y)
+
+	for _, path := range paths {
+		z.MoveTo(float32(path[0].X*pngScale-x0), float32(path[0].Y*pngScale-y0))
+
+		for _, p := range path[1:] {
+			z.LineTo(float32(p.X*pngScale-x0), float32(p.Y*pngScale-y0))
+		}
+
+		z.ClosePath()
+	}
+
+	z.DrawOp = draw.Over
+	z.Draw(c.img, bounds, image.NewUniform(col), image.Point{})
+}
+
+// pixelBounds returns the smallest rectangle of pixels covering the paths, given in points.
+func pixelBounds(paths [][]point) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, path := range paths {
+		for _, p := range path {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+
+	return image.Rect(
+		int(math.Floor(minX*pngScale)), int(math.Floor(minY*pngScale)),
+		int(math.Ceil(maxX*pngScale)), int(math.Ceil(maxY*pngScale)))
+}
+
+// rectOutline returns the corners of r, rounded to the given radius.
+func rectOutline(r rect, radius float64) []point {
+	if radius <= 0 {
+		return []point{
+			{X: r.X, Y: r.Y},
+			{X: r.X + r.Width, Y: r.Y},
+			{X: r.X + r.Width, Y: r.Y + r.Height},
+			{X: r.X, Y: r.Y + r.Height},
+		}
+	}
+
+	radius = math.Min(radius, math.Min(r.Width, r.Height)/2)
+
+	centres := []point{
+		{X: r.X + r.Width - radius, Y: r.Y + radius},
+		{X: r.X + r.Width - radius, Y: r.Y + r.Height - radius},
+		{X: r.X + radius, Y: r.Y + r.Height - radius},
+		{X: r.X + radius, Y: r.Y + radius},
+	}
+
+	result := make([]point, 0, 4*(cornerSegments+1))
+
+	for i, centre := range centres {
+		start := -math.Pi/2 + float64(i)*math.Pi/2
+
+		for step := range cornerSegments + 1 {
+			angle := start + float64(step)*math.Pi/2/cornerSegments
+			result = append(result, point{
+				X: centre.X + radius*math.Cos(angle),
+				Y: centre.Y + radius*math.Sin(angle),
+			})
+		}
+	}
+
+	return result
+}
+
+// dashes returns the segments of the line through points that are drawn with the given dash
+// pattern; all of them if the pattern is empty.
+func dashes(points []point, pattern []float64) [][2]point {
+	var result [][2]point
+
+	index, remaining, on := 0, 0.0, true
+	if len(pattern) > 0 {
+		remaining = pattern[0]
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+
+		if len(pattern) == 0 {
+			result = append(result, [2]point{a, b})
+
+			continue
+		}
+
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		at := 0.0
+
+		for at < length {
+			step := math.Min(remaining, length-at)
+			if on {
+				result = append(result, [2]point{lerp(a, b, at/length), lerp(a, b, (at+step)/length)})
+			}
+
+			at += step
+			remaining -= step
+
+			if remaining <= 0 {
+				index = (index + 1) % len(pattern)
+				remaining = pattern[index]
+				on = !on
+			}
+		}
+	}
+
+	return result
+}
+
+// lerp returns the point the fraction t of the way from a to b.
+func lerp(a point, b point, t float64) point {
+	return point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+}
@@ -0,0 +1,338 @@
+package layered
+
+import (
+	"image/color"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/edgestyle"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+	"github.com/theunrepentantgeek/task-graph/internal/rgb"
+)
+
+const (
+	// defaultFont is used when the config names no font.
+	defaultFont = "Verdana"
+
+	// defaultFontSize is the font size, in points, used when the config gives none.
+	defaultFontSize = 14.0
+)
+
+var (
+	black = color.RGBA{A: 0xff}
+	white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+var patternCache sync.Map // map[string]*regexp.Regexp
+
+// shapeStyle is the presentation of a node or cluster.
+type shapeStyle struct {
+	// Fill is the colour inside the shape; fully transparent if not filled
+	Fill color.RGBA
+	// Border is the colour of the outline
+	Border color.RGBA
+	// Text is the colour of the label
+	Text color.RGBA
+	// Line is how the outline is drawn
+	Line lineStyle
+	// Rounded is true if the corners are rounded
+	Rounded bool
+	// Hidden is true if the shape is not drawn at all
+	Hidden bool
+}
+
+// lineStyle is how a line is drawn.
+type lineStyle struct {
+	// Color is the colour of the line
+	Color color.RGBA
+	// Width is the width of the line, in points
+	Width float64
+	// Dash is the pattern of dashes and gaps, in points, or nil for a solid line
+	Dash []float64
+}
+
+// settings are the graph-wide options of a diagram.
+type settings struct {
+	font       string
+	fontSize   float64
+	background color.RGBA
+	text       color.RGBA
+	nodeSep    float64
+	rankSep    float64
+	grouped    bool
+}
+
+// newSettings returns the graph-wide options from cfg, using Graphviz defaults for any not set.
+func newSettings(cfg *config.Config) settings {
+	result := settings{
+		font:       defaultFont,
+		fontSize:   defaultFontSize,
+		background: white,
+		text:       black,
+		nodeSep:    0.25 * pointsPerInch,
+		rankSep:    0.5 * pointsPerInch,
+	}
+
+	if cfg == nil {
+		return result
+	}
+
+	result.grouped = cfg.GroupByNamespace
+
+	gv := cfg.Graphviz
+	if gv == nil {
+		return result
+	}
+
+	if gv.Font != "" {
+		result.font = gv.Font
+	}
+
+	if gv.FontSize > 0 {
+		result.fontSize = float64(gv.FontSize)
+	}
+
+	if c, ok := rgb.Parse(gv.Background); ok {
+		result.background = c
+	}
+
+	if c, ok := rgb.Parse(gv.FontColor); ok {
+		result.text = c
+	}
+
+	if gv.NodeSep > 0 {
+		result.nodeSep = gv.NodeSep * pointsPerInch
+	}
+
+	if gv.RankSep > 0 {
+		result.rankSep = gv.RankSep * pointsPerInch
+	}
+
+	return result
+}
+
+// nodeStyle returns the presentation of node: the configured style for its kind, overridden by
+// each matching NodeStyleRule in turn.
+func nodeStyle(node *graph.Node, cfg *config.Config, s settings) (shapeStyle, error) {
+	result := shapeStyle{
+		Border:  black,
+		Text:    s.text,
+		Line:    lineStyle{Color: black, Width: 1},
+		Rounded: node.Kind != graph.NodeKindVariable,
+	}
+
+	if cfg == nil {
+		return result, nil
+	}
+
+	var fill, style string
+
+	apply := func(colour, fillColor, nodeStyle, fontColor string) {
+		if c, ok := rgb.Parse(colour); ok {
+			result.Border = c
+		}
+
+		if c, ok := rgb.Parse(fontColor); ok {
+			result.Text = c
+		}
+
+		if fillColor != "" {
+			fill = fillColor
+		}
+
+		if nodeStyle != "" {
+			style = nodeStyle
+		}
+	}
+
+	if gv := cfg.Graphviz; gv != nil {
+		base := gv.TaskNodes
+		if node.Kind == graph.NodeKindVariable {
+			base = gv.VariableNodes
+		}
+
+		if base != nil {
+			apply(base.Color, base.FillColor, base.Style, base.FontColor)
+		}
+	}
+
+	for _, rule := range cfg.NodeStyleRules {
+		ok, err := matches(rule.Match, node.ID())
+		if err != nil {
+			return shapeStyle{}, err
+		}
+
+		if ok {
+			apply(rule.Color, rule.FillColor, rule.Style, rule.FontColor)
+		}
+	}
+
+	result.Line.Color = result.Border
+	result.applyStyle(style, fill)
+
+	return result, nil
+}
+
+// applyStyle applies a Graphviz style and fill colour to the shape. As in Graphviz, a fill
+// colour with no style fills the shape.
+func (s *shapeStyle) applyStyle(style string, fill string) {
+	styles := make(map[string]bool)
+	for part := range strings.SplitSeq(style, ",") {
+		styles[strings.TrimSpace(part)] = true
+	}
+
+	c, ok := rgb.Parse(fill)
+	if ok && (style == "" || styles["filled"] || styles["striped"] || styles["wedged"] || styles["radial"]) {
+		s.Fill = c
+	}
+
+	s.Line = s.Line.withStyle(styles)
+	s.Rounded = s.Rounded || styles["rounded"]
+	s.Hidden = styles["invis"]
+}
+
+// withStyle returns the line drawn with the given Graphviz styles.
+func (l lineStyle) withStyle(styles map[string]bool) lineStyle {
+	switch {
+	case styles["dashed"]:
+		l.Dash = []float64{5 * l.Width, 3 * l.Width}
+	case styles["dotted"]:
+		l.Dash = []float64{l.Width, 3 * l.Width}
+	}
+
+	if styles["bold"] {
+		l.Width *= 2
+	}
+
+	return l
+}
+
+// clusterStyle returns the presentation of the clusters drawn around namespaces.
+func clusterStyle(cfg *config.Config, s settings) shapeStyle {
+	result := shapeStyle{
+		Border: black,
+		Text:   s.text,
+		Line:   lineStyle{Color: black, Width: 1},
+	}
+
+	if cfg == nil || cfg.Graphviz == nil || cfg.Graphviz.Clusters == nil {
+		return result
+	}
+
+	cluster := cfg.Graphviz.Clusters
+
+	if c, ok := rgb.Parse(cluster.Color); ok {
+		result.Border = c
+		result.Line.Color = c
+	}
+
+	if c, ok := rgb.Parse(cluster.FontColor); ok {
+		result.Text = c
+	}
+
+	result.applyStyle(cluster.Style, cluster.FillColor)
+
+	return result
+}
+
+// edgeStyle returns the presentation and label of edge: the configured style for its class,
+// overridden by any matching EdgeStyleRules.
+func edgeStyle(edge *graph.Edge, cfg *config.Config) (lineStyle, string, bool, error) {
+	result := lineStyle{Color: black, Width: 1}
+	label := edge.Label()
+
+	if cfg == nil {
+		return result, label, false, nil
+	}
+
+	var gvEdge *config.GraphvizEdge
+
+	if gv := cfg.Graphviz; gv != nil {
+		switch edge.Class() {
+		case graph.EdgeClassDep:
+			gvEdge = gv.DependencyEdges
+		case graph.EdgeClassCall:
+			gvEdge = gv.CallEdges
+		case graph.EdgeClassVar:
+			gvEdge = gv.VariableEdges
+		}
+	}
+
+	style := edgestyle.Style{}
+	if gvEdge != nil {
+		style = edgestyle.Style{Color: gvEdge.Color, Style: gvEdge.Style, Width: gvEdge.Width}
+	}
+
+	rules, err := edgestyle.Resolve(cfg.EdgeStyleRules, edge)
+	if err != nil {
+		return lineStyle{}, "", false, err
+	}
+
+	style = overlay(style, rules)
+
+	if c, ok := rgb.Parse(style.Color); ok {
+		result.Color = c
+	}
+
+	if style.Width > 0 {
+		result.Width = float64(style.Width)
+	}
+
+	if style.Label != "" {
+		label = style.Label
+	}
+
+	styles := make(map[string]bool)
+	for part := range strings.SplitSeq(style.Style, ",") {
+		styles[strings.TrimSpace(part)] = true
+	}
+
+	return result.withStyle(styles), label, styles["invis"], nil
+}
+
+// overlay returns base with every field set in top replacing it.
+func overlay(base edgestyle.Style, top edgestyle.Style) edgestyle.Style {
+	if top.Color != "" {
+		base.Color = top.Color
+	}
+
+	if top.Style != "" {
+		base.Style = top.Style
+	}
+
+	if top.Width > 0 {
+		base.Width = top.Width
+	}
+
+	if top.Label != "" {
+		base.Label = top.Label
+	}
+
+	return base
+}
+
+// matches returns true if id matches the NodeStyleRule pattern.
+func matches(pattern string, id string) (bool, error) {
+	value, ok := patternCache.Load(pattern)
+	if !ok {
+		re, err := namespace.CompileMatchPattern(pattern)
+		if err != nil {
+			return false, eris.Wrapf(err, "failed to compile match pattern %q", pattern)
+		}
+
+		patternCache.Store(pattern, re)
+		value = re
+	}
+
+	re, ok := value.(*regexp.Regexp)
+	if !ok {
+		return false, eris.New("cached node style pattern is not a regular expression")
+	}
+
+	return re.MatchString(id), nil
+}
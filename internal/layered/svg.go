@@ -0,0 +1,137 @@
+package layered
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/rgb"
+)
+
+// svgEscaper escapes text for use in SVG content and attribute values.
+var svgEscaper = strings.NewReplacer(
+	`&`, `&amp;`,
+	`<`, `&lt;`,
+	`>`, `&gt;`,
+	`"`, `&quot;`,
+)
+
+// svgCanvas draws a diagram as SVG elements.
+type svgCanvas struct {
+	b        strings.Builder
+	fontSize float64
+}
+
+// writeSVG writes the diagram to w as an SVG document.
+func writeSVG(w io.Writer, d *diagram) error {
+	c := &svgCanvas{fontSize: d.Settings.fontSize}
+
+	fmt.Fprintf(&c.b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&c.b,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%spt" height="%spt" viewBox="0 0 %s %s"`+
+			` font-family="%s, sans-serif" font-size="%s">`+"\n",
+		num(d.Width), num(d.Height), num(d.Width), num(d.Height),
+		svgEscaper.Replace(d.Settings.font), num(d.Settings.fontSize))
+
+	drawDiagram(d, c)
+
+	c.b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, c.b.String())
+
+	return eris.Wrap(err, "failed to write SVG")
+}
+
+func (c *svgCanvas) rect(r rect, radius float64, fill color.RGBA, line lineStyle) {
+	fmt.Fprintf(&c.b, `  <rect x="%s" y="%s" width="%s" height="%s"`, num(r.X), num(r.Y), num(r.Width), num(r.Height))
+
+	if radius > 0 {
+		fmt.Fprintf(&c.b, ` rx="%s"`, num(radius))
+	}
+
+	c.b.WriteString(paint("fill", fill))
+	c.b.WriteString(stroke(line))
+	c.b.WriteString("/>\n")
+}
+
+func (c *svgCanvas) polyline(points []point, line lineStyle) {
+	fmt.Fprintf(&c.b, `  <polyline points="%s" fill="none"%s/>`+"\n", pointList(points), stroke(line))
+}
+
+func (c *svgCanvas) polygon(points []point, fill color.RGBA) {
+	fmt.Fprintf(&c.b, `  <polygon points="%s"%s/>`+"\n", pointList(points), paint("fill", fill))
+}
+
+func (c *svgCanvas) text(p point, text string, col color.RGBA, align anchor) {
+	textAnchor := "middle"
+	if align == anchorStart {
+		textAnchor = "start"
+	}
+
+	fmt.Fprintf(&c.b, `  <text x="%s" y="%s" text-anchor="%s"%s>%s</text>`+"\n",
+		num(p.X), num(p.Y), textAnchor, paint("fill", col), svgEscaper.Replace(text))
+}
+
+// paint returns the attributes painting a property of an element in colour c, or leaving it
+// unpainted if c is fully transparent.
+func paint(property string, c color.RGBA) string {
+	switch c.A {
+	case 0:
+		return fmt.Sprintf(` %s="none"`, property)
+	case 0xff:
+		return fmt.Sprintf(` %s="%s"`, property, rgb.Hex(c))
+	default:
+		return fmt.Sprintf(` %s="%s" %s-opacity="%s"`, property, rgb.Hex(c), property, num(float64(c.A)/0xff))
+	}
+}
+
+// stroke returns the attributes drawing the outline of an element with the given line.
+func stroke(line lineStyle) string {
+	if line.Width <= 0 || line.Color.A == 0 {
+		return ` stroke="none"`
+	}
+
+	result := paint("stroke", line.Color)
+
+	if line.Width != 1 {
+		result += fmt.Sprintf(` stroke-width="%s"`, num(line.Width))
+	}
+
+	if len(line.Dash) > 0 {
+		dashes := make([]string, len(line.Dash))
+		for i, d := range line.Dash {
+			dashes[i] = num(d)
+		}
+
+		result += fmt.Sprintf(` stroke-dasharray="%s"`, strings.Join(dashes, ","))
+	}
+
+	return result
+}
+
+// pointList returns points formatted for the points attribute of a polyline or polygon.
+func pointList(points []point) string {
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = num(p.X) + "," + num(p.Y)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// num formats a length to two decimal places, without trailing zeros, so that output is
+// compact and stable.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
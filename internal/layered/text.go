@@ -0,0 +1,51 @@
+package layered
+
+import (
+	"sync"
+
+	"github.com/rotisserie/eris"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	parseFontOnce sync.Once
+	regularFont   *opentype.Font
+	errParseFont  error
+)
+
+// newFace returns the font face used to measure and draw text of the given size, in points.
+// Text is measured with the Go font, which is embedded, so that layouts are the same wherever
+// they are made; SVG output names the configured font, which may be a little wider or narrower.
+func newFace(size float64) (font.Face, error) {
+	parseFontOnce.Do(func() {
+		regularFont, errParseFont = opentype.Parse(goregular.TTF)
+	})
+
+	if errParseFont != nil {
+		return nil, eris.Wrap(errParseFont, "failed to load font")
+	}
+
+	face, err := opentype.NewFace(regularFont, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     pointsPerInch,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create font face")
+	}
+
+	return face, nil
+}
+
+// measure returns the width of text drawn in face, in points.
+func measure(face font.Face, text string) float64 {
+	return fixedToFloat(font.MeasureString(face, text))
+}
+
+// fixedToFloat converts a fixed point length to points.
+func fixedToFloat(v fixed.Int26_6) float64 {
+	return float64(v) / 64
+}
@@ -0,0 +1,105 @@
+// Package links builds the links and tooltips of task nodes, so that rendered graphs can be used
+// to navigate to the definition of each task.
+package links
+
+import (
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// maxCommands is the number of commands listed in a tooltip before the rest are elided.
+const maxCommands = 10
+
+// Fields are the values available to a link template.
+type Fields struct {
+	// Task is the full name of the task, including any namespace.
+	Task string
+
+	// File is the path of the Taskfile defining the task.
+	File string
+
+	// Line is the line on which the task is defined.
+	Line int
+}
+
+var templateCache sync.Map // map[string]*template.Template
+
+// Parse parses a link template, such as https://example.com/{{.File}}#L{{.Line}}, checking that
+// it only refers to the available Fields.
+func Parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("link").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid link template %q", text)
+	}
+
+	err = tmpl.Execute(&strings.Builder{}, Fields{})
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid link template %q", text)
+	}
+
+	return tmpl, nil
+}
+
+// URL returns the link for node from the given template, or "" if the template is empty or the
+// source of the task is unknown.
+func URL(text string, node *graph.Node) (string, error) {
+	if text == "" || node.File == "" {
+		return "", nil
+	}
+
+	value, ok := templateCache.Load(text)
+	if !ok {
+		tmpl, err := Parse(text)
+		if err != nil {
+			return "", err
+		}
+
+		templateCache.Store(text, tmpl)
+		value = tmpl
+	}
+
+	tmpl, ok := value.(*template.Template)
+	if !ok {
+		return "", eris.New("cached link template is not a template")
+	}
+
+	var b strings.Builder
+
+	err := tmpl.Execute(&b, Fields{Task: node.ID(), File: node.File, Line: node.Line})
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to build link for task %s", node.ID())
+	}
+
+	return b.String(), nil
+}
+
+// Tooltip returns the tooltip for node: its full description followed by a summary of its
+// commands, one per line, or just its name if it has neither.
+func Tooltip(node *graph.Node) string {
+	lines := make([]string, 0, 2+len(node.Commands))
+
+	if node.Description != "" {
+		lines = append(lines, node.Description)
+	}
+
+	for i, cmd := range node.Commands {
+		if i == maxCommands {
+			lines = append(lines, "...")
+
+			break
+		}
+
+		lines = append(lines, "$ "+cmd)
+	}
+
+	if len(lines) == 0 {
+		return node.DisplayLabel()
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,67 @@
+package links
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestURL_ExpandsTemplate(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	node := graph.NewNode("docs:build")
+	node.File = "docs/Taskfile.yml"
+	node.Line = 12
+
+	// Act
+	url, err := URL("https://github.com/org/repo/blob/main/{{.File}}#L{{.Line}}", node)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://github.com/org/repo/blob/main/docs/Taskfile.yml#L12"))
+}
+
+func TestURL_UnknownSource_ReturnsEmpty(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	url, err := URL("https://example.com/{{.File}}", graph.NewNode("build"))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(BeEmpty())
+}
+
+func TestParse_UnknownField_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Parse("https://example.com/{{.Path}}")
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("invalid link template")))
+}
+
+func TestTooltip_ListsDescriptionAndCommands(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	node := graph.NewNode("build")
+	node.Description = "Build the site"
+	node.Commands = []string{"hugo --minify", "task: lint"}
+
+	// Act
+	detailed := Tooltip(node)
+	plain := Tooltip(graph.NewNode("test"))
+
+	// Assert
+	g.Expect(detailed).To(Equal("Build the site\n$ hugo --minify\n$ task: lint"))
+	g.Expect(plain).To(Equal("test"))
+}
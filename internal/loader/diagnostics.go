@@ -0,0 +1,69 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	taskerrors "github.com/go-task/task/v3/errors"
+
+	"github.com/go-task/task/v3/taskfile"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+// asDiagnostic converts an error from reading a Taskfile with invalid content into a
+// *diagnostic.Error locating each problem within the file, so the offending lines can be
+// shown. Other errors are returned unchanged. root is the node the Taskfile graph was read from.
+func asDiagnostic(err error, root taskfile.Node) error {
+	var (
+		decodeErr  *taskerrors.TaskfileDecodeError
+		invalidErr *taskerrors.TaskfileInvalidError
+	)
+
+	switch {
+	case errors.As(err, &decodeErr):
+		problems := []diagnostic.Diagnostic{{
+			Line:    decodeErr.Line,
+			Column:  decodeErr.Column,
+			Message: decodeErr.Message,
+		}}
+
+		if decodeErr.Message == "" && decodeErr.Err != nil {
+			problems = diagnostic.FromYAMLError(decodeErr.Err)
+			if len(problems) == 1 && problems[0].Line == decodeErr.Line {
+				// Keep the column, which is only known for a single problem
+				problems[0].Column = decodeErr.Column
+			}
+		}
+
+		return diagnostic.New(decodeErr.Location, source(decodeErr.Location, root), problems)
+
+	case errors.As(err, &invalidErr):
+		return diagnostic.New(
+			invalidErr.URI,
+			source(invalidErr.URI, root),
+			diagnostic.FromYAMLError(invalidErr.Err))
+
+	default:
+		return err
+	}
+}
+
+// source returns the content of the Taskfile at location, or nil if it can't be read.
+func source(location string, root taskfile.Node) []byte {
+	if node, ok := root.(*contentNode); ok && location == node.Location() {
+		return node.content
+	}
+
+	if taskfile.IsRemoteEntrypoint(location) {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(location))
+	if err != nil {
+		return nil
+	}
+
+	return content
+}
@@ -0,0 +1,138 @@
+package loader
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-task/task/v3/taskfile"
+	"github.com/rotisserie/eris"
+)
+
+// Find returns the path of the Taskfile to use for the given path, searching the same way task
+// does. A path naming a file is returned as is, and a path naming a directory must hold one of
+// the Taskfile names task recognises. Only when no path is given is the search widened, from
+// the working directory up through its parents, as described by findUpwards.
+func Find(path string) (string, error) {
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", eris.Wrap(err, "failed to determine working directory")
+		}
+
+		return findUpwards(wd)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to find taskfile: %s", path)
+	}
+
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	if file, ok := findTaskfileIn(path); ok {
+		return file, nil
+	}
+
+	return "", eris.Errorf(
+		"no taskfile found in %s (looked for %s)",
+		path,
+		strings.Join(taskfile.DefaultTaskfiles, ", "))
+}
+
+// findUpwards searches dir and then each of its parents for a Taskfile, stopping at the first
+// found. Like task, it stops early at a parent with a different owner, so that a Taskfile
+// belonging to another user is never picked up.
+func findUpwards(dir string) (string, error) {
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to resolve path: %s", dir)
+	}
+
+	owner, err := fileOwner(current)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to find taskfile: %s", dir)
+	}
+
+	for {
+		if file, ok := findTaskfileIn(current); ok {
+			return file, nil
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+
+		parentOwner, err := fileOwner(parent)
+		if err != nil || parentOwner != owner {
+			break
+		}
+
+		current = parent
+	}
+
+	return "", eris.Errorf(
+		"no taskfile found in %s or any parent directory with the same owner (looked for %s)",
+		dir,
+		strings.Join(taskfile.DefaultTaskfiles, ", "))
+}
+
+// Discover walks the directory tree rooted at root and returns the path of the Taskfile in each
+// directory that has one, in lexical order. Where a directory has more than one, the one task
+// itself would use is chosen. Hidden directories (such as .git) are skipped.
+func Discover(root string) ([]string, error) {
+	var result []string
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if file, ok := findTaskfileIn(path); ok {
+			result = append(result, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to search for taskfiles in %s", root)
+	}
+
+	return result, nil
+}
+
+// findTaskfileIn returns the path of the Taskfile task would use in the given directory, if any.
+func findTaskfileIn(dir string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	for _, name := range taskfile.DefaultTaskfiles {
+		if slices.Contains(names, name) {
+			return filepath.Join(dir, name), true
+		}
+	}
+
+	return "", false
+}
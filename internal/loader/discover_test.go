@@ -0,0 +1,158 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// writeFiles creates empty files at the given paths relative to root.
+func writeFiles(t *testing.T, root string, paths ...string) {
+	t.Helper()
+
+	for _, p := range paths {
+		full := filepath.Join(root, p)
+
+		err := os.MkdirAll(filepath.Dir(full), 0o750)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(full, []byte("version: '3'\n"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscover_FindsTaskfilesInTree(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	writeFiles(t, root,
+		"Taskfile.yml",
+		"services/api/Taskfile.yaml",
+		"services/web/Taskfile.dist.yml",
+		"services/web/Taskfile.yml",
+		"docs/README.md",
+		".git/Taskfile.yml",
+	)
+
+	// Act
+	found, err := Discover(root)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(Equal([]string{
+		filepath.Join(root, "Taskfile.yml"),
+		filepath.Join(root, "services", "api", "Taskfile.yaml"),
+		filepath.Join(root, "services", "web", "Taskfile.yml"),
+	}))
+}
+
+func TestDiscover_MissingDirectory_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Discover(filepath.Join(t.TempDir(), "missing"))
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFind_File_ReturnsPathUnchanged(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := filepath.Join("testdata", "go-vcr-tidy-taskfile.yml")
+
+	// Act
+	found, err := Find(path)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(Equal(path))
+}
+
+func TestFind_Directory_UsesTaskSearchOrder(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	writeFiles(t, root, "Taskfile.dist.yml", "Taskfile.yaml")
+
+	// Act
+	found, err := Find(root)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(Equal(filepath.Join(root, "Taskfile.yaml")))
+}
+
+func TestFind_DirectoryWithoutTaskfile_DoesNotWalkUp(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	writeFiles(t, root, "Taskfile.yml", "services/api/main.go")
+
+	// Act
+	_, err := Find(filepath.Join(root, "services", "api"))
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("no taskfile found")))
+}
+
+func TestFindUpwards_Subdirectory_WalksUpToParent(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	root := t.TempDir()
+	writeFiles(t, root, "Taskfile.yml", "services/api/main.go")
+
+	// Act
+	found, err := findUpwards(filepath.Join(root, "services", "api"))
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(Equal(filepath.Join(root, "Taskfile.yml")))
+}
+
+func TestFind_MissingPath_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Find(filepath.Join(t.TempDir(), "missing.yml"))
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestLoad_Directory_LoadsTaskfileWithin(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content, err := os.ReadFile(filepath.Join("testdata", "go-vcr-tidy-taskfile.yml"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	root := t.TempDir()
+	g.Expect(os.WriteFile(filepath.Join(root, "Taskfile.yml"), content, 0o600)).To(Succeed())
+
+	// Act
+	tf, _, err := Load(t.Context(), root, Options{})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Len()).To(Equal(14))
+}
@@ -0,0 +1,74 @@
+package loader
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/go-task/task/v3/taskfile"
+	"github.com/go-task/task/v3/taskfile/ast"
+	"github.com/rotisserie/eris"
+)
+
+// File is a Taskfile read while loading, giving access to content that go-task itself ignores,
+// such as top-level keys starting with x-.
+type File struct {
+	// Location is the path or URL of the Taskfile.
+	Location string
+
+	// Namespace is the namespace in which its tasks were placed: empty for the root Taskfile,
+	// and the namespaces of the includes leading to it, separated by colons, for others.
+	Namespace string
+
+	// Content is the content of the Taskfile, or nil for remote Taskfiles.
+	Content []byte
+
+	// Placeholder is true if the Taskfile stands in for a remote include that couldn't be
+	// loaded, holding just a PlaceholderTask.
+	Placeholder bool
+}
+
+// listFiles returns the Taskfiles in graph, starting from the root node. A Taskfile included
+// more than once is listed once for each namespace it is included in.
+func listFiles(graph *ast.TaskfileGraph, root taskfile.Node) ([]File, error) {
+	adjacency, err := graph.AdjacencyMap()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read taskfile graph")
+	}
+
+	var result []File
+
+	var visit func(location string, namespace string)
+	visit = func(location string, namespace string) {
+		result = append(result, File{
+			Location:  location,
+			Namespace: namespace,
+			Content:   source(location, root),
+		})
+
+		edges := adjacency[location]
+		for _, target := range slices.Sorted(maps.Keys(edges)) {
+			includes, _ := edges[target].Properties.Data.([]*ast.Include)
+			for _, include := range includes {
+				child := namespace
+				if !include.Flatten {
+					child = joinNamespace(namespace, include.Namespace)
+				}
+
+				visit(target, child)
+			}
+		}
+	}
+
+	visit(root.Location(), "")
+
+	return result, nil
+}
+
+// joinNamespace returns the namespace nested within parent.
+func joinNamespace(parent string, namespace string) string {
+	if parent == "" {
+		return namespace
+	}
+
+	return parent + ":" + namespace
+}
@@ -0,0 +1,62 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLoad_ReturnsEachTaskfileWithNamespace(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	files := map[string]string{
+		"Taskfile.yml": "version: '3'\n" +
+			"x-task-graph:\n" +
+			"  graphType: mermaid\n" +
+			"includes:\n" +
+			"  docs: ./docs\n" +
+			"  common:\n" +
+			"    taskfile: ./common\n" +
+			"    flatten: true\n" +
+			"tasks:\n" +
+			"  build: {}\n",
+		"docs/Taskfile.yml":      "version: '3'\nincludes:\n  site: ./site\ntasks:\n  build: {}\n",
+		"docs/site/Taskfile.yml": "version: '3'\ntasks:\n  publish: {}\n",
+		"common/Taskfile.yml":    "version: '3'\ntasks:\n  lint: {}\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		g.Expect(os.MkdirAll(filepath.Dir(path), 0o750)).To(Succeed())
+		g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+	}
+
+	// Act
+	_, loaded, err := Load(t.Context(), filepath.Join(dir, "Taskfile.yml"), Options{})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	namespaces := make(map[string]string, len(loaded))
+	for _, file := range loaded {
+		rel, err := filepath.Rel(dir, file.Location)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		namespaces[filepath.ToSlash(rel)] = file.Namespace
+	}
+
+	g.Expect(namespaces).To(Equal(map[string]string{
+		"Taskfile.yml":           "",
+		"docs/Taskfile.yml":      "docs",
+		"docs/site/Taskfile.yml": "docs:site",
+		"common/Taskfile.yml":    "",
+	}))
+
+	g.Expect(loaded[0].Namespace).To(BeEmpty())
+	g.Expect(string(loaded[0].Content)).To(ContainSubstring("x-task-graph:"))
+}
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"context"
+	"io"
 	"path/filepath"
 
 	"github.com/go-task/task/v3/taskfile"
@@ -10,22 +11,26 @@ import (
 )
 
 // Load reads and parses the Taskfile at the given filename, resolving relative
-// paths before delegating to the go-task reader. Returns the merged AST or an
-// error if the file cannot be read, parsed, or merged.
+// paths before delegating to the go-task reader. If filename is a directory, the
+// Taskfile is located as described by Find. Remote includes are resolved as
+// described by opts. Returns the merged AST or an error if the file cannot be
+// read, parsed, or merged. Each Taskfile read is also returned, as described by File.
 func Load(
 	ctx context.Context,
 	filename string,
-) (*ast.Taskfile, error) {
-	resolvedPath := filename
+	opts Options,
+) (*ast.Taskfile, []File, error) {
+	resolvedPath, err := Find(filename)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Resolve relative paths up front so the taskfile reader can locate
 	// the file regardless of the current working directory.
 	if !filepath.IsAbs(resolvedPath) {
-		var err error
-
-		resolvedPath, err = filepath.Abs(filename)
+		resolvedPath, err = filepath.Abs(resolvedPath)
 		if err != nil {
-			return nil, eris.Wrapf(err, "failed to resolve path: %s", filename)
+			return nil, nil, eris.Wrapf(err, "failed to resolve path: %s", filename)
 		}
 	}
 
@@ -39,20 +44,121 @@ func Load(
 		0,          // Task execution timeout
 	)
 	if err != nil {
-		return nil, eris.Wrapf(err, "failed to create root node for taskfile: %s", entrypoint)
+		return nil, nil, eris.Wrapf(err, "failed to create root node for taskfile: %s", entrypoint)
+	}
+
+	return read(ctx, node, entrypoint, opts)
+}
+
+// LoadReader reads and parses a Taskfile from the given reader, such as standard input.
+// Relative includes are resolved against dir, or the working directory if dir is empty, and
+// remote includes as described by opts. Each Taskfile read is also returned, as described by
+// File.
+func LoadReader(
+	ctx context.Context,
+	r io.Reader,
+	dir string,
+	opts Options,
+) (*ast.Taskfile, []File, error) {
+	if dir == "" {
+		dir = "."
 	}
 
-	reader := taskfile.NewReader()
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to resolve path: %s", dir)
+	}
 
-	graph, err := reader.Read(ctx, node)
+	stdin, err := taskfile.NewStdinNode(absDir)
 	if err != nil {
-		return nil, eris.Wrapf(err, "failed to read taskfile: %s", entrypoint)
+		return nil, nil, eris.Wrap(err, "failed to create root node for taskfile from stdin")
+	}
+
+	// Read the content up front, as the Taskfile may be read more than once
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "failed to read taskfile content")
+	}
+
+	return read(ctx, &contentNode{StdinNode: stdin, content: content}, "stdin", opts)
+}
+
+// read reads the Taskfile graph rooted at node and merges it into a single Taskfile.
+// If placeholders are enabled, each remote include that can't be loaded is replaced by a
+// placeholder and the graph read again, until it can be read in full.
+func read(
+	ctx context.Context,
+	node taskfile.Node,
+	entrypoint string,
+	opts Options,
+) (*ast.Taskfile, []File, error) {
+	defer enableRemoteTaskfiles()()
+
+	cache, err := newRemoteCache(opts, node.Dir())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer cache.close()
+
+	var graph *ast.TaskfileGraph
+
+	ctx, cancel := cache.context(ctx)
+	defer cancel()
+
+	for {
+		reader := taskfile.NewReader(cache.readerOptions()...)
+
+		graph, err = reader.Read(ctx, node)
+		if err == nil {
+			break
+		}
+
+		uri, unreachable := unreachableRemote(err)
+		if !opts.Placeholders || !unreachable || cache.hasStandIn(uri) {
+			return nil, nil, eris.Wrapf(
+				explainUntrusted(asDiagnostic(err, node)), "failed to read taskfile: %s", entrypoint)
+		}
+
+		err = cache.addPlaceholder(uri)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = cache.save()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Files are listed before merging, which changes the Taskfiles in the graph
+	files, err := listFiles(graph, node)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to list taskfiles: %s", entrypoint)
+	}
+
+	for i := range files {
+		files[i].Placeholder = cache.placeholders[files[i].Location]
 	}
 
 	result, err := graph.Merge()
 	if err != nil {
-		return nil, eris.Wrapf(err, "failed to merge taskfile graph: %s", entrypoint)
+		return nil, nil, eris.Wrapf(err, "failed to merge taskfile graph: %s", entrypoint)
 	}
 
-	return result, nil
+	return result, files, nil
+}
+
+// contentNode is a root Taskfile node with content already read, such as from standard input,
+// resolving includes in the same way as a Taskfile read from standard input.
+type contentNode struct {
+	*taskfile.StdinNode
+	content []byte
+}
+
+var _ taskfile.Node = &contentNode{}
+
+// Read returns the content of the Taskfile.
+func (n *contentNode) Read() ([]byte, error) {
+	return n.content, nil
 }
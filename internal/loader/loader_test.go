@@ -3,9 +3,12 @@ package loader
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
 )
 
 func TestLoad(t *testing.T) {
@@ -37,7 +40,7 @@ func TestLoad(t *testing.T) {
 
 			fn := filepath.Join("testdata", c.taskfile)
 
-			tf, err := Load(t.Context(), fn)
+			tf, _, err := Load(t.Context(), fn, Options{})
 			if c.expectedError == "" {
 				g.Expect(err).NotTo(HaveOccurred())
 				g.Expect(tf.Tasks.Len()).To(Equal(c.expectedTasks))
@@ -57,7 +60,7 @@ func TestLoad_AbsolutePath_Succeeds(t *testing.T) {
 	g.Expect(err).NotTo(HaveOccurred())
 
 	// Act
-	tf, err := Load(t.Context(), abs)
+	tf, _, err := Load(t.Context(), abs, Options{})
 
 	// Assert: absolute path is handled the same as relative
 	g.Expect(err).NotTo(HaveOccurred())
@@ -69,7 +72,7 @@ func TestLoad_NonExistentFile_ReturnsError(t *testing.T) {
 	g := NewWithT(t)
 
 	// Act
-	tf, err := Load(t.Context(), "testdata/does-not-exist.yml")
+	tf, _, err := Load(t.Context(), "testdata/does-not-exist.yml", Options{})
 
 	// Assert
 	g.Expect(err).To(HaveOccurred())
@@ -87,9 +90,77 @@ func TestLoad_InvalidYAML_ReturnsError(t *testing.T) {
 	g.Expect(err).NotTo(HaveOccurred())
 
 	// Act
-	tf, err := Load(t.Context(), badFile)
+	tf, _, err := Load(t.Context(), badFile, Options{})
 
 	// Assert
 	g.Expect(err).To(HaveOccurred())
 	g.Expect(tf).To(BeNil())
 }
+
+func TestLoadReader_ResolvesIncludesRelativeToDir(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	included := "version: '3'\ntasks:\n  build:\n    cmds:\n      - echo build\n"
+	g.Expect(os.WriteFile(filepath.Join(dir, "lib.yml"), []byte(included), 0o600)).To(Succeed())
+
+	root := "version: '3'\nincludes:\n  lib: ./lib.yml\ntasks:\n  default:\n    deps: [lib:build]\n"
+
+	// Act
+	tf, _, err := LoadReader(t.Context(), strings.NewReader(root), dir, Options{})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Len()).To(Equal(2))
+}
+
+func TestLoadReader_InvalidContent_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, _, err := LoadReader(t.Context(), strings.NewReader("tasks: [not, a, map]"), "", Options{})
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestLoad_InvalidTaskfile_ReportsProblemWithPosition(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\ntasks:\n  build:\n    deps: 42\n"
+	path := filepath.Join(t.TempDir(), "Taskfile.yml")
+	g.Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+
+	// Act
+	_, _, err := Load(t.Context(), path, Options{})
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(diags[0].File).To(Equal(path))
+	g.Expect(string(diags[0].Source)).To(Equal(content))
+	g.Expect(diags[0].Diagnostics).To(HaveLen(1))
+	g.Expect(diags[0].Diagnostics[0].Line).To(Equal(4))
+}
+
+func TestLoadReader_InvalidYAML_ReportsProblemInContent(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	content := "version: '3'\ntasks:\n  build: [\n"
+
+	// Act
+	_, _, err := LoadReader(t.Context(), strings.NewReader(content), "", Options{})
+
+	// Assert
+	diags := diagnostic.All(err)
+	g.Expect(diags).To(HaveLen(1))
+	g.Expect(string(diags[0].Source)).To(Equal(content))
+	g.Expect(diags[0].Diagnostics[0].Line).To(BeNumerically(">", 0))
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package loader
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/rotisserie/eris"
+)
+
+// fileOwner returns the ID of the user owning the file at path.
+func fileOwner(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to read owner of %s", path)
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(stat.Uid), nil
+	}
+
+	return os.Getuid(), nil
+}
@@ -0,0 +1,399 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	taskerrors "github.com/go-task/task/v3/errors"
+
+	"github.com/go-task/task/v3/experiments"
+	"github.com/go-task/task/v3/taskfile"
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// PlaceholderTask is the name of the task standing in for a remote include that could not be
+// loaded. It is placed in the namespace of the include, so that the include is still drawn.
+const PlaceholderTask = "unreachable-include"
+
+// Options control how remote includes are resolved when loading a Taskfile.
+type Options struct {
+	// CacheDir is the directory in which task-graph keeps the remote Taskfiles it downloads, in
+	// the same layout task uses. Defaults to a task-graph directory within the user's cache
+	// directory. The cache task keeps in the .task directory alongside the root Taskfile is
+	// also read, but never written.
+	CacheDir string
+
+	// Download allows remote includes to be downloaded when they are missing from the caches,
+	// or their cached copies have expired. Otherwise they are resolved only from the caches and
+	// Mappings.
+	Download bool
+
+	// Offline prevents downloading, even if Download is set.
+	Offline bool
+
+	// Timeout limits how long downloading remote includes may take. DefaultTimeout is used if
+	// it is zero.
+	Timeout time.Duration
+
+	// TrustedHosts are the hosts, with any port, whose Taskfiles are used when downloaded. A
+	// Taskfile from any other host is refused unless task has already trusted that content, as
+	// there is no one to ask.
+	TrustedHosts []string
+
+	// Mappings maps the URL of a remote include to a local file used in its place.
+	Mappings map[string]string
+
+	// Placeholders replaces each remote include that can't be loaded with a Taskfile holding a
+	// single PlaceholderTask, instead of failing.
+	Placeholders bool
+
+	// caCert is the path of an extra certificate authority to trust when downloading, so that
+	// tests can serve remote Taskfiles locally.
+	caCert string
+}
+
+// DefaultTimeout is how long downloading remote includes may take when Options.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// taskCacheDir is the cache directory task uses, relative to the root Taskfile.
+const taskCacheDir = ".task"
+
+// remoteSubdir is the directory within the cache where task keeps remote Taskfiles.
+const remoteSubdir = "remote"
+
+// checksumExt is the extension of the files in which task records the checksum of each remote
+// Taskfile it has trusted.
+const checksumExt = ".checksum"
+
+// standInTimestamp is recorded for stand-ins so that they never expire, and are used in
+// preference to downloading.
+var standInTimestamp = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// errNotTrusted refuses remote Taskfiles that task would ask about before using.
+var errNotTrusted = errors.New("remote taskfile is not trusted")
+
+// remoteTaskfilesLock serialises loads while go-task's remote Taskfiles experiment is enabled.
+var remoteTaskfilesLock sync.Mutex
+
+// enableRemoteTaskfiles turns on go-task's support for remote includes, an experiment otherwise
+// enabled through the environment, until the returned function is called. go-task keeps its
+// experiments in package variables, so loads are serialised while it is on, and the previous
+// setting is restored afterwards.
+func enableRemoteTaskfiles() func() {
+	remoteTaskfilesLock.Lock()
+
+	previous := experiments.RemoteTaskfiles
+	experiments.RemoteTaskfiles = experiments.Experiment{
+		Name:          "REMOTE_TASKFILES",
+		AllowedValues: []int{1},
+		Value:         1,
+	}
+
+	return func() {
+		experiments.RemoteTaskfiles = previous
+
+		remoteTaskfilesLock.Unlock()
+	}
+}
+
+// remoteCache is the directory from which remote includes are resolved: a temporary overlay
+// seeded from task's cache and then task-graph's own, to which stand-ins (mapped local files or
+// placeholders) are added. Anything downloaded is copied to task-graph's cache once reading
+// succeeds; task's cache is never changed, so that task still asks before using a Taskfile
+// whose content it hasn't seen.
+type remoteCache struct {
+	// dir is task-graph's own cache, or empty if there is none
+	dir          string
+	download     bool
+	timeout      time.Duration
+	trustedHosts []string
+	caCert       string
+	overlay      string
+	// standIns holds the cache keys of the stand-ins written to the overlay
+	standIns map[string]bool
+	// placeholders holds the URIs of the remote Taskfiles replaced by placeholders
+	placeholders map[string]bool
+}
+
+// newRemoteCache creates the cache for loading the Taskfile in rootDir, adding a stand-in for
+// each mapped URL.
+func newRemoteCache(opts Options, rootDir string) (*remoteCache, error) {
+	result := &remoteCache{
+		dir:          opts.CacheDir,
+		download:     opts.Download && !opts.Offline,
+		timeout:      opts.Timeout,
+		trustedHosts: opts.TrustedHosts,
+		caCert:       opts.caCert,
+		standIns:     make(map[string]bool),
+		placeholders: make(map[string]bool),
+	}
+
+	if result.dir == "" {
+		result.dir = defaultCacheDir()
+	}
+
+	if result.timeout <= 0 {
+		result.timeout = DefaultTimeout
+	}
+
+	err := result.createOverlay(filepath.Join(rootDir, taskCacheDir))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uri := range slices.Sorted(maps.Keys(opts.Mappings)) {
+		path := opts.Mappings[uri]
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			result.close()
+
+			return nil, eris.Wrapf(err, "failed to read local file %s for remote include %s", path, uri)
+		}
+
+		err = result.addStandIn(uri, content)
+		if err != nil {
+			result.close()
+
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// defaultCacheDir returns the default location of task-graph's cache, or nothing if the user
+// has no cache directory.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "task-graph")
+}
+
+// readerOptions returns the options configuring a go-task reader to use this cache.
+func (c *remoteCache) readerOptions() []taskfile.ReaderOption {
+	result := []taskfile.ReaderOption{
+		taskfile.WithTempDir(c.overlay),
+		taskfile.WithOffline(!c.download),
+		taskfile.WithTrustedHosts(c.trustedHosts),
+		taskfile.WithPromptFunc(func(string) error {
+			return errNotTrusted
+		}),
+	}
+
+	if c.caCert != "" {
+		result = append(result, taskfile.WithReaderCACert(c.caCert))
+	}
+
+	return result
+}
+
+// context returns the context for reading, limited by the timeout if downloading is allowed.
+func (c *remoteCache) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if !c.download {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// hasStandIn returns true if the remote Taskfile at uri has already been replaced by a stand-in.
+func (c *remoteCache) hasStandIn(uri string) bool {
+	node, err := remoteNode(uri)
+	if err != nil {
+		return false
+	}
+
+	return c.standIns[node.CacheKey()]
+}
+
+// addPlaceholder adds a stand-in for the remote Taskfile at uri that holds just a PlaceholderTask.
+func (c *remoteCache) addPlaceholder(uri string) error {
+	content, err := yaml.Marshal(map[string]any{
+		"version": "3",
+		"tasks": map[string]any{
+			PlaceholderTask: map[string]string{
+				"desc": "Remote Taskfile could not be loaded: " + uri,
+			},
+		},
+	})
+	if err != nil {
+		return eris.Wrapf(err, "failed to create placeholder for remote include %s", uri)
+	}
+
+	c.placeholders[uri] = true
+
+	return c.addStandIn(uri, content)
+}
+
+// addStandIn writes content to the overlay as the cached copy of the remote Taskfile at uri.
+func (c *remoteCache) addStandIn(uri string, content []byte) error {
+	node, err := remoteNode(uri)
+	if err != nil {
+		return err
+	}
+
+	cache := taskfile.NewCacheNode(node, c.overlay)
+
+	err = cache.Write(content)
+	if err == nil {
+		err = cache.WriteTimestamp(standInTimestamp)
+	}
+
+	if err != nil {
+		return eris.Wrapf(err, "failed to cache stand-in for remote include %s", uri)
+	}
+
+	c.standIns[node.CacheKey()] = true
+
+	return nil
+}
+
+// createOverlay creates the overlay, seeded with task's cache and then task-graph's own.
+func (c *remoteCache) createOverlay(taskDir string) error {
+	overlay, err := os.MkdirTemp("", "task-graph-remote-")
+	if err != nil {
+		return eris.Wrap(err, "failed to create directory for remote includes")
+	}
+
+	c.overlay = overlay
+	target := filepath.Join(c.overlay, remoteSubdir)
+
+	err = copyCacheFiles(filepath.Join(taskDir, remoteSubdir), target, func(string) bool { return true })
+	if err == nil && c.dir != "" {
+		err = copyCacheFiles(filepath.Join(c.dir, remoteSubdir), target, func(string) bool { return true })
+	}
+
+	if err != nil {
+		c.close()
+	}
+
+	return err
+}
+
+// save copies any remote Taskfiles downloaded into the overlay to task-graph's cache. Checksums
+// are left behind, so that only task records which Taskfiles have been trusted.
+func (c *remoteCache) save() error {
+	if !c.download || c.dir == "" {
+		return nil
+	}
+
+	return copyCacheFiles(
+		filepath.Join(c.overlay, remoteSubdir),
+		filepath.Join(c.dir, remoteSubdir),
+		func(name string) bool {
+			// Cache files are named for their key, with an extension giving their content
+			ext := filepath.Ext(name)
+			key := strings.TrimSuffix(name, ext)
+
+			return ext != checksumExt && !c.standIns[key]
+		})
+}
+
+// close removes the overlay, if any.
+func (c *remoteCache) close() {
+	if c.overlay != "" {
+		_ = os.RemoveAll(c.overlay)
+	}
+}
+
+// remoteNode returns the go-task node for the remote Taskfile at uri.
+func remoteNode(uri string) (taskfile.RemoteNode, error) {
+	node, err := taskfile.NewNode(uri, "", true)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid remote include: %s", uri)
+	}
+
+	remote, ok := node.(taskfile.RemoteNode)
+	if !ok {
+		return nil, eris.Errorf("not a remote include: %s", uri)
+	}
+
+	return remote, nil
+}
+
+// copyCacheFiles copies the files in from that are accepted by include into the directory to.
+// A missing source directory has nothing to copy.
+func copyCacheFiles(from string, to string, include func(name string) bool) error {
+	entries, err := os.ReadDir(from)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return eris.Wrapf(err, "failed to read remote cache: %s", from)
+	}
+
+	err = os.MkdirAll(to, 0o750)
+	if err != nil {
+		return eris.Wrapf(err, "failed to create remote cache: %s", to)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !include(entry.Name()) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(from, entry.Name()))
+		if err != nil {
+			return eris.Wrapf(err, "failed to read cached file: %s", entry.Name())
+		}
+
+		err = os.WriteFile(filepath.Join(to, entry.Name()), content, 0o600)
+		if err != nil {
+			return eris.Wrapf(err, "failed to write cached file: %s", entry.Name())
+		}
+	}
+
+	return nil
+}
+
+// unreachableRemote returns the URI of the remote Taskfile that err reports could not be
+// downloaded, found in the cache, or trusted, if any.
+func unreachableRemote(err error) (string, bool) {
+	var (
+		cacheErr   *taskerrors.TaskfileCacheNotFoundError
+		fetchErr   taskerrors.TaskfileFetchFailedError
+		timeoutErr *taskerrors.TaskfileNetworkTimeoutError
+		trustErr   *taskerrors.TaskfileNotTrustedError
+	)
+
+	switch {
+	case errors.As(err, &cacheErr):
+		return cacheErr.URI, true
+	case errors.As(err, &trustErr):
+		return trustErr.URI, true
+	case errors.As(err, &fetchErr):
+		return fetchErr.URI, true
+	case errors.As(err, &timeoutErr):
+		return timeoutErr.URI, true
+	default:
+		return "", false
+	}
+}
+
+// explainUntrusted adds advice on how to trust a remote Taskfile to an error refusing one,
+// returning any other error unchanged.
+func explainUntrusted(err error) error {
+	var trustErr *taskerrors.TaskfileNotTrustedError
+	if !errors.As(err, &trustErr) {
+		return err
+	}
+
+	return eris.Wrapf(
+		err,
+		"run task to review %s, or add its host to remote.trustedHosts",
+		trustErr.URI)
+}
@@ -0,0 +1,301 @@
+package loader
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/go-task/task/v3/taskfile"
+)
+
+const sharedURL = "https://example.com/shared/Taskfile.yml"
+
+// writeRemoteInclude creates a Taskfile in a new directory that includes the shared Taskfile
+// from url, returning its path.
+func writeRemoteInclude(t *testing.T, url string) string {
+	t.Helper()
+
+	content := "version: '3'\n" +
+		"includes:\n" +
+		"  shared: " + url + "\n" +
+		"tasks:\n" +
+		"  build:\n" +
+		"    deps: [shared:lint]\n"
+
+	path := filepath.Join(t.TempDir(), "Taskfile.yml")
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// writeCache caches content as the remote Taskfile at uri in dir, as task itself would.
+func writeCache(t *testing.T, dir string, uri string, content string) {
+	t.Helper()
+
+	restore := enableRemoteTaskfiles()
+	node, err := remoteNode(uri)
+
+	restore()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = taskfile.NewCacheNode(node, dir).Write([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_UsesCachedRemoteInclude(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cacheDir := t.TempDir()
+	writeCache(t, cacheDir, sharedURL, "version: '3'\ntasks:\n  lint: {}\n  test: {}\n")
+
+	// Act
+	tf, _, err := Load(t.Context(), writeRemoteInclude(t, sharedURL), Options{CacheDir: cacheDir})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Keys(nil)).To(ConsistOf("build", "shared:lint", "shared:test"))
+}
+
+func TestLoad_UsesRemoteIncludeCachedByTask(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	path := writeRemoteInclude(t, sharedURL)
+	writeCache(t, filepath.Join(filepath.Dir(path), taskCacheDir), sharedURL, sharedContent)
+
+	// Act
+	tf, _, err := Load(t.Context(), path, Options{CacheDir: t.TempDir()})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Keys(nil)).To(ConsistOf("build", "shared:lint"))
+}
+
+func TestLoad_Offline_MissingCache_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, _, err := Load(t.Context(), writeRemoteInclude(t, sharedURL), Options{CacheDir: t.TempDir(), Offline: true})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(sharedURL)))
+}
+
+func TestLoad_Mapping_UsesLocalFile(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	local := filepath.Join(t.TempDir(), "shared.yml")
+	g.Expect(os.WriteFile(local, []byte("version: '3'\ntasks:\n  lint: {}\n"), 0o600)).To(Succeed())
+
+	cacheDir := t.TempDir()
+	opts := Options{
+		CacheDir: cacheDir,
+		Offline:  true,
+		Mappings: map[string]string{sharedURL: local},
+	}
+
+	// Act
+	tf, _, err := Load(t.Context(), writeRemoteInclude(t, sharedURL), opts)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Keys(nil)).To(ConsistOf("build", "shared:lint"))
+	g.Expect(filepath.Join(cacheDir, remoteSubdir)).NotTo(BeADirectory())
+}
+
+func TestLoad_Placeholders_StandInForUnreachableInclude(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	cacheDir := t.TempDir()
+	opts := Options{
+		CacheDir:     cacheDir,
+		Offline:      true,
+		Placeholders: true,
+	}
+
+	// Act
+	tf, files, err := Load(t.Context(), writeRemoteInclude(t, sharedURL), opts)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Keys(nil)).To(ConsistOf("build", "shared:"+PlaceholderTask))
+	g.Expect(files).To(ContainElement(HaveField("Placeholder", BeTrue())))
+	g.Expect(files[0].Placeholder).To(BeFalse())
+
+	placeholder, ok := tf.Tasks.Get("shared:" + PlaceholderTask)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(placeholder.Desc).To(ContainSubstring(sharedURL))
+
+	// Placeholders must not be left in the cache, where they would hide the real Taskfile later
+	g.Expect(filepath.Join(cacheDir, remoteSubdir)).NotTo(BeADirectory())
+}
+
+const sharedContent = "version: '3'\ntasks:\n  lint: {}\n"
+
+// serveRemoteInclude starts a TLS server for the shared Taskfile, returning options trusting its
+// certificate and the URL of the Taskfile.
+func serveRemoteInclude(t *testing.T, handler http.HandlerFunc) (Options, string) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+
+	err := os.WriteFile(caCert, pem.EncodeToMemory(block), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		CacheDir: t.TempDir(),
+		caCert:   caCert,
+	}
+
+	return opts, server.URL + "/shared/Taskfile.yml"
+}
+
+// serveContent is a handler serving the shared Taskfile.
+func serveContent(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte(sharedContent))
+}
+
+func TestLoad_WithoutDownload_DoesNotFetch(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	fetched := false
+	opts, uri := serveRemoteInclude(t, func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+
+		serveContent(w, r)
+	})
+
+	// Act
+	_, _, err := Load(t.Context(), writeRemoteInclude(t, uri), opts)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring(uri)))
+	g.Expect(fetched).To(BeFalse())
+}
+
+func TestLoad_DownloadOffline_DoesNotFetch(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	fetched := false
+	opts, uri := serveRemoteInclude(t, func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+
+		serveContent(w, r)
+	})
+
+	opts.Download = true
+	opts.Offline = true
+
+	// Act
+	_, _, err := Load(t.Context(), writeRemoteInclude(t, uri), opts)
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(fetched).To(BeFalse())
+}
+
+func TestLoad_Download_TrustedHost_CachesPrivately(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	opts, uri := serveRemoteInclude(t, serveContent)
+	parsed, err := url.Parse(uri)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	opts.Download = true
+	opts.TrustedHosts = []string{parsed.Host}
+	path := writeRemoteInclude(t, uri)
+
+	// Act
+	tf, _, err := Load(t.Context(), path, opts)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tf.Tasks.Keys(nil)).To(ConsistOf("build", "shared:lint"))
+
+	// The download is kept in our cache, without a checksum that would mark it as trusted by task
+	entries, err := os.ReadDir(filepath.Join(opts.CacheDir, remoteSubdir))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries).NotTo(ContainElement(HaveField("Name()", HaveSuffix(checksumExt))))
+
+	// Task's own cache is left alone
+	g.Expect(filepath.Join(filepath.Dir(path), taskCacheDir)).NotTo(BeADirectory())
+}
+
+func TestLoad_Download_UntrustedHost_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	opts, uri := serveRemoteInclude(t, serveContent)
+	opts.Download = true
+
+	// Act
+	_, _, err := Load(t.Context(), writeRemoteInclude(t, uri), opts)
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("remote.trustedHosts")))
+	g.Expect(filepath.Join(opts.CacheDir, remoteSubdir)).NotTo(BeADirectory())
+}
+
+func TestLoad_Download_SlowHost_TimesOut(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	opts, uri := serveRemoteInclude(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+
+		serveContent(w, r)
+	})
+
+	opts.Download = true
+	opts.Timeout = 100 * time.Millisecond
+
+	// Act
+	start := time.Now()
+	_, _, err := Load(t.Context(), writeRemoteInclude(t, uri), opts)
+
+	// Assert
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+}
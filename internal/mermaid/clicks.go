@@ -0,0 +1,56 @@
+package mermaid
+
+import (
+	"strings"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/links"
+	"github.com/theunrepentantgeek/task-graph/internal/safe"
+)
+
+// writeClicksTo writes a click directive linking each task node to its definition, with its
+// tooltip if enabled. Mermaid only shows tooltips on nodes that can be clicked, so nodes without
+// a link get no tooltip.
+func writeClicksTo(
+	root *indentwriter.Line,
+	nodes []*graph.Node,
+	cfg *config.Config,
+	reg *safe.Registry,
+) error {
+	if cfg == nil || cfg.Links == nil || cfg.Links.URL == "" {
+		return nil
+	}
+
+	for _, node := range nodes {
+		url, err := links.URL(cfg.Links.URL, node)
+		if err != nil {
+			return err
+		}
+
+		if url == "" {
+			continue
+		}
+
+		click := []string{"click", reg.ID(node.ID()), "href", quote(url)}
+
+		if cfg.Links.Tooltips {
+			tooltip := strings.ReplaceAll(links.Tooltip(node), "\n", "; ")
+			click = append(click, quote(tooltip))
+		}
+
+		if cfg.Links.Target != "" {
+			click = append(click, cfg.Links.Target)
+		}
+
+		root.Add(strings.Join(click, " "))
+	}
+
+	return nil
+}
+
+// quote returns text as a quoted Mermaid string.
+func quote(text string) string {
+	return `"` + safe.Label(text) + `"`
+}
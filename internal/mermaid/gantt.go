@@ -0,0 +1,307 @@
+package mermaid
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/namespace"
+)
+
+// defaultGanttDuration is used for tasks without a measured duration.
+const defaultGanttDuration = time.Second
+
+// rootSection is the Gantt section used for tasks that are not in a namespace.
+const rootSection = "(root)"
+
+// WriteGanttTo writes a Mermaid Gantt chart of the simulated execution of the given task to
+// the given writer.
+//
+// Execution follows the rules task uses: the dependencies of a task all start together and run
+// in parallel; once they have all finished, the task runs its own commands, followed by each
+// task it calls, in order. A task marked `run: once` runs only the first time it is needed;
+// later uses wait for that run to finish. Each bar is the task's own time (see durations.Apply),
+// so a caller's bar doesn't include the tasks it calls. Tasks without a measured duration are
+// shown as taking one second. Bars on the chain that determines when the target finishes are
+// marked critical.
+func WriteGanttTo(
+	w io.Writer,
+	g *graph.Graph,
+	target string,
+) error {
+	const indent = "  "
+
+	if g == nil {
+		return errors.New("mermaid: graph is nil")
+	}
+
+	node, ok := g.Node(target)
+	if !ok {
+		return eris.Errorf("target task %q not found", target)
+	}
+
+	sim := newGanttSimulation()
+	_, last := sim.run(node, 0, nil)
+	sim.markCritical(last)
+
+	iw := indentwriter.New()
+	root := iw.Add("gantt")
+	root.Addf("title %s", ganttText(target))
+	root.Add("dateFormat x")
+	root.Add(ganttAxisFormat(sim.end()))
+
+	for _, section := range sim.sections() {
+		sec := root.Addf("section %s", ganttText(section.name))
+		for _, bar := range section.bars {
+			sec.Add(bar.String())
+		}
+	}
+
+	_, err := iw.WriteTo(w, indent)
+	if err != nil {
+		return eris.Wrap(err, "failed to write mermaid output")
+	}
+
+	return nil
+}
+
+// ganttBar is a single run of a task in the simulated execution.
+type ganttBar struct {
+	id    string
+	node  *graph.Node
+	label string
+	start time.Duration
+	end   time.Duration
+	// after is the bar whose end determined when this bar could start, if any
+	after    *ganttBar
+	critical bool
+}
+
+// String returns the Mermaid definition of the bar.
+func (b *ganttBar) String() string {
+	tags := b.id
+	if b.critical {
+		tags = "crit, " + tags
+	}
+
+	return fmt.Sprintf(
+		"%s :%s, %d, %d",
+		ganttText(b.label),
+		tags,
+		b.start.Milliseconds(),
+		b.end.Milliseconds())
+}
+
+// ganttRun records the outcome of running a task: when it finished, and the bar that finished last.
+type ganttRun struct {
+	end  time.Duration
+	last *ganttBar
+}
+
+// ganttSimulation schedules task runs following task's execution rules.
+type ganttSimulation struct {
+	bars     []*ganttBar
+	runs     map[*graph.Node]int
+	once     map[*graph.Node]ganttRun
+	visiting map[*graph.Node]bool
+}
+
+func newGanttSimulation() *ganttSimulation {
+	return &ganttSimulation{
+		runs:     make(map[*graph.Node]int),
+		once:     make(map[*graph.Node]ganttRun),
+		visiting: make(map[*graph.Node]bool),
+	}
+}
+
+// run schedules node to start at the given time, after prev, returning when it finishes
+// and the bar that finished last.
+func (s *ganttSimulation) run(
+	node *graph.Node,
+	start time.Duration,
+	prev *ganttBar,
+) (time.Duration, *ganttBar) {
+	if r, ok := s.once[node]; ok {
+		// Already run (or running); wait for it to finish
+		return r.end, r.last
+	}
+
+	if s.visiting[node] {
+		// Ignore cycles
+		return start, prev
+	}
+
+	s.visiting[node] = true
+	defer delete(s.visiting, node)
+
+	var deps, calls []*graph.Node
+
+	for _, edge := range node.Edges() {
+		switch edge.Class() {
+		case graph.EdgeClassDep:
+			deps = append(deps, edge.To())
+		case graph.EdgeClassCall:
+			calls = append(calls, edge.To())
+		}
+	}
+
+	ready, gate := start, prev
+
+	for _, dep := range deps {
+		end, last := s.run(dep, start, prev)
+		if end > ready {
+			ready, gate = end, last
+		}
+	}
+
+	bar := s.addBar(node, ready, gate)
+	end, last := bar.end, bar
+
+	if node.RunOnce {
+		// Mark as running before following calls, so that it can't be run again
+		s.once[node] = ganttRun{end: end, last: last}
+	}
+
+	for _, call := range calls {
+		callEnd, callLast := s.run(call, end, last)
+		if callEnd > end {
+			end, last = callEnd, callLast
+		}
+	}
+
+	if node.RunOnce {
+		s.once[node] = ganttRun{end: end, last: last}
+	}
+
+	return end, last
+}
+
+// addBar records a run of node starting at the given time.
+func (s *ganttSimulation) addBar(node *graph.Node, start time.Duration, after *ganttBar) *ganttBar {
+	s.runs[node]++
+
+	duration := node.Duration
+	if duration <= 0 {
+		duration = defaultGanttDuration
+	}
+
+	label := node.Label
+	if label == "" {
+		label = localName(node.ID())
+	}
+
+	if s.runs[node] > 1 {
+		label = fmt.Sprintf("%s (run %d)", label, s.runs[node])
+	}
+
+	bar := &ganttBar{
+		id:    fmt.Sprintf("t%d", len(s.bars)+1),
+		node:  node,
+		label: label,
+		start: start,
+		end:   start + duration,
+		after: after,
+	}
+
+	s.bars = append(s.bars, bar)
+
+	return bar
+}
+
+// markCritical marks the chain of bars ending with last as critical.
+func (*ganttSimulation) markCritical(last *ganttBar) {
+	for bar := last; bar != nil; bar = bar.after {
+		bar.critical = true
+	}
+}
+
+// end returns the time at which the last bar finishes.
+func (s *ganttSimulation) end() time.Duration {
+	var result time.Duration
+	for _, bar := range s.bars {
+		result = max(result, bar.end)
+	}
+
+	return result
+}
+
+// ganttSection is a group of bars for tasks in the same namespace.
+type ganttSection struct {
+	name string
+	bars []*ganttBar
+}
+
+// sections groups bars by namespace, ordered by when each section starts, with bars in each
+// section ordered by start time.
+func (s *ganttSimulation) sections() []ganttSection {
+	index := make(map[string]int)
+
+	var result []ganttSection
+
+	for _, bar := range s.bars {
+		name := namespace.Namespace(bar.node.ID())
+		if name == "" {
+			name = rootSection
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(result)
+			index[name] = i
+			result = append(result, ganttSection{name: name})
+		}
+
+		result[i].bars = append(result[i].bars, bar)
+	}
+
+	for _, section := range result {
+		slices.SortStableFunc(section.bars, compareBars)
+	}
+
+	slices.SortStableFunc(result, func(left, right ganttSection) int {
+		return cmp.Or(
+			compareBars(left.bars[0], right.bars[0]),
+			cmp.Compare(left.name, right.name))
+	})
+
+	return result
+}
+
+func compareBars(left, right *ganttBar) int {
+	return cmp.Compare(left.start, right.start)
+}
+
+// ganttAxisFormat returns the axis format directive suited to the overall length of the chart.
+func ganttAxisFormat(total time.Duration) string {
+	if total >= time.Hour {
+		return "axisFormat %H:%M:%S"
+	}
+
+	return "axisFormat %M:%S"
+}
+
+// localName returns the part of a task name after its namespace, as the namespace is already
+// shown by the section.
+func localName(id string) string {
+	ns := namespace.Namespace(id)
+	if ns == "" {
+		return id
+	}
+
+	// Skip the delimiter following the namespace
+	return id[len(ns)+1:]
+}
+
+// ganttText makes text safe for use in a Gantt chart, where colons and semicolons separate
+// fields and # starts a comment.
+func ganttText(s string) string {
+	return strings.NewReplacer(":", " ", ";", " ", "#", " ").Replace(s)
+}
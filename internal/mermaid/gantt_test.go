@@ -0,0 +1,111 @@
+package mermaid
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// buildReleaseGraph creates a graph where release depends on build and lint, then calls
+// docs:package and docs:publish; build and lint both depend on generate.
+func buildReleaseGraph(t *testing.T, generateRunsOnce bool) *graph.Graph {
+	t.Helper()
+
+	gr := graph.New()
+	release := gr.AddNode("release")
+	build := gr.AddNode("build")
+	lint := gr.AddNode("lint")
+	generate := gr.AddNode("generate")
+	pkg := gr.AddNode("docs:package")
+	publish := gr.AddNode("docs:publish")
+
+	release.AddEdge(build).SetClass(graph.EdgeClassDep)
+	release.AddEdge(lint).SetClass(graph.EdgeClassDep)
+	release.AddEdge(pkg).SetClass(graph.EdgeClassCall)
+	release.AddEdge(publish).SetClass(graph.EdgeClassCall)
+	build.AddEdge(generate).SetClass(graph.EdgeClassDep)
+	lint.AddEdge(generate).SetClass(graph.EdgeClassDep)
+
+	generate.RunOnce = generateRunsOnce
+	build.Duration = 5 * time.Second
+	lint.Duration = 2 * time.Second
+
+	return gr
+}
+
+func TestWriteGanttTo_SimulatesExecution(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"gantt_release_run_once":   true,
+		"gantt_release_run_always": false,
+	}
+
+	for name, runOnce := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Arrange
+			var buf bytes.Buffer
+
+			// Act
+			err := WriteGanttTo(&buf, buildReleaseGraph(t, runOnce), "release")
+
+			// Assert
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gg := goldie.New(t)
+			gg.Assert(t, name, buf.Bytes())
+		})
+	}
+}
+
+func TestWriteGanttTo_RunOnce_RunsTaskOnce(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteGanttTo(&buf, buildReleaseGraph(t, true), "release")
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("generate :"))
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("generate (run 2)"))
+}
+
+func TestWriteGanttTo_UnknownTarget_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteGanttTo(&buf, graph.New(), "missing")
+
+	// Assert
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("missing")))
+}
+
+func TestWriteGanttTo_NilGraph_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	var buf bytes.Buffer
+
+	// Act
+	err := WriteGanttTo(&buf, nil, "release")
+
+	// Assert
+	g.Expect(err).To(gomega.HaveOccurred())
+}
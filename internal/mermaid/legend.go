@@ -0,0 +1,98 @@
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/safe"
+)
+
+// legendName is used to derive the names of all legend elements before they are made safe,
+// keeping them distinct from the IDs of tasks and namespaces.
+const (
+	legendName   = "#legend"
+	legendPrefix = legendName + ":"
+)
+
+// legendEdgeClasses lists the edge classes shown in the legend, in display order.
+var legendEdgeClasses = []struct {
+	class string
+	label string
+}{
+	{class: graph.EdgeClassDep, label: "dependency"},
+	{class: graph.EdgeClassCall, label: "call"},
+	{class: graph.EdgeClassVar, label: "variable reference"},
+}
+
+// writeLegendTo writes a subgraph explaining the node shapes, node colours and edge styles
+// used in the flowchart. Variable entries are only included when the graph contains variables.
+// The legend must be written after all graph edges so that it doesn't disturb link indices.
+func writeLegendTo(
+	root *indentwriter.Line,
+	cfg *config.Config,
+	includeVariables bool,
+	reg *safe.Registry,
+) {
+	sg := root.Addf("subgraph %s[\"Legend\"]", reg.IDWithPrefix("sg_", legendName))
+
+	var styles []string
+
+	taskID := reg.IDWithPrefix(legendPrefix, "task")
+	sg.Add(taskNode(cfg, taskID, "task"))
+
+	if m := mermaidConfig(cfg); m != nil && m.TaskNodes != nil {
+		if css := nodeCSS(m.TaskNodes.Fill, m.TaskNodes.Stroke, m.TaskNodes.Color, m.TaskNodes.Style); css != "" {
+			styles = append(styles, fmt.Sprintf("style %s %s", taskID, css))
+		}
+	}
+
+	if includeVariables {
+		id := reg.IDWithPrefix(legendPrefix, "variable")
+		sg.Addf("%s(\"variable\")", id)
+		styles = append(styles, fmt.Sprintf("style %s %s", id, strings.Join(variableClassDefParts(cfg), ",")))
+	}
+
+	for _, rule := range cfg.NodeStyleRules {
+		if rule.Name == "" {
+			continue
+		}
+
+		id := reg.IDWithPrefix(legendPrefix, "rule:"+rule.Key())
+		sg.Addf("%s[\"%s\"]", id, safe.Label(legendRuleLabel(rule)))
+
+		if classDef := buildClassDef(rule); classDef != "" {
+			styles = append(styles, fmt.Sprintf("style %s %s", id, classDef))
+		}
+	}
+
+	for _, entry := range legendEdgeClasses {
+		if entry.class == graph.EdgeClassVar && !includeVariables {
+			continue
+		}
+
+		sg.Addf(
+			"%s[\" \"] %s|\"%s\"| %s[\" \"]",
+			reg.IDWithPrefix(legendPrefix+"edge:", entry.class),
+			edgeConnector(entry.class, cfg),
+			entry.label,
+			reg.IDWithPrefix(legendPrefix+"edge-end:", entry.class))
+	}
+
+	root.Add("end")
+
+	for _, style := range styles {
+		root.Add(style)
+	}
+}
+
+// legendRuleLabel returns the text shown in the legend for a named rule.
+func legendRuleLabel(rule config.NodeStyleRule) string {
+	if rule.Description != "" {
+		return rule.Name + ": " + rule.Description
+	}
+
+	return rule.Name
+}
@@ -0,0 +1,124 @@
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/edgestyle"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+)
+
+// linkStyles tracks the index of each edge (link) as it is written to the flowchart.
+// Mermaid identifies links only by their position in the output, so linkStyle directives
+// can only be generated once we know the order in which edges were written.
+type linkStyles struct {
+	styles     map[*graph.Edge]edgestyle.Style
+	cfg        *config.Config
+	next       int
+	directives []string
+}
+
+// newLinkStyles resolves the EdgeStyleRules for every outgoing edge of the given nodes.
+// Resolving up front means any invalid patterns are reported before output begins.
+func newLinkStyles(
+	nodes []*graph.Node,
+	cfg *config.Config,
+) (*linkStyles, error) {
+	result := &linkStyles{
+		styles: make(map[*graph.Edge]edgestyle.Style),
+		cfg:    cfg,
+	}
+
+	if cfg == nil || len(cfg.EdgeStyleRules) == 0 {
+		return result, nil
+	}
+
+	for _, node := range nodes {
+		for _, edge := range node.Edges() {
+			style, err := edgestyle.Resolve(cfg.EdgeStyleRules, edge)
+			if err != nil {
+				return nil, err
+			}
+
+			if !style.IsEmpty() {
+				result.styles[edge] = style
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// add records edge as the next link written and returns the label to show for it,
+// which is the label from any matching rule, or the edge's own label otherwise.
+// The style of any matching rule is written after that of the class of the edge, so that it
+// takes precedence.
+func (l *linkStyles) add(edge *graph.Edge) string {
+	index := l.next
+	l.next++
+
+	style, ok := l.styles[edge]
+
+	var parts []string
+	if css := edgeClassCSS(l.cfg, edge.Class()); css != "" {
+		parts = append(parts, css)
+	}
+
+	if css := linkStyleCSS(style); ok && css != "" {
+		parts = append(parts, css)
+	}
+
+	if len(parts) > 0 {
+		l.directives = append(l.directives, fmt.Sprintf("linkStyle %d %s", index, strings.Join(parts, ",")))
+	}
+
+	if !ok {
+		return edge.Label()
+	}
+
+	if style.Label != "" {
+		return style.Label
+	}
+
+	return edge.Label()
+}
+
+// writeTo writes the accumulated linkStyle directives.
+func (l *linkStyles) writeTo(root *indentwriter.Line) {
+	for _, directive := range l.directives {
+		root.Add(directive)
+	}
+}
+
+// linkStyleCSS constructs a Mermaid linkStyle value string from an edge style.
+// Returns empty string if no visual properties are set.
+func linkStyleCSS(style edgestyle.Style) string {
+	var parts []string
+
+	if style.Color != "" {
+		parts = append(parts, "stroke:"+style.Color)
+	}
+
+	width := style.Width
+
+	switch style.Style {
+	case "dashed":
+		parts = append(parts, "stroke-dasharray:5 5")
+	case "dotted":
+		parts = append(parts, "stroke-dasharray:2 2")
+	case "solid":
+		parts = append(parts, "stroke-dasharray:0")
+	case "bold":
+		width = max(width, 3)
+	default:
+		// Other Graphviz styles have no CSS equivalent
+	}
+
+	if width > 0 {
+		parts = append(parts, fmt.Sprintf("stroke-width:%dpx", width))
+	}
+
+	return strings.Join(parts, ",")
+}
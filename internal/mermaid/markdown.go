@@ -0,0 +1,314 @@
+package mermaid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/graphns"
+)
+
+// overviewTitle is the title of the overview diagram of a split flowchart.
+const overviewTitle = "Overview"
+
+// Report describes the Markdown written by WriteMarkdownTo.
+type Report struct {
+	// Diagrams lists the titles of the diagrams written, in order; there are several only if the
+	// flowchart was split.
+	Diagrams []string
+
+	// Oversized lists the diagrams written that still exceed the limits of the config.
+	Oversized []Diagram
+}
+
+// Diagram describes the size of one Mermaid diagram.
+type Diagram struct {
+	// Title is the title of the diagram, or empty for a flowchart that wasn't split.
+	Title string
+
+	// Nodes is the number of nodes in the diagram.
+	Nodes int
+
+	// Edges is the number of edges in the diagram.
+	Edges int
+
+	// TextSize is the number of characters in the diagram.
+	TextSize int
+}
+
+// diagram is a Mermaid diagram to write, with its size.
+type diagram struct {
+	Diagram
+	source []byte
+}
+
+// WriteMarkdownTo writes the Mermaid flowchart of the graph as Markdown ready to paste into
+// GitHub or GitLab, in a fenced mermaid block. A flowchart exceeding the limits of the config is
+// split into an overview, with each top-level namespace collapsed to a single node, followed by
+// a diagram for each top-level namespace, each under its own heading.
+func WriteMarkdownTo(
+	w io.Writer,
+	g *graph.Graph,
+	cfg *config.Config,
+) (*Report, error) {
+	if g == nil {
+		return nil, errors.New("mermaid: graph is nil")
+	}
+
+	whole, err := newDiagram("", g, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	diagrams := []*diagram{whole}
+	if exceedsLimits(whole.Diagram, cfg) && len(topLevelNamespaces(g)) > 0 {
+		diagrams, err = splitDiagrams(g, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{}
+
+	var buf bytes.Buffer
+
+	for i, d := range diagrams {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		if d.Title != "" {
+			fmt.Fprintf(&buf, "## %s\n\n", d.Title)
+		}
+
+		writeFenced(&buf, d.source)
+
+		report.Diagrams = append(report.Diagrams, d.Title)
+		if exceedsLimits(d.Diagram, cfg) {
+			report.Oversized = append(report.Oversized, d.Diagram)
+		}
+	}
+
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to write mermaid output")
+	}
+
+	return report, nil
+}
+
+// WriteFencedTo writes Mermaid source as Markdown, in a fenced mermaid block.
+func WriteFencedTo(w io.Writer, source []byte) error {
+	var buf bytes.Buffer
+
+	writeFenced(&buf, source)
+
+	_, err := buf.WriteTo(w)
+
+	return eris.Wrap(err, "failed to write mermaid output")
+}
+
+// writeFenced adds Mermaid source to buf in a fenced mermaid block.
+func writeFenced(buf *bytes.Buffer, source []byte) {
+	buf.WriteString("```mermaid\n")
+	buf.Write(source)
+
+	if !bytes.HasSuffix(source, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("```\n")
+}
+
+// newDiagram returns the flowchart of the graph, with its size.
+func newDiagram(title string, g *graph.Graph, cfg *config.Config) (*diagram, error) {
+	var buf bytes.Buffer
+
+	err := WriteTo(&buf, g, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &diagram{
+		Diagram: Diagram{Title: title, TextSize: buf.Len()},
+		source:  buf.Bytes(),
+	}
+
+	for node := range g.Nodes() {
+		result.Nodes++
+		result.Edges += len(node.Edges())
+	}
+
+	return result, nil
+}
+
+// exceedsLimits reports whether the diagram is too big for GitHub and GitLab, according to the
+// limits of the config.
+func exceedsLimits(d Diagram, cfg *config.Config) bool {
+	m := mermaidConfig(cfg)
+	if m == nil {
+		m = &config.Mermaid{}
+	}
+
+	return d.Nodes > m.MaxNodesOrDefault() ||
+		d.Edges > m.MaxEdgesOrDefault() ||
+		d.TextSize > m.MaxTextSizeOrDefault()
+}
+
+// splitDiagrams returns the overview of the graph, followed by a diagram of each top-level
+// namespace.
+func splitDiagrams(g *graph.Graph, cfg *config.Config) ([]*diagram, error) {
+	// The overview has no namespaces left to group
+	overviewCfg := config.New()
+	if cfg != nil {
+		copied := *cfg
+		overviewCfg = &copied
+	}
+
+	overviewCfg.GroupByNamespace = false
+
+	overview, err := newDiagram(overviewTitle, overviewGraph(g), overviewCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*diagram{overview}
+
+	for _, ns := range topLevelNamespaces(g) {
+		d, err := newDiagram(ns, namespaceGraph(g, ns), cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+// topLevelNamespace returns the top-level namespace of a task node, or "" if it has none.
+// Variables belong to no namespace.
+func topLevelNamespace(node *graph.Node) string {
+	if node.Kind == graph.NodeKindVariable {
+		return ""
+	}
+
+	ns, _, found := strings.Cut(node.ID(), ":")
+	if !found {
+		return ""
+	}
+
+	return ns
+}
+
+// topLevelNamespaces returns the top-level namespaces of the tasks of the graph, sorted.
+func topLevelNamespaces(g *graph.Graph) []string {
+	var result []string
+
+	for node := range g.Nodes() {
+		if ns := topLevelNamespace(node); ns != "" && !slices.Contains(result, ns) {
+			result = append(result, ns)
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// overviewID returns the ID of the node standing for the given node in the overview: the node
+// collapsing its top-level namespace, or the node itself if it has none.
+func overviewID(node *graph.Node) string {
+	if ns := topLevelNamespace(node); ns != "" {
+		return ns + ":"
+	}
+
+	return node.ID()
+}
+
+// overviewGraph returns the graph with each top-level namespace collapsed to a single node,
+// keeping a single edge of each class between any two nodes.
+func overviewGraph(g *graph.Graph) *graph.Graph {
+	keep := make(map[string]bool)
+	counts := make(map[string]int)
+
+	for node := range g.Nodes() {
+		if ns := topLevelNamespace(node); ns != "" {
+			counts[ns]++
+		} else {
+			keep[node.ID()] = true
+		}
+	}
+
+	// Edges between the nodes kept are copied along with them
+	result := g.FilterNodes(keep)
+
+	for ns, count := range counts {
+		collapsed := result.AddNode(ns + ":")
+		collapsed.Label = fmt.Sprintf("%s (%s)", ns, plural(count, "task"))
+	}
+
+	seen := make(map[[3]string]bool)
+
+	for _, node := range graphns.CollectSortedNodes(g) {
+		for _, edge := range node.Edges() {
+			from := overviewID(edge.From())
+			to := overviewID(edge.To())
+
+			key := [3]string{from, to, edge.Class()}
+			if from == to || (keep[from] && keep[to]) || seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			fromNode, _ := result.Node(from)
+			toNode, _ := result.Node(to)
+			fromNode.AddEdge(toNode).SetClass(edge.Class())
+		}
+	}
+
+	return result
+}
+
+// namespaceGraph returns the tasks of the graph within the given top-level namespace, with the
+// edges between them and any variables they use.
+func namespaceGraph(g *graph.Graph, ns string) *graph.Graph {
+	keep := make(map[string]bool)
+
+	for node := range g.Nodes() {
+		if topLevelNamespace(node) == ns {
+			keep[node.ID()] = true
+		}
+	}
+
+	for node := range g.Nodes() {
+		if node.Kind != graph.NodeKindVariable {
+			continue
+		}
+
+		for _, edge := range node.Edges() {
+			if keep[edge.To().ID()] {
+				keep[node.ID()] = true
+			}
+		}
+	}
+
+	return g.FilterNodes(keep)
+}
+
+// plural returns the count with the noun, made plural unless the count is one.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,163 @@
+package mermaid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestWriteMarkdownTo_WithinLimits_WritesSingleFencedDiagram(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	// Act
+	report, err := WriteMarkdownTo(&buf, gr, config.New())
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Diagrams).To(gomega.Equal([]string{""}))
+	g.Expect(report.Oversized).To(gomega.BeEmpty())
+	g.Expect(buf.String()).To(gomega.HavePrefix("```mermaid\nflowchart TD\n"))
+	g.Expect(buf.String()).To(gomega.HaveSuffix("\n```\n"))
+}
+
+func TestWriteMarkdownTo_ExceedingLimits_SplitsByNamespace(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildPlatformGraph(t)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.Mermaid.MaxNodes = 4
+
+	// Act
+	report, err := WriteMarkdownTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Diagrams).To(gomega.Equal([]string{"Overview", "docs", "go"}))
+	g.Expect(report.Oversized).To(gomega.BeEmpty())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "platform_safe_split", buf.Bytes())
+}
+
+func TestWriteMarkdownTo_DiagramStillTooBig_ReportsOversized(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildPlatformGraph(t)
+
+	cfg := config.New()
+	cfg.Mermaid.MaxEdges = 1
+
+	// Act
+	report, err := WriteMarkdownTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Diagrams).To(gomega.HaveLen(3))
+	g.Expect(report.Oversized).To(gomega.ConsistOf(
+		gomega.HaveField("Title", "Overview"),
+		gomega.HaveField("Title", "go"),
+	))
+}
+
+func TestWriteMarkdownTo_WithoutNamespaces_ReportsWholeGraph(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.Mermaid.MaxTextSize = 10
+
+	// Act
+	report, err := WriteMarkdownTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(report.Diagrams).To(gomega.Equal([]string{""}))
+	g.Expect(report.Oversized).To(gomega.ConsistOf(gomega.Equal(Diagram{
+		Nodes:    3,
+		Edges:    3,
+		TextSize: buf.Len() - len("```mermaid\n```\n"),
+	})))
+}
+
+func TestWriteMarkdownTo_NilGraph_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+
+	// Act
+	_, err := WriteMarkdownTo(&buf, nil, config.New())
+
+	// Assert
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestWriteFencedTo_WithoutFinalNewline_ClosesFenceOnItsOwnLine(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+
+	// Act
+	err := WriteFencedTo(&buf, []byte("gantt"))
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.Equal("```mermaid\ngantt\n```\n"))
+}
+
+// buildPlatformGraph returns a graph with tasks in two top-level namespaces, one of them
+// nested, along with a task outside any namespace and a variable.
+func buildPlatformGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+
+	gr := graph.New()
+
+	ci := gr.AddNode("ci")
+	goBuild := gr.AddNode("go:build")
+	goTest := gr.AddNode("go:test")
+	goLint := gr.AddNode("go:lint:golangci")
+	docsBuild := gr.AddNode("docs:build")
+	docsPublish := gr.AddNode("docs:publish")
+
+	version := gr.AddNode("var:VERSION")
+	version.Kind = graph.NodeKindVariable
+	version.Label = "VERSION"
+
+	ci.AddEdge(goTest).SetClass(graph.EdgeClassDep)
+	ci.AddEdge(goLint).SetClass(graph.EdgeClassDep)
+	ci.AddEdge(docsPublish).SetClass(graph.EdgeClassCall)
+	goTest.AddEdge(goBuild).SetClass(graph.EdgeClassDep)
+	docsPublish.AddEdge(docsBuild).SetClass(graph.EdgeClassDep)
+	docsBuild.AddEdge(goBuild).SetClass(graph.EdgeClassDep)
+	docsPublish.AddEdge(goBuild).SetClass(graph.EdgeClassDep)
+	version.AddEdge(goBuild).SetClass(graph.EdgeClassVar)
+
+	return gr
+}
@@ -2,6 +2,7 @@ package mermaid
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
@@ -60,25 +61,47 @@ func WriteTo(
 
 	taskNodes, varNodes := graphns.SplitByKind(nodes)
 
+	links, err := newLinkStyles(nodes, cfg)
+	if err != nil {
+		return err
+	}
+
 	iw := indentwriter.New()
-	root := iw.Addf("flowchart %s", flowchartDirection(cfg))
+
+	root, err := writeFlowchartTo(iw, cfg)
+	if err != nil {
+		return err
+	}
 
 	if cfg != nil && cfg.GroupByNamespace {
-		writeGroupedNodesTo(root, taskNodes, reg)
+		writeGroupedNodesTo(root, taskNodes, cfg, reg, links)
 	} else {
-		writeNodesTo(root, taskNodes, reg)
+		writeNodesTo(root, taskNodes, cfg, reg, links)
 	}
 
 	if len(varNodes) > 0 {
-		writeVariableNodesTo(root, varNodes, reg)
+		writeVariableNodesTo(root, varNodes, cfg, reg, links)
 		writeVariableClassDef(root, varNodes, cfg, reg)
 	}
 
-	err := writeStyleRulesTo(root, nodes, cfg, reg)
+	if cfg != nil && cfg.Legend {
+		writeLegendTo(root, cfg, len(varNodes) > 0, reg)
+	}
+
+	writeTaskClassDef(root, taskNodes, cfg, reg)
+
+	err = writeStyleRulesTo(root, nodes, cfg, reg)
 	if err != nil {
 		return err
 	}
 
+	err = writeClicksTo(root, taskNodes, cfg, reg)
+	if err != nil {
+		return err
+	}
+
+	links.writeTo(root)
+
 	_, err = iw.WriteTo(w, indent)
 	if err != nil {
 		return eris.Wrap(err, "failed to write mermaid output")
@@ -87,6 +110,21 @@ func WriteTo(
 	return nil
 }
 
+// writeFlowchartTo writes the init directive, if any, and the flowchart header, returning the
+// line to which the body of the flowchart is added.
+func writeFlowchartTo(iw *indentwriter.IndentWriter, cfg *config.Config) (*indentwriter.Line, error) {
+	directive, err := initDirective(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if directive != "" {
+		iw.Add(directive)
+	}
+
+	return iw.Addf("flowchart %s", flowchartDirection(cfg)), nil
+}
+
 // flowchartDirection returns the mermaid flowchart direction from config,
 // defaulting to "TD" (top-down).
 func flowchartDirection(cfg *config.Config) string {
@@ -97,11 +135,54 @@ func flowchartDirection(cfg *config.Config) string {
 	return "TD"
 }
 
+// initDirective returns the init directive setting the Mermaid theme and theme variables from
+// config, or "" if neither is set.
+func initDirective(cfg *config.Config) (string, error) {
+	data, err := ConfigJSON(cfg)
+	if err != nil || data == nil {
+		return "", err
+	}
+
+	return "%%{init: " + string(data) + "}%%", nil
+}
+
+// ConfigJSON returns the Mermaid configuration setting the theme and theme variables from
+// config, as JSON, or nil if neither is set.
+func ConfigJSON(cfg *config.Config) ([]byte, error) {
+	if cfg == nil || cfg.Mermaid == nil {
+		return nil, nil
+	}
+
+	settings := make(map[string]any)
+
+	if cfg.Mermaid.Theme != "" {
+		settings["theme"] = cfg.Mermaid.Theme
+	}
+
+	if len(cfg.Mermaid.ThemeVariables) > 0 {
+		settings["themeVariables"] = cfg.Mermaid.ThemeVariables
+	}
+
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	// Map keys are sorted when marshalled, so the configuration is stable
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to encode mermaid theme")
+	}
+
+	return data, nil
+}
+
 // writeGroupedNodesTo writes nodes organised into namespace subgraph clusters.
 func writeGroupedNodesTo(
 	root *indentwriter.Line,
 	nodes []*graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
 	nsToNodes := graphns.IndexByNamespace(nodes)
 	allNS := graphns.FindAllNamespaces(nsToNodes)
@@ -109,10 +190,10 @@ func writeGroupedNodesTo(
 	// Pre-build parent→children map so each lookup is O(1) rather than O(N).
 	childrenOf := graphns.BuildChildrenMap(allNS)
 
-	writeNodesTo(root, nsToNodes[""], reg)
+	writeNodesTo(root, nsToNodes[""], cfg, reg, links)
 
 	for _, ns := range childrenOf[""] {
-		writeNamespaceSubgraphTo(root, ns, nsToNodes, childrenOf, reg)
+		writeNamespaceSubgraphTo(root, ns, nsToNodes, childrenOf, cfg, reg, links)
 	}
 }
 
@@ -122,14 +203,18 @@ func writeNamespaceSubgraphTo(
 	ns string,
 	nsToNodes map[string][]*graph.Node,
 	childrenOf map[string][]string,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
-	sg := parent.Addf("subgraph %s[\"%s\"]", reg.IDWithPrefix("sg_", ns), ns)
+	id := reg.IDWithPrefix("sg_", ns)
+	sg := parent.Addf("subgraph %s[\"%s\"]", id, ns)
 
-	writeNodesTo(sg, nsToNodes[ns], reg)
+	writeSubgraphStyleTo(sg, id, ns, cfg)
+	writeNodesTo(sg, nsToNodes[ns], cfg, reg, links)
 
 	for _, child := range childrenOf[ns] {
-		writeNamespaceSubgraphTo(sg, child, nsToNodes, childrenOf, reg)
+		writeNamespaceSubgraphTo(sg, child, nsToNodes, childrenOf, cfg, reg, links)
 	}
 
 	parent.Add("end")
@@ -139,10 +224,12 @@ func writeNamespaceSubgraphTo(
 func writeNodesTo(
 	root *indentwriter.Line,
 	nodes []*graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
 	for _, node := range nodes {
-		writeNodeTo(root, node, reg)
+		writeNodeTo(root, node, cfg, reg, links)
 	}
 }
 
@@ -150,12 +237,14 @@ func writeNodesTo(
 func writeNodeTo(
 	root *indentwriter.Line,
 	node *graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
-	writeNodeDefinitionTo(root, node, reg)
+	writeNodeDefinitionTo(root, node, cfg, reg)
 
 	for _, edge := range node.Edges() {
-		writeEdgeTo(root, edge, reg)
+		writeEdgeTo(root, edge, cfg, reg, links)
 	}
 
 	root.Add("")
@@ -164,27 +253,61 @@ func writeNodeTo(
 func writeNodeDefinitionTo(
 	root *indentwriter.Line,
 	node *graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
 ) {
-	label := safe.Label(node.DisplayLabel())
-	root.Addf("%s[\"%s\"]", reg.ID(node.ID()), label)
+	label := safe.Label(taskDisplayLabel(node))
+
+	if m := mermaidConfig(cfg); m != nil && m.Descriptions && node.Description != "" {
+		label += "<br/>" + descriptionLabel(node.Description)
+	}
+
+	root.Add(taskNode(cfg, reg.ID(node.ID()), label))
+}
+
+// taskDisplayLabel returns the label for a task node, including its duration if known.
+func taskDisplayLabel(node *graph.Node) string {
+	if d := node.DisplayDuration(); d != "" {
+		return node.DisplayLabel() + " (" + d + ")"
+	}
+
+	return node.DisplayLabel()
+}
+
+// descriptionLabel returns a task description made safe for a label, wrapped over several lines
+// as in Graphviz output.
+func descriptionLabel(description string) string {
+	margin := min((len(description)+20)/2, 40)
+
+	lines := indentwriter.WordWrap(description, margin)
+	for i, line := range lines {
+		lines[i] = safe.Label(descriptionReplacer.Replace(strings.TrimSpace(line)))
+	}
+
+	return strings.Join(lines, "<br/>")
 }
 
+// descriptionReplacer escapes the characters of a description that Mermaid would take as HTML,
+// since its labels may contain line breaks.
+var descriptionReplacer = strings.NewReplacer(
+	"<", "&lt;",
+	">", "&gt;",
+)
+
 func writeEdgeTo(
 	root *indentwriter.Line,
 	edge *graph.Edge,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
 	from := reg.ID(edge.From().ID())
 	to := reg.ID(edge.To().ID())
 
-	connector := "-->"
-	if edge.Class() == graph.EdgeClassCall {
-		connector = "-.->"
-	}
+	connector := edgeConnector(edge.Class(), cfg)
 
-	if edge.Label() != "" {
-		label := safe.Label(edge.Label())
+	if text := links.add(edge); text != "" {
+		label := safe.Label(text)
 		root.Addf("%s %s|\"%s\"| %s", from, connector, label, to)
 	} else {
 		root.Addf("%s %s %s", from, connector, to)
@@ -202,8 +325,8 @@ func writeStyleRulesTo(
 		return nil
 	}
 
-	for i, rule := range cfg.NodeStyleRules {
-		err := writeStyleRuleTo(root, nodes, i, rule, reg)
+	for _, rule := range cfg.NodeStyleRules {
+		err := writeStyleRuleTo(root, nodes, rule, reg)
 		if err != nil {
 			return err
 		}
@@ -212,10 +335,11 @@ func writeStyleRulesTo(
 	return nil
 }
 
+// writeStyleRuleTo writes the classDef of a rule and the class directive applying it. The class is
+// named by the key of the rule, so that adding or removing rules doesn't rename others.
 func writeStyleRuleTo(
 	root *indentwriter.Line,
 	nodes []*graph.Node,
-	index int,
 	rule config.NodeStyleRule,
 	reg *safe.Registry,
 ) error {
@@ -231,8 +355,8 @@ func writeStyleRuleTo(
 
 	if len(matchingIDs) > 0 {
 		slices.Sort(matchingIDs)
-		root.Addf("classDef rule%d %s", index, classDef)
-		root.Addf("class %s rule%d", strings.Join(matchingIDs, ","), index)
+		root.Addf("classDef rule_%s %s", rule.Key(), classDef)
+		root.Addf("class %s rule_%s", strings.Join(matchingIDs, ","), rule.Key())
 	}
 
 	return nil
@@ -258,31 +382,19 @@ func findMatchingNodeIDs(nodes []*graph.Node, pattern string, reg *safe.Registry
 // buildClassDef constructs a Mermaid classDef value string from a NodeStyleRule.
 // Returns empty string if no visual properties are set.
 func buildClassDef(rule config.NodeStyleRule) string {
-	var parts []string
-
-	if rule.FillColor != "" {
-		parts = append(parts, "fill:"+rule.FillColor)
-	}
-
-	if rule.Color != "" {
-		parts = append(parts, "stroke:"+rule.Color)
-	}
-
-	if rule.FontColor != "" {
-		parts = append(parts, "color:"+rule.FontColor)
-	}
-
-	return strings.Join(parts, ",")
+	return nodeCSS(rule.FillColor, rule.Color, rule.FontColor, rule.Style)
 }
 
 func writeVariableNodesTo(
 	root *indentwriter.Line,
 	nodes []*graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
 	for _, node := range nodes {
 		writeVariableNodeDefinitionTo(root, node, reg)
-		writeVariableEdgesTo(root, node, reg)
+		writeVariableEdgesTo(root, node, cfg, reg, links)
 		root.Add("")
 	}
 }
@@ -299,14 +411,23 @@ func writeVariableNodeDefinitionTo(
 func writeVariableEdgesTo(
 	root *indentwriter.Line,
 	node *graph.Node,
+	cfg *config.Config,
 	reg *safe.Registry,
+	links *linkStyles,
 ) {
 	for _, edge := range node.Edges() {
 		// Reverse edge direction visually: write as task ==> variable
 		// so Mermaid's layout pushes variables below tasks
 		from := reg.ID(edge.To().ID())
 		to := reg.ID(edge.From().ID())
-		root.Addf("%s ==> %s", from, to)
+
+		connector := edgeConnector(graph.EdgeClassVar, cfg)
+
+		if text := links.add(edge); text != "" {
+			root.Addf("%s %s|\"%s\"| %s", from, connector, safe.Label(text), to)
+		} else {
+			root.Addf("%s %s %s", from, connector, to)
+		}
 	}
 }
 
@@ -345,21 +466,7 @@ func variableClassDefParts(cfg *config.Config) []string {
 
 	vs := cfg.Mermaid.VariableNodes
 
-	var parts []string
-
-	if vs.Fill != "" {
-		parts = append(parts, "fill:"+vs.Fill)
-	}
-
-	if vs.Stroke != "" {
-		parts = append(parts, "stroke:"+vs.Stroke)
-	}
-
-	if vs.Color != "" {
-		parts = append(parts, "color:"+vs.Color)
-	}
-
-	if len(parts) > 0 {
+	if parts := nodeCSSParts(vs.Fill, vs.Stroke, vs.Color, vs.Style); len(parts) > 0 {
 		return parts
 	}
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 	"github.com/sebdah/goldie/v2"
@@ -250,6 +251,76 @@ func TestWriteTo_WithStyleRuleWithNoProperties_SkipsClassDef(t *testing.T) {
 	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("classDef"))
 }
 
+// TestWriteTo_WithEdgeStyleRules_WritesLinkStyles verifies that edges selected by
+// EdgeStyleRules produce linkStyle directives using the index of each edge in the output.
+func TestWriteTo_WithEdgeStyleRules_WritesLinkStyles(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildNamespacedGraph(t)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{From: "cmd:*", CrossesNamespace: true, Color: "red", Width: 3, Label: "cross-namespace"},
+		{Class: graph.EdgeClassDep, To: "*golden", Style: "dotted"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "namespace_graph_with_edge_style_rules", buf.Bytes())
+}
+
+func TestWriteTo_WithVariableEdgeStyleRule_CountsVariableLinks(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildGraphWithVariables(t)
+
+	cfg := config.New()
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{From: "var:VERSION", Color: "orange"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert: build-->test is link 0, PACKAGE is link 1, VERSION is link 2
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("linkStyle 2 stroke:orange"))
+}
+
+func TestWriteTo_WithInvalidEdgeStyleRulePattern_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{To: "[unclosed", Color: "red"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("failed to compile edge match pattern")))
+}
+
 func buildSampleGraph(t *testing.T) *graph.Graph {
 	t.Helper()
 
@@ -321,3 +392,156 @@ func buildGraphWithVariables(t *testing.T) *graph.Graph {
 
 	return gr
 }
+
+func TestWriteTo_WithLegend_WritesLegend(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildGraphWithVariables(t)
+
+	cfg := config.New()
+	cfg.Legend = true
+	cfg.NodeStyleRules = []config.NodeStyleRule{
+		{Match: "build", Name: "Entry points", Description: "Tasks run by CI", FillColor: "gold"},
+		{Match: "test", FontColor: "blue"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "sample_graph_with_legend", buf.Bytes())
+}
+
+func TestWriteTo_WithLegendAndNoVariables_OmitsVariableEntries(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.Legend = true
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring("Legend"))
+	g.Expect(buf.String()).To(gomega.ContainSubstring("dependency"))
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("variable"))
+}
+
+func TestWriteTo_WithDuration_IncludesDurationInLabel(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	n := gr.AddNode("build")
+	n.Duration = 90 * time.Second
+
+	cfg := config.New()
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring(`build["build (1m30s)"]`))
+}
+
+func TestWriteTo_WithTheme_WritesInitDirective(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildSampleGraph(t)
+
+	cfg := config.New()
+	cfg.Mermaid.Theme = "base"
+	cfg.Mermaid.ThemeVariables = map[string]string{
+		"primaryColor": "#000000",
+		"fontSize":     "18px",
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.HavePrefix(
+		`%%{init: {"theme":"base","themeVariables":{"fontSize":"18px","primaryColor":"#000000"}}}%%` + "\n" +
+			"flowchart TD\n"))
+}
+
+func TestConfigJSON_WithoutTheme_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Act
+	data, err := ConfigJSON(config.New())
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(data).To(gomega.BeNil())
+}
+
+func TestConfigJSON_WithTheme_ReturnsThemeAndVariables(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	cfg := config.New()
+	cfg.Mermaid.Theme = "dark"
+	cfg.Mermaid.ThemeVariables = map[string]string{"fontFamily": "Arial"}
+
+	// Act
+	data, err := ConfigJSON(cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.Equal(`{"theme":"dark","themeVariables":{"fontFamily":"Arial"}}`))
+}
+
+func TestWriteTo_WithLinks_WritesClickDirectives(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	build := gr.AddNode("build")
+	build.File = "Taskfile.yml"
+	build.Line = 4
+	build.Description = "Build it"
+	build.Commands = []string{"go build ./..."}
+	gr.AddNode("test")
+
+	cfg := config.New()
+	cfg.Links = &config.Links{
+		URL:      "https://example.com/{{.File}}#L{{.Line}}",
+		Target:   "_blank",
+		Tooltips: true,
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring(
+		`click build href "https://example.com/Taskfile.yml#L4" "Build it; $ go build ./..." _blank`))
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("click test"))
+}
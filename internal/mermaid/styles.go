@@ -0,0 +1,235 @@
+package mermaid
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+	"github.com/theunrepentantgeek/task-graph/internal/indentwriter"
+	"github.com/theunrepentantgeek/task-graph/internal/safe"
+)
+
+// taskClass is the class given to task nodes when TaskNodes sets their style.
+const taskClass = "taskStyle"
+
+// shapes maps each task node shape to the brackets enclosing the label of a node.
+// https://mermaid.js.org/syntax/flowchart.html#node-shapes
+var shapes = map[string][2]string{
+	"rect":          {"[", "]"},
+	"rounded":       {"(", ")"},
+	"stadium":       {"([", "])"},
+	"subroutine":    {"[[", "]]"},
+	"cylinder":      {"[(", ")]"},
+	"circle":        {"((", "))"},
+	"hexagon":       {"{{", "}}"},
+	"parallelogram": {"[/", "/]"},
+	"trapezoid":     {"[/", `\]`},
+	"rhombus":       {"{", "}"},
+}
+
+// mermaidConfig returns the Mermaid settings of cfg, or nil if there are none.
+func mermaidConfig(cfg *config.Config) *config.Mermaid {
+	if cfg == nil {
+		return nil
+	}
+
+	return cfg.Mermaid
+}
+
+// taskNode returns the definition of a task node with the given ID and (safe) label, in the
+// configured shape.
+func taskNode(cfg *config.Config, id string, label string) string {
+	brackets := shapes["rect"]
+
+	if m := mermaidConfig(cfg); m != nil {
+		if b, ok := shapes[m.TaskShape]; ok {
+			brackets = b
+		}
+	}
+
+	return fmt.Sprintf("%s%s\"%s\"%s", id, brackets[0], label, brackets[1])
+}
+
+// styleCSS returns the CSS properties drawing the Graphviz-like styles, such as dashed or bold,
+// given as a comma separated list. Styles without a CSS equivalent, such as filled, are ignored.
+func styleCSS(style string) []string {
+	var result []string
+
+	for s := range strings.SplitSeq(style, ",") {
+		switch strings.TrimSpace(s) {
+		case "dashed":
+			result = append(result, "stroke-dasharray:5 5")
+		case "dotted":
+			result = append(result, "stroke-dasharray:2 2")
+		case "solid":
+			result = append(result, "stroke-dasharray:0")
+		case "bold":
+			result = append(result, "stroke-width:3px")
+		case "rounded":
+			result = append(result, "rx:6px", "ry:6px")
+		case "invis":
+			result = append(result, "opacity:0")
+		default:
+			// Other Graphviz styles have no CSS equivalent
+		}
+	}
+
+	return result
+}
+
+// nodeCSS returns the CSS properties of a node or subgraph with the given fill, stroke and text
+// colours and style, separated by commas as for a classDef or style directive.
+func nodeCSS(fill string, stroke string, color string, style string) string {
+	return strings.Join(nodeCSSParts(fill, stroke, color, style), ",")
+}
+
+// nodeCSSParts returns the CSS properties of a node or subgraph with the given fill, stroke and
+// text colours and style.
+func nodeCSSParts(fill string, stroke string, color string, style string) []string {
+	var parts []string
+
+	if fill != "" {
+		parts = append(parts, "fill:"+fill)
+	}
+
+	if stroke != "" {
+		parts = append(parts, "stroke:"+stroke)
+	}
+
+	if color != "" {
+		parts = append(parts, "color:"+color)
+	}
+
+	return append(parts, styleCSS(style)...)
+}
+
+// writeTaskClassDef writes the classDef giving task nodes the style of TaskNodes, if any. It
+// is written before the classes of style rules, so that they take precedence.
+func writeTaskClassDef(
+	root *indentwriter.Line,
+	nodes []*graph.Node,
+	cfg *config.Config,
+	reg *safe.Registry,
+) {
+	m := mermaidConfig(cfg)
+	if m == nil || m.TaskNodes == nil || len(nodes) == 0 {
+		return
+	}
+
+	style := m.TaskNodes
+
+	classDef := nodeCSS(style.Fill, style.Stroke, style.Color, style.Style)
+	if classDef == "" {
+		return
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, reg.ID(n.ID()))
+	}
+
+	slices.Sort(ids)
+	root.Addf("classDef %s %s", taskClass, classDef)
+	root.Addf("class %s %s", strings.Join(ids, ","), taskClass)
+}
+
+// writeSubgraphStyleTo writes the style directive and direction of the subgraph of a namespace,
+// as configured.
+func writeSubgraphStyleTo(sg *indentwriter.Line, id string, ns string, cfg *config.Config) {
+	m := mermaidConfig(cfg)
+	if m == nil {
+		return
+	}
+
+	direction := m.SubgraphDirections[ns]
+	if direction == "" && m.Subgraphs != nil {
+		direction = m.Subgraphs.Direction
+	}
+
+	if direction != "" {
+		sg.Addf("direction %s", direction)
+	}
+
+	if s := m.Subgraphs; s != nil {
+		if css := nodeCSS(s.Fill, s.Stroke, s.Color, s.Style); css != "" {
+			sg.Addf("style %s %s", id, css)
+		}
+	}
+}
+
+// edgeConfig returns the presentation configured for edges of the given class, or nil.
+func edgeConfig(cfg *config.Config, class string) *config.MermaidEdge {
+	m := mermaidConfig(cfg)
+	if m == nil {
+		return nil
+	}
+
+	switch class {
+	case graph.EdgeClassCall:
+		return m.CallEdges
+	case graph.EdgeClassVar:
+		return m.VariableEdges
+	default:
+		return m.DependencyEdges
+	}
+}
+
+// edgeConnector returns the Mermaid arrow used to draw edges of the given class: a dotted
+// arrow for dashed or dotted edges, a thick arrow for bold edges, and an invisible link for
+// invisible edges. Unless configured, call edges are dotted and variable edges thick.
+func edgeConnector(class string, cfg *config.Config) string {
+	if edge := edgeConfig(cfg, class); edge != nil && edge.Style != "" {
+		return styleConnector(edge.Style)
+	}
+
+	switch class {
+	case graph.EdgeClassCall:
+		return "-.->"
+	case graph.EdgeClassVar:
+		return "==>"
+	default:
+		return "-->"
+	}
+}
+
+// styleConnector returns the Mermaid arrow drawing edges with the given styles.
+func styleConnector(style string) string {
+	styles := strings.Split(strings.ReplaceAll(style, " ", ""), ",")
+
+	switch {
+	case slices.Contains(styles, "invis"):
+		return "~~~"
+	case slices.Contains(styles, "dashed"), slices.Contains(styles, "dotted"):
+		return "-.->"
+	case slices.Contains(styles, "bold"):
+		return "==>"
+	default:
+		return "-->"
+	}
+}
+
+// edgeClassCSS returns the linkStyle CSS giving edges of the given class their configured
+// colour, style and width. The style also chooses the arrow drawn by edgeConnector; the CSS
+// keeps dashed and dotted edges distinct.
+func edgeClassCSS(cfg *config.Config, class string) string {
+	edge := edgeConfig(cfg, class)
+	if edge == nil {
+		return ""
+	}
+
+	var parts []string
+
+	if edge.Color != "" {
+		parts = append(parts, "stroke:"+edge.Color)
+	}
+
+	parts = append(parts, styleCSS(edge.Style)...)
+
+	if edge.Width > 0 {
+		parts = append(parts, fmt.Sprintf("stroke-width:%dpx", edge.Width))
+	}
+
+	return strings.Join(parts, ",")
+}
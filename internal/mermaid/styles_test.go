@@ -0,0 +1,200 @@
+package mermaid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/config"
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+func TestWriteTo_WithMermaidStyles_WritesStyledFlowchart(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := buildNamespacedGraph(t)
+
+	build, ok := gr.Node("build")
+	g.Expect(ok).To(gomega.BeTrue())
+
+	build.Description = "Compile every package of the project into a single binary"
+
+	unit, ok := gr.Node("cmd:test:unit")
+	g.Expect(ok).To(gomega.BeTrue())
+
+	unit.AddEdge(build).SetClass(graph.EdgeClassCall)
+
+	cfg := config.New()
+	cfg.GroupByNamespace = true
+	cfg.Mermaid.Descriptions = true
+	cfg.Mermaid.TaskShape = "rounded"
+	cfg.Mermaid.TaskNodes = &config.MermaidStyle{Fill: "#eef", Stroke: "#336", Style: "bold"}
+	cfg.Mermaid.DependencyEdges = &config.MermaidEdge{Color: "#336", Width: 2}
+	cfg.Mermaid.CallEdges = &config.MermaidEdge{Color: "#933", Style: "bold"}
+	cfg.Mermaid.Subgraphs = &config.MermaidSubgraph{Fill: "#f8f8f8", Style: "dashed", Direction: "LR"}
+	cfg.Mermaid.SubgraphDirections = map[string]string{"cmd:test": "TB"}
+	cfg.NodeStyleRules = []config.NodeStyleRule{
+		{Match: "cmd:test:*", Color: "green", Style: "dashed"},
+	}
+	cfg.EdgeStyleRules = []config.EdgeStyleRule{
+		{To: "cmd:test:golden", Color: "red"},
+	}
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	gg := goldie.New(t)
+	g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+	gg.Assert(t, "styled_graph", buf.Bytes())
+}
+
+func TestWriteTo_WithDescriptions_EscapesMarkup(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	gr.AddNode("build").Description = `Build <all> "targets"`
+
+	cfg := config.New()
+	cfg.Mermaid.Descriptions = true
+
+	// Act
+	err := WriteTo(&buf, gr, cfg)
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).To(gomega.ContainSubstring(`build["build<br/>Build &lt;all&gt; &quot;targets&quot;"]`))
+}
+
+func TestWriteTo_WithoutDescriptions_OmitsDescriptions(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	buf := bytes.Buffer{}
+	gr := graph.New()
+	gr.AddNode("build").Description = "Build the project"
+
+	// Act
+	err := WriteTo(&buf, gr, config.New())
+
+	// Assert
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(buf.String()).NotTo(gomega.ContainSubstring("Build the project"))
+}
+
+func TestTaskNode_GivenShape_UsesShapeBrackets(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":              `id["label"]`,
+		"rect":          `id["label"]`,
+		"rounded":       `id("label")`,
+		"stadium":       `id(["label"])`,
+		"subroutine":    `id[["label"]]`,
+		"cylinder":      `id[("label")]`,
+		"circle":        `id(("label"))`,
+		"hexagon":       `id{{"label"}}`,
+		"parallelogram": `id[/"label"/]`,
+		"trapezoid":     `id[/"label"\]`,
+		"rhombus":       `id{"label"}`,
+	}
+
+	for shape, expected := range cases {
+		t.Run(shape, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Arrange
+			cfg := config.New()
+			cfg.Mermaid.TaskShape = shape
+
+			// Act
+			result := taskNode(cfg, "id", "label")
+
+			// Assert
+			g.Expect(result).To(gomega.Equal(expected))
+		})
+	}
+}
+
+func TestStyleCSS_GivenStyles_ReturnsCSS(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		style    string
+		expected []string
+	}{
+		"empty":    {style: "", expected: nil},
+		"dashed":   {style: "dashed", expected: []string{"stroke-dasharray:5 5"}},
+		"dotted":   {style: "dotted", expected: []string{"stroke-dasharray:2 2"}},
+		"solid":    {style: "solid", expected: []string{"stroke-dasharray:0"}},
+		"bold":     {style: "bold", expected: []string{"stroke-width:3px"}},
+		"rounded":  {style: "rounded", expected: []string{"rx:6px", "ry:6px"}},
+		"invis":    {style: "invis", expected: []string{"opacity:0"}},
+		"filled":   {style: "filled", expected: nil},
+		"combined": {style: "dashed, bold", expected: []string{"stroke-dasharray:5 5", "stroke-width:3px"}},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Act
+			result := styleCSS(c.style)
+
+			// Assert
+			g.Expect(result).To(gomega.Equal(c.expected))
+		})
+	}
+}
+
+func TestEdgeConnector_GivenClassAndStyle_ReturnsArrow(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		class    string
+		edge     *config.MermaidEdge
+		expected string
+	}{
+		"dep default":  {class: graph.EdgeClassDep, expected: "-->"},
+		"call default": {class: graph.EdgeClassCall, expected: "-.->"},
+		"var default":  {class: graph.EdgeClassVar, expected: "==>"},
+		"dep dashed":   {class: graph.EdgeClassDep, edge: &config.MermaidEdge{Style: "dashed"}, expected: "-.->"},
+		"dep bold":     {class: graph.EdgeClassDep, edge: &config.MermaidEdge{Style: "bold"}, expected: "==>"},
+		"call solid":   {class: graph.EdgeClassCall, edge: &config.MermaidEdge{Style: "solid"}, expected: "-->"},
+		"var invis":    {class: graph.EdgeClassVar, edge: &config.MermaidEdge{Style: "invis"}, expected: "~~~"},
+		"color only":   {class: graph.EdgeClassCall, edge: &config.MermaidEdge{Color: "red"}, expected: "-.->"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Arrange
+			cfg := config.New()
+			cfg.Mermaid.DependencyEdges = c.edge
+			cfg.Mermaid.CallEdges = c.edge
+			cfg.Mermaid.VariableEdges = c.edge
+
+			// Act
+			result := edgeConnector(c.class, cfg)
+
+			// Assert
+			g.Expect(result).To(gomega.Equal(c.expected))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package mmdc runs mermaid-cli (mmdc) to render Mermaid source as images.
+package mmdc
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/render"
+)
+
+// Formats are the image formats mermaid-cli can render.
+var Formats = []string{"svg", "png", "pdf"}
+
+// themes are the themes accepted by the --theme option of mermaid-cli; others, such as base,
+// are set by the config file instead.
+var themes = []string{"default", "forest", "dark", "neutral"}
+
+// FindExecutable returns the path to the mmdc executable.
+// If mmdcPath is the full path to an executable, it is returned directly.
+// If mmdcPath is a directory, the mmdc executable is looked up within that directory.
+// If mmdcPath is empty, mmdc is looked up on the system PATH.
+func FindExecutable(mmdcPath string) (string, error) {
+	result, err := render.FindExecutable("mmdc", "mmdcPath", mmdcPath)
+	if err != nil {
+		return "", eris.Wrap(
+			err,
+			"mermaid-cli is needed to render Mermaid images; install it with "+
+				"'npm install -g @mermaid-js/mermaid-cli', or set mmdcPath")
+	}
+
+	return result, nil
+}
+
+// Renderer runs the mmdc executable to render Mermaid source as images.
+type Renderer struct {
+	// Executable is the path to the mmdc executable.
+	Executable string
+
+	// Theme is the Mermaid theme, passed to mmdc as --theme if it is one mmdc accepts.
+	Theme string
+
+	// Config is the Mermaid configuration as JSON, passed to mmdc in a config file if not empty.
+	Config []byte
+
+	// Timeout is how long mmdc may take to render each image before it is stopped; no limit if
+	// zero.
+	Timeout time.Duration
+
+	// Stdout receives any image without a path.
+	Stdout io.Writer
+}
+
+var _ render.Renderer = &Renderer{}
+
+// Render runs mmdc for each of the images, sending it the Mermaid source on stdin. If mmdc
+// fails, each problem it reports is located at the line of the source it complains about.
+func (r *Renderer) Render(ctx context.Context, source []byte, images []render.Image) error {
+	for _, image := range images {
+		if !slices.Contains(Formats, image.Format) {
+			return eris.Errorf(
+				"mermaid-cli can't render %s images, only %s",
+				image.Format,
+				strings.Join(Formats, ", "))
+		}
+	}
+
+	args := []string{"--quiet", "--input", "-"}
+
+	if slices.Contains(themes, r.Theme) {
+		args = append(args, "--theme", r.Theme)
+	}
+
+	if len(r.Config) > 0 {
+		configFile, err := writeConfig(r.Config)
+		if err != nil {
+			return err
+		}
+
+		defer os.Remove(configFile)
+
+		args = append(args, "--configFile", configFile)
+	}
+
+	for _, image := range images {
+		output := image.Path
+		if output == "" {
+			output = "-"
+		}
+
+		err := render.Run(ctx, render.Command{
+			Name:       "mmdc",
+			Executable: r.Executable,
+			Args:       append(slices.Clone(args), "--output", output, "--outputFormat", image.Format),
+			Source:     source,
+			SourceName: "generated Mermaid",
+			Timeout:    r.Timeout,
+			Stdout:     r.Stdout,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeConfig writes the Mermaid configuration to a temporary file, returning its path.
+func writeConfig(config []byte) (string, error) {
+	f, err := os.CreateTemp("", "task-graph-mermaid-*.json")
+	if err != nil {
+		return "", eris.Wrap(err, "failed to create mermaid config file")
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(config)
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return "", eris.Wrapf(err, "failed to write mermaid config file: %s", f.Name())
+	}
+
+	return f.Name(), nil
+}
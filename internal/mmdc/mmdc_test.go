@@ -0,0 +1,129 @@
+package mmdc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/render"
+)
+
+func TestFindExecutable_Missing_ExplainsHowToInstall(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := FindExecutable(t.TempDir())
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("mmdc executable not found in directory")))
+	g.Expect(err).To(MatchError(ContainSubstring("npm install -g @mermaid-js/mermaid-cli")))
+}
+
+func TestRender_PassesThemeAndConfigForEachImage(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+
+	// Record the arguments and config file of each run, then echo stdin to stdout
+	exe := fakeMmdc(t, dir,
+		`echo "$@" >> "`+log+`"`,
+		`while [ $# -gt 0 ]; do [ "$1" = --configFile ] && cat "$2" >> "`+log+`"; shift; done`,
+		`cat`)
+
+	var stdout bytes.Buffer
+
+	r := &Renderer{
+		Executable: exe,
+		Theme:      "dark",
+		Config:     []byte(`{"theme":"dark"}`),
+		Stdout:     &stdout,
+	}
+
+	// Act
+	err := r.Render(context.Background(), []byte("flowchart TD\n"), []render.Image{
+		{Format: "png", Path: "graph.png"},
+		{Format: "svg"},
+	})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(MatchRegexp(
+		`^--quiet --input - --theme dark --configFile \S+\.json --output graph.png --outputFormat png\n` +
+			`\{"theme":"dark"\}` +
+			`--quiet --input - --theme dark --configFile \S+\.json --output - --outputFormat svg\n` +
+			`\{"theme":"dark"\}$`))
+	g.Expect(stdout.String()).To(Equal("flowchart TD\nflowchart TD\n"))
+}
+
+func TestRender_BaseTheme_IsSetOnlyByConfig(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	exe := fakeMmdc(t, dir, `echo "$@" > "`+log+`"`)
+
+	r := &Renderer{Executable: exe, Theme: "base"}
+
+	// Act
+	err := r.Render(context.Background(), []byte("flowchart TD\n"), []render.Image{{Format: "svg", Path: "graph.svg"}})
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := os.ReadFile(log)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("--quiet --input - --output graph.svg --outputFormat svg\n"))
+}
+
+func TestRender_UnsupportedFormat_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	r := &Renderer{Executable: "/nonexistent/mmdc"}
+
+	// Act
+	err := r.Render(context.Background(), []byte("flowchart TD\n"), []render.Image{{Format: "gif"}})
+
+	// Assert
+	g.Expect(err).To(MatchError("mermaid-cli can't render gif images, only svg, png, pdf"))
+}
+
+// fakeMmdc writes a shell script standing in for mmdc, running the given commands, and returns
+// its path. Tests using it are skipped on Windows.
+func fakeMmdc(t *testing.T, dir string, commands ...string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("fake mmdc is a shell script, skipping on Windows")
+	}
+
+	path := filepath.Join(dir, "mmdc")
+
+	script := "#!/bin/sh\n"
+	for _, command := range commands {
+		script += command + "\n"
+	}
+
+	//nolint:gosec // The script must be executable
+	err := os.WriteFile(path, []byte(script), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
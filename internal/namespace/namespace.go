@@ -148,3 +148,25 @@ func MatchPattern(ns string) string {
 
 	return ns + "[-.:]*"
 }
+
+// QuoteMatchPattern returns a glob-style pattern that matches exactly the given name,
+// escaping any characters that would otherwise be treated as wildcards.
+// The returned pattern is intended for storage in NodeStyleRule.Match.
+func QuoteMatchPattern(name string) string {
+	var b strings.Builder
+
+	b.Grow(len(name))
+
+	for _, c := range name {
+		switch c {
+		case '*', '?', '[':
+			b.WriteRune('[')
+			b.WriteRune(c)
+			b.WriteRune(']')
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
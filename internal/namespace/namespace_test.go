@@ -209,6 +209,47 @@ func TestMatchPattern_AmbiguousNamespace_ReturnsAllDelimiterPattern(t *testing.T
 	g.Expect(p).To(Equal("build[-.:]*"))
 }
 
+func TestQuoteMatchPattern_VariousNames_MatchesOnlyThatName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		name    string
+		noMatch string
+	}{
+		"plain name": {
+			name:    "cmd:build",
+			noMatch: "cmd:builds",
+		},
+		"star": {
+			name:    "build*",
+			noMatch: "builder",
+		},
+		"question mark": {
+			name:    "build?",
+			noMatch: "builds",
+		},
+		"brackets": {
+			name:    "build[x]",
+			noMatch: "buildx",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			re, err := CompileMatchPattern(QuoteMatchPattern(c.name))
+
+			// Assert
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(re.MatchString(c.name)).To(BeTrue())
+			g.Expect(re.MatchString(c.noMatch)).To(BeFalse())
+		})
+	}
+}
+
 // BenchmarkCompileMatchPattern measures allocations for a typical style-rule pattern.
 func BenchmarkCompileMatchPattern(b *testing.B) {
 	b.ReportAllocs()
@@ -0,0 +1,181 @@
+// Package render runs the external tools that render graph source as images, such as Graphviz
+// dot and mermaid-cli. Each tool is wrapped by a Renderer; this package holds what they share:
+// finding the executable, running it with a timeout, and reporting its errors located within
+// the source.
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+// Image is an image to render.
+type Image struct {
+	// Format is the output format, such as png, svg or png:cairo.
+	Format string
+
+	// Path is the file to write the image to. If empty, the image is written to the Stdout of
+	// the Renderer.
+	Path string
+}
+
+// Renderer renders graph source as images.
+type Renderer interface {
+	// Render renders the source as each of the images.
+	Render(ctx context.Context, source []byte, images []Image) error
+}
+
+// FindExecutable returns the path to the executable of the named tool, given the value of the
+// config setting naming where it is.
+// If path is the full path to an executable, it is returned directly.
+// If path is a directory, the executable is looked up within that directory.
+// If path is empty, the executable is looked up on the system PATH.
+func FindExecutable(name string, setting string, path string) (string, error) {
+	if path == "" {
+		result, err := exec.LookPath(name)
+		if err != nil {
+			return "", eris.Wrapf(err, "%s executable not found on PATH", name)
+		}
+
+		return result, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", eris.Wrapf(err, "%s not found: %s", setting, path)
+	}
+
+	if info.IsDir() {
+		return findInDirectory(name, path)
+	}
+
+	return path, nil
+}
+
+// executableSuffixes are the suffixes tried when looking for an executable in a directory, so
+// that it is found on Windows too.
+var executableSuffixes = []string{"", ".exe", ".cmd"}
+
+// findInDirectory checks for the presence of the named executable in the specified directory.
+// directory is expected to be a valid directory path.
+func findInDirectory(name string, directory string) (string, error) {
+	for _, suffix := range executableSuffixes {
+		candidate := filepath.Join(directory, name+suffix)
+
+		_, err := os.Stat(candidate)
+		if err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", eris.Errorf(
+		"%s executable not found in directory: %s",
+		name,
+		directory)
+}
+
+// Command is a single run of a tool, rendering source sent to it on stdin.
+type Command struct {
+	// Name is the name of the tool, used in errors.
+	Name string
+
+	// Executable is the path to the executable of the tool.
+	Executable string
+
+	// Args are the arguments passed to the tool.
+	Args []string
+
+	// Source is the graph source sent on stdin.
+	Source []byte
+
+	// SourceName names the source in the problems reported by the tool.
+	SourceName string
+
+	// Timeout is how long the tool may run before it is stopped; no limit if zero.
+	Timeout time.Duration
+
+	// Stdout receives what the tool writes to stdout.
+	Stdout io.Writer
+}
+
+// waitDelay is how long to wait for the output of a tool to close once it has been stopped.
+const waitDelay = time.Second
+
+// Run runs the command. If the tool fails, the error holds what it wrote to stderr as a
+// *diagnostic.Error, so that each problem is reported with the line of the source it complains
+// about.
+func Run(ctx context.Context, c Command) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+
+	//nolint:gosec // Executable is resolved from a trusted config path or system PATH
+	cmd := exec.CommandContext(ctx, c.Executable, c.Args...)
+	cmd.Stdin = bytes.NewReader(c.Source)
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return eris.Errorf("%s did not finish rendering within %s", c.Name, c.Timeout)
+	}
+
+	problems := diagnostic.New(c.SourceName, c.Source, parseStderr(stderr.String()))
+	if problems == nil {
+		return eris.Wrapf(err, "%s command failed", c.Name)
+	}
+
+	return eris.Wrapf(problems, "%s command failed (%s)", c.Name, err)
+}
+
+// stdinPrefix is how tools name their input when reading from stdin.
+const stdinPrefix = "<stdin>: "
+
+// stderrLine matches the line number in a message from a tool, such as
+// "Error: <stdin>: syntax error in line 3 near '->'" or "Error: Parse error on line 3:".
+var stderrLine = regexp.MustCompile(`\bline (\d+)\b`)
+
+// parseStderr returns a problem for each message a tool wrote to stderr, located at the line of
+// the source it names, if any. Stack frames, as written by mermaid-cli, are left out.
+func parseStderr(stderr string) []diagnostic.Diagnostic {
+	var result []diagnostic.Diagnostic
+
+	for _, message := range strings.Split(stderr, "\n") {
+		message = strings.TrimSpace(strings.ReplaceAll(message, stdinPrefix, ""))
+		if message == "" || strings.HasPrefix(message, "at ") {
+			continue
+		}
+
+		d := diagnostic.Diagnostic{Message: message}
+		if match := stderrLine.FindStringSubmatch(message); match != nil {
+			d.Line, _ = strconv.Atoi(match[1])
+		}
+
+		result = append(result, d)
+	}
+
+	return result
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
+)
+
+func TestFindExecutable_DirectoryWithCmd_ReturnsCmdPath(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	dir := t.TempDir()
+	cmdPath := filepath.Join(dir, "mmdc.cmd")
+	g.Expect(os.WriteFile(cmdPath, []byte("fake mmdc"), 0o600)).To(Succeed())
+
+	// Act
+	result, err := FindExecutable("mmdc", "mmdcPath", dir)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(cmdPath))
+}
+
+func TestFindExecutable_NonExistentPath_NamesSetting(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := FindExecutable("mmdc", "mmdcPath", "/this/path/does/not/exist")
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("mmdcPath not found: /this/path/does/not/exist")))
+}
+
+func TestRun_NonExistentExecutable_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	err := Run(context.Background(), Command{Name: "mmdc", Executable: "/nonexistent/mmdc"})
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("mmdc command failed")))
+}
+
+func TestParseStderr_MermaidParseError_LocatesLineWithoutStackFrames(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	stderr := "\nError: Parse error on line 3:\n...B --> --> C\n---------^\n" +
+		"Expecting 'AMP', got 'LINK'\n" +
+		"    at Parser.parseError (file:///mermaid.js:1:100)\n" +
+		"    at Parser.parse (file:///mermaid.js:2:200)\n"
+
+	// Act
+	problems := parseStderr(stderr)
+
+	// Assert
+	g.Expect(problems).To(Equal([]diagnostic.Diagnostic{
+		{Line: 3, Message: "Error: Parse error on line 3:"},
+		{Message: "...B --> --> C"},
+		{Message: "---------^"},
+		{Message: "Expecting 'AMP', got 'LINK'"},
+	}))
+}
@@ -0,0 +1,54 @@
+package rgb
+
+import (
+	"image/color"
+	"math"
+)
+
+var (
+	// Black and White are the colours Readable chooses between.
+	Black = color.RGBA{A: 0xff}
+	White = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// Luminance returns the relative luminance of c as defined by WCAG 2, from 0 for black to 1
+// for white.
+func Luminance(c color.RGBA) float64 {
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// Contrast returns the WCAG 2 contrast ratio between a and b, from 1 for identical colours to
+// 21 for black and white.
+func Contrast(a color.RGBA, b color.RGBA) float64 {
+	la, lb := Luminance(a), Luminance(b)
+
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
+
+// Readable returns whichever of black and white has the greater contrast with background.
+func Readable(background color.RGBA) color.RGBA {
+	if Contrast(background, Black) >= Contrast(background, White) {
+		return Black
+	}
+
+	return White
+}
+
+// Mix returns a blend of a and b, taking the given fraction, between 0 and 1, of b.
+func Mix(a color.RGBA, b color.RGBA, fraction float64) color.RGBA {
+	blend := func(x uint8, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*fraction))
+	}
+
+	return color.RGBA{R: blend(a.R, b.R), G: blend(a.G, b.G), B: blend(a.B, b.B), A: blend(a.A, b.A)}
+}
+
+// linear converts an sRGB channel to linear light.
+func linear(channel uint8) float64 {
+	v := float64(channel) / 0xff
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
@@ -0,0 +1,203 @@
+// Package rgb parses the colours accepted in a config into RGB values, and measures and blends
+// them for the renderers and for choosing readable colours.
+package rgb
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+var (
+	// numberedColor matches a Graphviz colour name with a numeric suffix, such as gray42 or red3.
+	numberedColor = regexp.MustCompile(`^([a-z]+)([0-9]{1,3})$`)
+
+	// functionColor matches the CSS colour functions rgb() and rgba().
+	functionColor = regexp.MustCompile(`^rgba?\(([^()]*)\)$`)
+
+	// hsvColor matches a Graphviz HSV colour: three numbers between 0 and 1.
+	hsvColor = regexp.MustCompile(`^([01]?\.?[0-9]*)[ ,]+([01]?\.?[0-9]*)[ ,]+([01]?\.?[0-9]*)$`)
+)
+
+// extraColorNames are the Graphviz (X11) colour names that are not also CSS colour names.
+var extraColorNames = map[string]color.RGBA{
+	"lightgoldenrod": {R: 0xee, G: 0xdd, B: 0x82, A: 0xff},
+	"lightslateblue": {R: 0x84, G: 0x70, B: 0xff, A: 0xff},
+	"navyblue":       {R: 0x00, G: 0x00, B: 0x80, A: 0xff},
+	"violetred":      {R: 0xd0, G: 0x20, B: 0x90, A: 0xff},
+}
+
+// Parse returns the colour described by s, as accepted by config.ValidateColor, or false
+// if it is empty or can't be drawn. Only the first colour of a gradient or colour list is used,
+// and X11 colour variants such as red3 are drawn as their base colour.
+func Parse(s string) (color.RGBA, bool) {
+	first, _, _ := strings.Cut(s, ":")
+	first, _, _ = strings.Cut(first, ";")
+	first = strings.TrimSpace(first)
+	lower := strings.ToLower(first)
+
+	switch {
+	case lower == "":
+		return color.RGBA{}, false
+	case lower == "none" || lower == "transparent":
+		return color.RGBA{}, true
+	case strings.HasPrefix(lower, "#"):
+		return parseHexColor(lower[1:])
+	}
+
+	if c, ok := namedColor(lower); ok {
+		return c, true
+	}
+
+	if match := numberedColor.FindStringSubmatch(lower); match != nil {
+		return parseNumberedColor(match[1], match[2])
+	}
+
+	if match := functionColor.FindStringSubmatch(lower); match != nil {
+		return parseRGBFunction(match[1])
+	}
+
+	if match := hsvColor.FindStringSubmatch(lower); match != nil {
+		return parseHSVColor(match[1:])
+	}
+
+	return color.RGBA{}, false
+}
+
+// namedColor returns the colour with the given CSS or Graphviz name.
+func namedColor(name string) (color.RGBA, bool) {
+	if c, ok := colornames.Map[name]; ok {
+		return c, true
+	}
+
+	c, ok := extraColorNames[name]
+
+	return c, ok
+}
+
+// parseHexColor parses the digits of an #rgb, #rrggbb or #rrggbbaa colour.
+func parseHexColor(digits string) (color.RGBA, bool) {
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) != 8 {
+		return color.RGBA{}, false
+	}
+
+	return color.RGBA{
+		R: uint8(value >> 24),
+		G: uint8(value >> 16),
+		B: uint8(value >> 8),
+		A: uint8(value),
+	}, true
+}
+
+// parseNumberedColor parses a Graphviz colour with a numeric suffix. Greys take their level from
+// the number; other colours are drawn as their base colour.
+func parseNumberedColor(name string, number string) (color.RGBA, bool) {
+	level, err := strconv.Atoi(number)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+
+	if (name == "gray" || name == "grey") && level <= 100 {
+		v := uint8(math.Round(float64(level) * 255 / 100))
+
+		return color.RGBA{R: v, G: v, B: v, A: 0xff}, true
+	}
+
+	return namedColor(name)
+}
+
+// parseRGBFunction parses the arguments of an rgb() or rgba() colour.
+func parseRGBFunction(args string) (color.RGBA, bool) {
+	parts := strings.Split(args, ",")
+	if len(parts) < 3 || len(parts) > 4 {
+		return color.RGBA{}, false
+	}
+
+	values := make([]uint8, 4)
+	values[3] = 0xff
+
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+
+		scale := 1.0
+		if i == 3 {
+			scale = 255
+		}
+
+		if p, ok := strings.CutSuffix(part, "%"); ok {
+			part = p
+			scale = 2.55
+		}
+
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return color.RGBA{}, false
+		}
+
+		values[i] = uint8(math.Round(math.Max(0, math.Min(255, v*scale))))
+	}
+
+	return color.RGBA{R: values[0], G: values[1], B: values[2], A: values[3]}, true
+}
+
+// parseHSVColor parses the hue, saturation and value of a Graphviz HSV colour.
+func parseHSVColor(parts []string) (color.RGBA, bool) {
+	hsv := make([]float64, 3)
+
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return color.RGBA{}, false
+		}
+
+		hsv[i] = math.Max(0, math.Min(1, v))
+	}
+
+	h, s, v := hsv[0]*6, hsv[1], hsv[2]
+	sector := math.Floor(h)
+	f := h - sector
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+
+	var r, g, b float64
+
+	switch int(sector) % 6 {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round(r * 255)),
+		G: uint8(math.Round(g * 255)),
+		B: uint8(math.Round(b * 255)),
+		A: 0xff,
+	}, true
+}
+
+// Hex returns c as a colour, #rrggbb.
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
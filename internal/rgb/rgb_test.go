@@ -0,0 +1,107 @@
+package rgb
+
+import (
+	"image/color"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		color string
+		hex   string
+		ok    bool
+	}{
+		"Name":         {"orange", "#ffa500", true},
+		"GraphvizName": {"navyblue", "#000080", true},
+		"Hex":          {"#1f78b4", "#1f78b4", true},
+		"ShortHex":     {"#abc", "#aabbcc", true},
+		"Gray level":   {"gray50", "#808080", true},
+		"Numbered":     {"red3", "#ff0000", true},
+		"RGB function": {"rgb(255, 0, 128)", "#ff0080", true},
+		"HSV":          {"0.0 1.0 1.0", "#ff0000", true},
+		"Gradient":     {"yellow:blue", "#ffff00", true},
+		"Empty":        {"", "", false},
+		"Unknown":      {"sparkly", "", false},
+		"Transparent":  {"none", "#000000", true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Act
+			result, ok := Parse(c.color)
+
+			// Assert
+			g.Expect(ok).To(Equal(c.ok))
+
+			if ok {
+				g.Expect(Hex(result)).To(Equal(c.hex))
+			}
+		})
+	}
+}
+
+func TestContrast_BlackOnWhite_IsTwentyOne(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	blackOnWhite := Contrast(Black, White)
+	whiteOnWhite := Contrast(White, White)
+
+	// Assert
+	g.Expect(blackOnWhite).To(BeNumerically("~", 21, 0.001))
+	g.Expect(whiteOnWhite).To(BeNumerically("~", 1, 0.001))
+}
+
+func TestReadable(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		background string
+		expected   color.RGBA
+	}{
+		"Light":      {"lightyellow", Black},
+		"Dark":       {"navy", White},
+		"Okabe blue": {"#0072B2", White},
+		"Orange":     {"#E69F00", Black},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			// Arrange
+			background, ok := Parse(c.background)
+			g.Expect(ok).To(BeTrue())
+
+			// Act
+			result := Readable(background)
+
+			// Assert
+			g.Expect(result).To(Equal(c.expected))
+		})
+	}
+}
+
+func TestMix(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	half := Mix(Black, White, 0.5)
+	none := Mix(Black, White, 0)
+	all := Mix(Black, White, 1)
+
+	// Assert
+	g.Expect(Hex(half)).To(Equal("#808080"))
+	g.Expect(none).To(Equal(Black))
+	g.Expect(all).To(Equal(White))
+}
@@ -1,14 +1,22 @@
 package safe
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"maps"
+	"slices"
 	"strings"
 	"unicode/utf8"
 )
 
 // Registry maintains a cache of safe identifiers, ensuring that each unique input
 // produces a unique output, with already-safe names taking precedence.
+//
+// Each identifier is derived from the content of its name, never from the order in which
+// names arrive: a name colliding with another is given a suffix hashed from the name itself.
+// Adding or removing a name therefore changes no identifier but those of any names it collides
+// with, keeping the diffs of generated files small.
 type Registry struct {
 	claimed map[string]string // safeID -> original name that claimed it
 	results map[string]string // original name -> assigned safe ID
@@ -22,9 +30,11 @@ func NewRegistry() *Registry {
 	}
 }
 
-// Prepare pre-registers all names that are already valid safe identifiers so they
-// take precedence over names that require sanitization. Must be called with all
-// names before calling ID() to guarantee deterministic results.
+// Prepare registers all the names to be given identifiers. Names that are already valid safe
+// identifiers take precedence over names that require sanitization; a sanitized name keeps its
+// sanitized form only if no other name shares it, so that which of them gets the plain form
+// doesn't depend on the names present. Must be called with all names before calling ID() to
+// guarantee deterministic results.
 //
 // Prepare also pre-sizes the internal maps to len(names) to avoid incremental
 // rehashing when the caller knows the full name set up front.
@@ -40,18 +50,45 @@ func (r *Registry) Prepare(names []string) {
 		r.results = results
 	}
 
+	// Names needing sanitization, grouped by their sanitized form
+	groups := make(map[string][]string)
+
 	for _, name := range names {
+		if _, ok := r.results[name]; ok {
+			continue
+		}
+
 		if r.isValid(name) {
-			if _, ok := r.results[name]; !ok {
-				r.claimed[name] = name
-				r.results[name] = name
-			}
+			r.claimed[name] = name
+			r.results[name] = name
+
+			continue
+		}
+
+		base := r.sanitize(name)
+		if !slices.Contains(groups[base], name) {
+			groups[base] = append(groups[base], name)
+		}
+	}
+
+	for _, base := range slices.Sorted(maps.Keys(groups)) {
+		group := groups[base]
+		if len(group) == 1 {
+			r.results[group[0]] = r.claim(group[0], base)
+
+			continue
+		}
+
+		slices.Sort(group)
+
+		for _, name := range group {
+			r.results[name] = r.claimHashed(name, base)
 		}
 	}
 }
 
 // ID returns a safe identifier for the given name. If two different names produce
-// the same sanitized identifier, a unique letter suffix is appended to disambiguate.
+// the same sanitized identifier, a suffix hashed from the name is appended to disambiguate.
 func (r *Registry) ID(name string) string {
 	if result, ok := r.results[name]; ok {
 		return result
@@ -77,8 +114,17 @@ func (r *Registry) claim(original string, base string) string {
 		return base
 	}
 
-	for _, suffix := range knownSuffixes {
-		candidate := base + "_" + suffix
+	return r.claimHashed(original, base)
+}
+
+// claimHashed assigns base with a suffix hashed from the original name, lengthening the hash
+// in the unlikely event that it collides too.
+func (r *Registry) claimHashed(original string, base string) string {
+	sum := sha256.Sum256([]byte(original))
+	hash := hex.EncodeToString(sum[:])
+
+	for length := minHashLength; length <= len(hash); length += 2 {
+		candidate := base + "_" + hash[:length]
 		if r.tryAssign(original, candidate) {
 			return candidate
 		}
@@ -171,21 +217,5 @@ func isDigit(ch rune) bool {
 	return ch >= '0' && ch <= '9'
 }
 
-// knownSuffixes contains the letter-based disambiguation suffixes in order: A–Z, AA–ZZ.
-var knownSuffixes = buildSuffixes()
-
-func buildSuffixes() []string {
-	suffixes := make([]string, 0, 26+26*26)
-
-	for c := 'A'; c <= 'Z'; c++ {
-		suffixes = append(suffixes, string(c))
-	}
-
-	for c1 := 'A'; c1 <= 'Z'; c1++ {
-		for c2 := 'A'; c2 <= 'Z'; c2++ {
-			suffixes = append(suffixes, string([]rune{c1, c2}))
-		}
-	}
-
-	return suffixes
-}
+// minHashLength is the number of hex digits of the hash first tried as a disambiguation suffix.
+const minHashLength = 6
@@ -1,6 +1,8 @@
 package safe
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	"github.com/onsi/gomega"
@@ -243,8 +245,8 @@ func TestRegistry_ID_SafeNameTakesPrecedence_WhenPrepared(t *testing.T) {
 	g.Expect(colon).NotTo(gomega.Equal(slash), "two colliding variants must get distinct IDs")
 }
 
-// TestRegistry_ID_DisambiguationSuffix tests that disambiguation suffixes follow the expected pattern.
-func TestRegistry_ID_DisambiguationSuffix_UsesLetterSuffix(t *testing.T) {
+// TestRegistry_ID_DisambiguationSuffix tests that disambiguation suffixes are hashed from the name.
+func TestRegistry_ID_DisambiguationSuffix_UsesHashSuffix(t *testing.T) {
 	t.Parallel()
 	g := gomega.NewWithT(t)
 
@@ -254,47 +256,110 @@ func TestRegistry_ID_DisambiguationSuffix_UsesLetterSuffix(t *testing.T) {
 
 	// Act
 	reg.ID("doc_taskfile")          // claims doc_taskfile
-	colon := reg.ID("doc:taskfile") // collides -> gets _A
-	slash := reg.ID("doc/taskfile") // collides -> gets _B
+	colon := reg.ID("doc:taskfile") // collides -> gets a suffix hashed from "doc:taskfile"
+	slash := reg.ID("doc/taskfile") // collides -> gets a suffix hashed from "doc/taskfile"
 
 	// Assert
-	g.Expect(colon).To(gomega.Equal("doc_taskfile_A"))
-	g.Expect(slash).To(gomega.Equal("doc_taskfile_B"))
+	g.Expect(colon).To(gomega.Equal("doc_taskfile_" + hashOf("doc:taskfile")[:minHashLength]))
+	g.Expect(slash).To(gomega.Equal("doc_taskfile_" + hashOf("doc/taskfile")[:minHashLength]))
 }
 
-// TestRegistry_ID_NumericFallback_UsesNumericSuffix verifies that when all 702 letter-based
-// disambiguation suffixes (_A..._ZZ) are exhausted, claim falls back to numeric suffixes (_1, _2…).
-// The test pre-claims "a_b" and all its letter variants via Prepare, then forces a collision
-// through a name that sanitizes to "a_b".
-func TestRegistry_ID_NumericFallback_UsesNumericSuffix(t *testing.T) {
+// TestRegistry_Prepare_SharedSanitizedForm_HashesEveryName verifies that when several names
+// sanitize to the same form, none of them gets the plain form, so which one would get it
+// can't depend on the names present.
+func TestRegistry_Prepare_SharedSanitizedForm_HashesEveryName(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	reg := NewRegistry()
+
+	// Act
+	reg.Prepare([]string{"doc:taskfile", "doc/taskfile"})
+
+	// Assert
+	g.Expect(reg.ID("doc:taskfile")).To(gomega.Equal("doc_taskfile_" + hashOf("doc:taskfile")[:minHashLength]))
+	g.Expect(reg.ID("doc/taskfile")).To(gomega.Equal("doc_taskfile_" + hashOf("doc/taskfile")[:minHashLength]))
+}
+
+// TestRegistry_Prepare_AddingName_KeepsUnrelatedIDs verifies that adding a name changes only
+// the IDs of names it collides with, whatever the order of the names.
+func TestRegistry_Prepare_AddingName_KeepsUnrelatedIDs(t *testing.T) {
 	t.Parallel()
 	g := gomega.NewWithT(t)
 
-	// Pre-claim "a_b" and all letter-suffixed variants so they belong to themselves.
-	preNames := make([]string, 0, 1+26+26*26)
+	// Arrange
+	before := NewRegistry()
+	before.Prepare([]string{"cmd:build", "cmd/test", "cmd.test", "lint"})
+
+	after := NewRegistry()
 
-	preNames = append(preNames, "a_b")
+	// Act
+	after.Prepare([]string{"lint", "cmd.test", "a:new", "cmd:test", "cmd/test", "cmd:build"})
 
-	for c := 'A'; c <= 'Z'; c++ {
-		preNames = append(preNames, "a_b_"+string(c))
+	// Assert
+	for _, name := range []string{"cmd:build", "cmd/test", "cmd.test", "lint"} {
+		g.Expect(after.ID(name)).To(gomega.Equal(before.ID(name)), "ID of %q changed", name)
 	}
 
-	for c1 := 'A'; c1 <= 'Z'; c1++ {
-		for c2 := 'A'; c2 <= 'Z'; c2++ {
-			preNames = append(preNames, "a_b_"+string(c1)+string(c2))
-		}
+	g.Expect(after.ID("cmd:test")).NotTo(gomega.BeElementOf(after.ID("cmd/test"), after.ID("cmd.test")))
+}
+
+// TestRegistry_ID_HashCollision_LengthensHash verifies that a hashed suffix already claimed by
+// another name is lengthened.
+func TestRegistry_ID_HashCollision_LengthensHash(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	hash := hashOf("a:b")
+
+	reg := NewRegistry()
+	reg.Prepare([]string{"a_b", "a_b_" + hash[:minHashLength]})
+
+	// Act
+	result := reg.ID("a:b")
+
+	// Assert
+	g.Expect(result).To(gomega.Equal("a_b_" + hash[:minHashLength+2]))
+}
+
+// TestRegistry_ID_NumericFallback_UsesNumericSuffix verifies that when every length of hashed
+// disambiguation suffix is claimed, claim falls back to numeric suffixes (_1, _2…).
+// The test pre-claims "a_b" and all its hashed variants via Prepare, then forces a collision
+// through a name that sanitizes to "a_b".
+func TestRegistry_ID_NumericFallback_UsesNumericSuffix(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewWithT(t)
+
+	// Arrange
+	hash := hashOf("a:b")
+
+	// Pre-claim "a_b" and all hashed variants so they belong to themselves.
+	preNames := []string{"a_b"}
+	for length := minHashLength; length <= len(hash); length += 2 {
+		preNames = append(preNames, "a_b_"+hash[:length])
 	}
 
 	reg := NewRegistry()
 	reg.Prepare(preNames)
 
-	// "a:b" sanitizes to "a_b"; all letter variants are claimed by different originals,
+	// Act
+	// "a:b" sanitizes to "a_b"; all hashed variants are claimed by different originals,
 	// so claim must fall back to the numeric suffix.
 	result := reg.ID("a:b")
 
+	// Assert
 	g.Expect(result).To(gomega.Equal("a_b_1"))
 }
 
+// hashOf returns the hex SHA-256 hash of name, from which disambiguation suffixes are taken.
+func hashOf(name string) string {
+	sum := sha256.Sum256([]byte(name))
+
+	return hex.EncodeToString(sum[:])
+}
+
 // TestRegistry_IDWithPrefix_AddsPrefixBeforeTransformation verifies IDWithPrefix behavior.
 func TestRegistry_IDWithPrefix_AddsPrefixBeforeTransformation(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// WriteReport writes a human-readable summary of the analysis to the given writer.
+func (a *Analysis) WriteReport(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Execution analysis for %q\n", a.Target.ID())
+
+	if a.UsesDurations {
+		b.WriteString("Task weights: measured durations\n")
+	} else {
+		b.WriteString("Task weights: 1 per task\n")
+	}
+
+	b.WriteString("\n")
+
+	for i, wave := range a.Waves {
+		fmt.Fprintf(&b, "Wave %d (%d): %s\n", i+1, len(wave), joinNodes(wave, ", "))
+	}
+
+	size, index := a.MaxParallelism()
+
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Tasks: %d\n", a.TaskCount())
+	fmt.Fprintf(&b, "Waves: %d\n", len(a.Waves))
+	fmt.Fprintf(&b, "Maximum parallelism: %d (wave %d)\n", size, index)
+	fmt.Fprintf(&b, "Longest chain (%d tasks): %s\n", len(a.LongestChain), joinNodes(a.LongestChain, " -> "))
+	fmt.Fprintf(&b, "Critical path (%s): %s\n", a.formatWeight(a.CriticalPathWeight), joinNodes(a.CriticalPath, " -> "))
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return eris.Wrap(err, "failed to write analysis report")
+	}
+
+	return nil
+}
+
+// formatWeight formats a weight for display, as a duration when durations are in use.
+func (a *Analysis) formatWeight(weight float64) string {
+	if a.UsesDurations {
+		return time.Duration(weight * float64(time.Second)).Round(time.Millisecond).String()
+	}
+
+	return "weight " + strconv.FormatFloat(weight, 'f', -1, 64)
+}
+
+func joinNodes(nodes []*graph.Node, sep string) string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID()
+	}
+
+	return strings.Join(ids, sep)
+}
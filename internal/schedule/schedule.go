@@ -0,0 +1,342 @@
+// Package schedule analyzes how the tasks needed by a target task could be executed, grouping
+// them into waves of tasks that can run concurrently and finding the chains that bound how
+// quickly the target can complete.
+//
+// Task runs the dependencies of a task in parallel, before any of its commands, and runs the
+// tasks called from its commands in order. Each task is placed in the wave after everything it
+// must wait for: its own dependencies and calls, plus (for a called task) the dependencies of
+// its caller and any task called before it.
+package schedule
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/rotisserie/eris"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// Analysis is the result of analysing the execution of a target task.
+type Analysis struct {
+	// Target is the task that was analysed.
+	Target *graph.Node
+
+	// Waves groups the tasks needed by the target into sets that can run concurrently,
+	// in execution order. The target itself is always in the final wave.
+	Waves [][]*graph.Node
+
+	// LongestChain is the chain with the most tasks, from the first task to run through to
+	// the target.
+	LongestChain []*graph.Node
+
+	// CriticalPath is the chain with the greatest total weight, from the first task to run
+	// through to the target.
+	CriticalPath []*graph.Node
+
+	// CriticalPathWeight is the total weight of the CriticalPath.
+	CriticalPathWeight float64
+
+	// UsesDurations is true if task weights came from measured durations, with weights in
+	// seconds; otherwise every task has a weight of 1.
+	UsesDurations bool
+}
+
+// Analyze computes the execution waves, longest chain and critical path for the named target
+// task. When any task in the graph has a measured duration, durations (in seconds) are used as
+// task weights, with unmeasured tasks weighing nothing; otherwise each task has a weight of 1.
+func Analyze(gr *graph.Graph, target string) (*Analysis, error) {
+	node, ok := gr.Node(target)
+	if !ok {
+		return nil, eris.Errorf("target task %q not found", target)
+	}
+
+	if node.Kind != graph.NodeKindTask {
+		return nil, eris.Errorf("target %q is not a task", target)
+	}
+
+	usesDurations := hasDurations(gr)
+	weight := func(*graph.Node) float64 { return 1 }
+
+	if usesDurations {
+		weight = durationWeight
+	}
+
+	p := newPlanner()
+	p.visit(node, nil)
+
+	wave := p.layer(func(*graph.Node) float64 { return 1 })
+	finish := p.layer(weight)
+
+	return &Analysis{
+		Target:             node,
+		Waves:              p.waves(wave),
+		LongestChain:       p.chain(node, wave),
+		CriticalPath:       p.chain(node, finish),
+		CriticalPathWeight: finish[node],
+		UsesDurations:      usesDurations,
+	}, nil
+}
+
+// CriticalPath returns the critical path of whichever task takes longest to complete, in
+// execution order, weighing tasks by their measured durations as Analyze does. Of tasks taking
+// equally long, the one with the longest chain is chosen, then the first alphabetically.
+// Returns nil if no task has a measured duration.
+func CriticalPath(gr *graph.Graph) []*graph.Node {
+	if !hasDurations(gr) {
+		return nil
+	}
+
+	var tasks []*graph.Node
+	for node := range gr.Nodes() {
+		if node.Kind == graph.NodeKindTask {
+			tasks = append(tasks, node)
+		}
+	}
+
+	slices.SortFunc(tasks, compareNodes)
+
+	var (
+		result  []*graph.Node
+		longest float64
+	)
+
+	// Each task is planned on its own, as where a task is called from depends on the target
+	for _, node := range tasks {
+		p := newPlanner()
+		p.visit(node, nil)
+
+		finish := p.layer(durationWeight)
+		if finish[node] < longest {
+			continue
+		}
+
+		chain := p.chain(node, finish)
+		if finish[node] > longest || len(chain) > len(result) {
+			result, longest = chain, finish[node]
+		}
+	}
+
+	return result
+}
+
+// MaxParallelism returns the largest number of tasks that can run at once, and the 1-based
+// index of the first wave achieving it.
+func (a *Analysis) MaxParallelism() (int, int) {
+	size, index := 0, 0
+
+	for i, w := range a.Waves {
+		if len(w) > size {
+			size, index = len(w), i+1
+		}
+	}
+
+	return size, index
+}
+
+// TaskCount returns the number of distinct tasks needed by the target, including the target.
+func (a *Analysis) TaskCount() int {
+	count := 0
+	for _, w := range a.Waves {
+		count += len(w)
+	}
+
+	return count
+}
+
+// Apply records the wave of each analysed task on its node, so that it can be used when
+// rendering the graph.
+func (a *Analysis) Apply() {
+	for i, w := range a.Waves {
+		for _, node := range w {
+			node.Wave = i + 1
+		}
+	}
+}
+
+// planner builds the precedence relation between the tasks needed by a target.
+type planner struct {
+	// after maps each task to the tasks that must finish before it can complete
+	after map[*graph.Node][]*graph.Node
+	// order lists the tasks in the order they were first visited
+	order []*graph.Node
+	// visiting tracks the tasks currently being visited, so that cycles are ignored
+	visiting map[*graph.Node]bool
+}
+
+func newPlanner() *planner {
+	return &planner{
+		after:    make(map[*graph.Node][]*graph.Node),
+		visiting: make(map[*graph.Node]bool),
+	}
+}
+
+// visit records the prerequisites of node and all the tasks it needs. prior holds the tasks
+// that must finish before node can start because of where it is called from.
+func (p *planner) visit(node *graph.Node, prior []*graph.Node) {
+	_, seen := p.after[node]
+	p.after[node] = appendUnique(p.after[node], prior...)
+
+	if seen {
+		return
+	}
+
+	p.order = append(p.order, node)
+	p.visiting[node] = true
+
+	var deps, calls []*graph.Node
+
+	for _, edge := range node.Edges() {
+		to := edge.To()
+		if p.visiting[to] {
+			// Ignore edges that would close a cycle
+			continue
+		}
+
+		switch edge.Class() {
+		case graph.EdgeClassDep:
+			deps = append(deps, to)
+		case graph.EdgeClassCall:
+			calls = append(calls, to)
+		}
+	}
+
+	for _, dep := range deps {
+		p.visit(dep, nil)
+	}
+
+	// Calls run in order, once all dependencies have finished
+	previous := deps
+	for _, call := range calls {
+		p.visit(call, previous)
+		previous = []*graph.Node{call}
+	}
+
+	p.after[node] = appendUnique(p.after[node], deps...)
+	p.after[node] = appendUnique(p.after[node], calls...)
+	p.visiting[node] = false
+}
+
+// layer returns, for each task, the weight of the heaviest chain of prerequisites ending with
+// that task (inclusive).
+func (p *planner) layer(weight func(*graph.Node) float64) map[*graph.Node]float64 {
+	result := make(map[*graph.Node]float64, len(p.order))
+	active := make(map[*graph.Node]bool)
+
+	var measure func(*graph.Node) float64
+
+	measure = func(node *graph.Node) float64 {
+		if w, ok := result[node]; ok {
+			return w
+		}
+
+		active[node] = true
+
+		var longest float64
+
+		for _, pre := range p.after[node] {
+			if active[pre] {
+				continue
+			}
+
+			longest = max(longest, measure(pre))
+		}
+
+		active[node] = false
+		result[node] = longest + weight(node)
+
+		return result[node]
+	}
+
+	for _, node := range p.order {
+		measure(node)
+	}
+
+	return result
+}
+
+// waves groups tasks by their layer, each wave sorted alphabetically.
+func (p *planner) waves(layer map[*graph.Node]float64) [][]*graph.Node {
+	var result [][]*graph.Node
+
+	for _, node := range p.order {
+		index := int(layer[node]) - 1
+		for len(result) <= index {
+			result = append(result, nil)
+		}
+
+		result[index] = append(result[index], node)
+	}
+
+	for _, w := range result {
+		slices.SortFunc(w, compareNodes)
+	}
+
+	return result
+}
+
+// chain walks back from node through the heaviest prerequisite at each step, returning the
+// chain in execution order. Ties are broken alphabetically.
+func (p *planner) chain(node *graph.Node, layer map[*graph.Node]float64) []*graph.Node {
+	result := []*graph.Node{node}
+	seen := map[*graph.Node]bool{node: true}
+
+	for current := node; ; {
+		var heaviest *graph.Node
+
+		for _, pre := range p.after[current] {
+			if seen[pre] {
+				continue
+			}
+
+			if heaviest == nil ||
+				layer[pre] > layer[heaviest] ||
+				(layer[pre] == layer[heaviest] && compareNodes(pre, heaviest) < 0) {
+				heaviest = pre
+			}
+		}
+
+		if heaviest == nil {
+			break
+		}
+
+		result = append(result, heaviest)
+		seen[heaviest] = true
+		current = heaviest
+	}
+
+	slices.Reverse(result)
+
+	return result
+}
+
+// durationWeight weighs a task by its measured duration, in seconds.
+func durationWeight(node *graph.Node) float64 {
+	return node.Duration.Seconds()
+}
+
+// hasDurations returns true if any node in the graph has a measured duration.
+func hasDurations(gr *graph.Graph) bool {
+	for node := range gr.Nodes() {
+		if node.Duration > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compareNodes(left, right *graph.Node) int {
+	return cmp.Compare(left.ID(), right.ID())
+}
+
+// appendUnique appends each of the given nodes to list, skipping any already present.
+func appendUnique(list []*graph.Node, nodes ...*graph.Node) []*graph.Node {
+	for _, n := range nodes {
+		if !slices.Contains(list, n) {
+			list = append(list, n)
+		}
+	}
+
+	return list
+}
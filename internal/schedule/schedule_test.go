@@ -0,0 +1,248 @@
+package schedule
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// newReleaseGraph creates a graph where release depends on build and lint (run in parallel)
+// and then calls package and publish (run in order); build depends on generate.
+func newReleaseGraph() *graph.Graph {
+	gr := graph.New()
+	release := gr.AddNode("release")
+	build := gr.AddNode("build")
+	lint := gr.AddNode("lint")
+	generate := gr.AddNode("generate")
+	pkg := gr.AddNode("package")
+	publish := gr.AddNode("publish")
+	gr.AddNode("unrelated")
+
+	release.AddEdge(build).SetClass(graph.EdgeClassDep)
+	release.AddEdge(lint).SetClass(graph.EdgeClassDep)
+	release.AddEdge(pkg).SetClass(graph.EdgeClassCall)
+	release.AddEdge(publish).SetClass(graph.EdgeClassCall)
+	build.AddEdge(generate).SetClass(graph.EdgeClassDep)
+
+	return gr
+}
+
+func ids(nodes []*graph.Node) []string {
+	result := make([]string, len(nodes))
+	for i, n := range nodes {
+		result[i] = n.ID()
+	}
+
+	return result
+}
+
+func waveIDs(waves [][]*graph.Node) [][]string {
+	result := make([][]string, len(waves))
+	for i, w := range waves {
+		result[i] = ids(w)
+	}
+
+	return result
+}
+
+func TestAnalyze_UnknownTarget_ReturnsError(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	_, err := Analyze(graph.New(), "missing")
+
+	// Assert
+	g.Expect(err).To(MatchError(ContainSubstring("missing")))
+}
+
+func TestAnalyze_DepsRunInParallel_CallsRunInOrder(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	analysis, err := Analyze(newReleaseGraph(), "release")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(waveIDs(analysis.Waves)).To(Equal([][]string{
+		{"generate", "lint"},
+		{"build"},
+		{"package"},
+		{"publish"},
+		{"release"},
+	}))
+	g.Expect(analysis.TaskCount()).To(Equal(6))
+
+	size, index := analysis.MaxParallelism()
+	g.Expect(size).To(Equal(2))
+	g.Expect(index).To(Equal(1))
+
+	g.Expect(ids(analysis.LongestChain)).To(Equal(
+		[]string{"generate", "build", "package", "publish", "release"}))
+	g.Expect(analysis.UsesDurations).To(BeFalse())
+	g.Expect(analysis.CriticalPathWeight).To(Equal(5.0))
+}
+
+func TestAnalyze_WithDurations_CriticalPathFollowsWeights(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	all := gr.AddNode("all")
+	quick := gr.AddNode("quick")
+	first := gr.AddNode("first")
+	second := gr.AddNode("second")
+	slow := gr.AddNode("slow")
+
+	all.AddEdge(quick).SetClass(graph.EdgeClassDep)
+	all.AddEdge(slow).SetClass(graph.EdgeClassDep)
+	quick.AddEdge(first).SetClass(graph.EdgeClassDep)
+	first.AddEdge(second).SetClass(graph.EdgeClassDep)
+
+	quick.Duration = time.Second
+	first.Duration = time.Second
+	second.Duration = time.Second
+	slow.Duration = time.Minute
+
+	// Act
+	analysis, err := Analyze(gr, "all")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(analysis.UsesDurations).To(BeTrue())
+	g.Expect(ids(analysis.LongestChain)).To(Equal([]string{"second", "first", "quick", "all"}))
+	g.Expect(ids(analysis.CriticalPath)).To(Equal([]string{"slow", "all"}))
+	g.Expect(analysis.CriticalPathWeight).To(Equal(60.0))
+}
+
+func TestCriticalPath_WithoutDurations_ReturnsNil(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Act
+	path := CriticalPath(newReleaseGraph())
+
+	// Assert
+	g.Expect(path).To(BeNil())
+}
+
+func TestCriticalPath_TaskCallingTwoSlowTasks_RunsCallsInTurn(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := newReleaseGraph()
+	durations := map[string]time.Duration{
+		"build":   10 * time.Second,
+		"lint":    50 * time.Second,
+		"package": 30 * time.Second,
+		"publish": 30 * time.Second,
+	}
+
+	for node := range gr.Nodes() {
+		node.Duration = durations[node.ID()]
+	}
+
+	// Act
+	path := CriticalPath(gr)
+
+	// Assert
+	g.Expect(ids(path)).To(Equal([]string{"lint", "package", "publish", "release"}))
+}
+
+func TestCriticalPath_MatchesAnalysisOfSlowestTask(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := newReleaseGraph()
+	for node := range gr.Nodes() {
+		node.Duration = time.Second
+	}
+
+	unrelated, _ := gr.Node("unrelated")
+	unrelated.Duration = time.Minute
+
+	analysis, err := Analyze(gr, "unrelated")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	path := CriticalPath(gr)
+
+	// Assert
+	g.Expect(path).To(Equal(analysis.CriticalPath))
+}
+
+func TestAnalyze_WithCycle_Terminates(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := graph.New()
+	a := gr.AddNode("a")
+	b := gr.AddNode("b")
+	a.AddEdge(b).SetClass(graph.EdgeClassDep)
+	b.AddEdge(a).SetClass(graph.EdgeClassDep)
+
+	// Act
+	analysis, err := Analyze(gr, "a")
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(waveIDs(analysis.Waves)).To(Equal([][]string{{"b"}, {"a"}}))
+}
+
+func TestAnalysis_Apply_SetsNodeWaves(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := newReleaseGraph()
+
+	analysis, err := Analyze(gr, "release")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Act
+	analysis.Apply()
+
+	// Assert
+	lint, _ := gr.Node("lint")
+	release, _ := gr.Node("release")
+	unrelated, _ := gr.Node("unrelated")
+
+	g.Expect(lint.Wave).To(Equal(1))
+	g.Expect(release.Wave).To(Equal(5))
+	g.Expect(unrelated.Wave).To(BeZero())
+}
+
+func TestAnalysis_WriteReport(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	gr := newReleaseGraph()
+	for node := range gr.Nodes() {
+		node.Duration = 1500 * time.Millisecond
+	}
+
+	analysis, err := Analyze(gr, "release")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var buf bytes.Buffer
+
+	// Act
+	err = analysis.WriteReport(&buf)
+
+	// Assert
+	g.Expect(err).NotTo(HaveOccurred())
+
+	gg := goldie.New(t)
+	gg.Assert(t, "release-report", buf.Bytes())
+}
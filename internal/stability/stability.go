@@ -0,0 +1,106 @@
+// Package stability provides the shared check that rendered output stays stable as tasks are
+// added to a graph. It is used by both the graphviz and mermaid rendering packages, so that
+// each renderer is held to the same fixtures.
+package stability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/sebdah/goldie/v2"
+
+	"github.com/theunrepentantgeek/task-graph/internal/graph"
+)
+
+// Writer renders a graph, auto-coloured by namespace and with a legend.
+type Writer func(t *testing.T, gr *graph.Graph) []byte
+
+// fixture is a graph before and after some tasks are added.
+type fixture struct {
+	name  string
+	build func(extra bool) *graph.Graph
+}
+
+var fixtures = []fixture{
+	{name: "collisions", build: collisionsGraph},
+	{name: "crowded", build: crowdedGraph},
+}
+
+// Check verifies, for each fixture, that adding tasks only adds lines to the output written by
+// write: the IDs, colours and legend entries of the other tasks are unchanged. Both outputs are
+// also compared against golden files in testdata.
+func Check(t *testing.T, write Writer) {
+	t.Helper()
+
+	for _, f := range fixtures {
+		t.Run(f.name, func(t *testing.T) {
+			t.Parallel()
+			g := gomega.NewWithT(t)
+
+			// Act
+			before := write(t, f.build(false))
+			after := write(t, f.build(true))
+
+			// Assert
+			gg := goldie.New(t)
+			g.Expect(gg.WithFixtureDir("testdata")).To(gomega.Succeed())
+
+			gg.Assert(t, "stability_"+f.name+"_before", before)
+			gg.Assert(t, "stability_"+f.name+"_after", after)
+
+			g.Expect(strings.Split(string(after), "\n")).To(
+				gomega.ContainElements(strings.Split(string(before), "\n")))
+		})
+	}
+}
+
+// collisionsGraph returns a graph whose tasks include two with colliding safe IDs; with extra,
+// it also has a task in a new namespace and a third task colliding with the others.
+func collisionsGraph(extra bool) *graph.Graph {
+	gr := graph.New()
+
+	build := gr.AddNode("build")
+	bin := gr.AddNode("build:bin")
+	sbom := gr.AddNode("build.sbom")
+	sbomNs := gr.AddNode("build:sbom")
+	site := gr.AddNode("docs:site")
+	unit := gr.AddNode("test:unit")
+
+	build.AddEdge(bin).SetClass(graph.EdgeClassDep)
+	build.AddEdge(sbom).SetClass(graph.EdgeClassDep)
+	sbom.AddEdge(sbomNs).SetClass(graph.EdgeClassCall)
+	site.AddEdge(build).SetClass(graph.EdgeClassDep)
+	unit.AddEdge(bin).SetClass(graph.EdgeClassDep)
+
+	if extra {
+		prod := gr.AddNode("deploy:prod")
+		prod.AddEdge(build).SetClass(graph.EdgeClassDep)
+
+		gr.AddNode("build/sbom")
+	}
+
+	return gr
+}
+
+// crowdedGraph returns a graph with more namespaces than the default palette has colours; with
+// extra, it also has a task in a new namespace. The new namespace sorts after all the others,
+// as one sorting earlier may take the colour of a namespace after it.
+func crowdedGraph(extra bool) *graph.Graph {
+	gr := graph.New()
+
+	namespaces := []string{
+		"build", "ci", "deploy", "docs", "gen", "lint", "mocks", "release", "test", "tidy",
+	}
+
+	all := gr.AddNode("all")
+	for _, ns := range namespaces {
+		all.AddEdge(gr.AddNode(ns + ":run")).SetClass(graph.EdgeClassDep)
+	}
+
+	if extra {
+		all.AddEdge(gr.AddNode("vet:run")).SetClass(graph.EdgeClassDep)
+	}
+
+	return gr
+}
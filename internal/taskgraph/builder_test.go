@@ -1,6 +1,7 @@
 package taskgraph
 
 import (
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -242,3 +243,113 @@ func TestAddEdgesForCalls_MissingSourceNode_IsNoop(t *testing.T) {
 	builder.addEdgesForCalls("nonexistent-task", task, gr)
 	// If we reach here, the defensive guard worked correctly.
 }
+
+// TestBuilder_Build_RunOnce_UsesTaskOrTaskfileDefault verifies that a task's run mode is taken
+// from the task when set, falling back to the Taskfile default.
+func TestBuilder_Build_RunOnce_UsesTaskOrTaskfileDefault(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	tf := makeTaskfile(
+		&ast.TaskElement{Key: "inherits", Value: &ast.Task{}},
+		&ast.TaskElement{Key: "always", Value: &ast.Task{Run: "always"}},
+	)
+	tf.Run = "once"
+
+	// Act
+	gr := New(tf).Build()
+
+	// Assert
+	inherits, _ := gr.Node("inherits")
+	always, _ := gr.Node("always")
+
+	g.Expect(inherits.RunOnce).To(BeTrue())
+	g.Expect(always.RunOnce).To(BeFalse())
+}
+
+func TestBuilder_Build_RecordsTaskMetadata(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	base := filepath.Join(t.TempDir(), "repo")
+	tf := makeTaskfile(
+		&ast.TaskElement{
+			Key: "docs:build",
+			Value: &ast.Task{
+				Location:  &ast.Location{Taskfile: filepath.Join(base, "docs", "Taskfile.yml"), Line: 12},
+				Aliases:   []string{"docs"},
+				Sources:   []*ast.Glob{{Glob: "content/**"}, {Glob: "layouts/**"}},
+				Generates: []*ast.Glob{{Glob: "public/**"}},
+				Internal:  true,
+				Prompt:    ast.Prompt{"Publish?"},
+				Cmds: []*ast.Cmd{
+					{Cmd: "hugo --minify\necho done"},
+					{Task: "lint"},
+				},
+			},
+		},
+		&ast.TaskElement{
+			Key: "remote:build",
+			Value: &ast.Task{
+				Location: &ast.Location{Taskfile: "https://example.com/Taskfile.yml", Line: 3},
+			},
+		},
+	)
+
+	builder := New(tf)
+	builder.BaseDir = base
+
+	// Act
+	gr := builder.Build()
+
+	// Assert
+	local, _ := gr.Node("docs:build")
+	g.Expect(local.File).To(Equal("docs/Taskfile.yml"))
+	g.Expect(local.Line).To(Equal(12))
+	g.Expect(local.Commands).To(Equal([]string{"hugo --minify", "task: lint"}))
+	g.Expect(local.Aliases).To(Equal([]string{"docs"}))
+	g.Expect(local.Sources).To(Equal(2))
+	g.Expect(local.Generates).To(Equal(1))
+	g.Expect(local.Internal).To(BeTrue())
+	g.Expect(local.Prompt).To(BeTrue())
+
+	remote, _ := gr.Node("remote:build")
+	g.Expect(remote.File).To(Equal("https://example.com/Taskfile.yml"))
+	g.Expect(remote.Line).To(Equal(3))
+}
+
+func TestBuilder_Build_MarksPlaceholders(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+
+	// Arrange
+	tf := makeTaskfile(
+		&ast.TaskElement{
+			Key: "shared:unreachable-include",
+			Value: &ast.Task{
+				Location: &ast.Location{Taskfile: "https://example.com/Taskfile.yml", Line: 1},
+			},
+		},
+		&ast.TaskElement{
+			Key: "build",
+			Value: &ast.Task{
+				Location: &ast.Location{Taskfile: "/repo/Taskfile.yml", Line: 3},
+			},
+		},
+	)
+
+	builder := New(tf)
+	builder.Placeholders = map[string]bool{"https://example.com/Taskfile.yml": true}
+
+	// Act
+	gr := builder.Build()
+
+	// Assert
+	placeholder, _ := gr.Node("shared:unreachable-include")
+	build, _ := gr.Node("build")
+
+	g.Expect(placeholder.Placeholder).To(BeTrue())
+	g.Expect(build.Placeholder).To(BeFalse())
+}
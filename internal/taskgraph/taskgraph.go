@@ -2,13 +2,18 @@ package taskgraph
 
 import (
 	"fmt"
+	"path/filepath"
 	"slices"
+	"strings"
 
 	"github.com/go-task/task/v3/taskfile/ast"
 
 	"github.com/theunrepentantgeek/task-graph/internal/graph"
 )
 
+// runOnce is the Taskfile `run` mode for tasks that run at most once.
+const runOnce = "once"
+
 // Builder is responsible for building a graph.Graph from a Taskfile.
 type Builder struct {
 	taskfile *ast.Taskfile
@@ -18,6 +23,14 @@ type Builder struct {
 	// IncludeGlobalVars controls whether global variables are added as nodes
 	// to the graph, with edges pointing to the tasks that reference them.
 	IncludeGlobalVars bool
+
+	// BaseDir is the directory that the paths of Taskfiles are made relative to, when recording
+	// where each task is defined. Paths are left absolute if empty.
+	BaseDir string
+
+	// Placeholders holds the locations of Taskfiles standing in for remote includes that
+	// couldn't be loaded. Their tasks are marked as placeholders.
+	Placeholders map[string]bool
 }
 
 // New creates a new Builder that builds a graph from the given Taskfile.
@@ -38,6 +51,19 @@ func (b *Builder) Build() *graph.Graph {
 	for taskName, task := range b.taskfile.Tasks.All(alphaNumeric) {
 		node := g.AddNode(taskName)
 		node.Description = task.Desc
+		node.RunOnce = b.runsOnce(task)
+		node.Commands = commandSummary(task)
+		node.Aliases = task.Aliases
+		node.Sources = len(task.Sources)
+		node.Generates = len(task.Generates)
+		node.Internal = task.Internal
+		node.Prompt = len(task.Prompt) > 0
+
+		if task.Location != nil {
+			node.File = b.relativePath(task.Location.Taskfile)
+			node.Line = task.Location.Line
+			node.Placeholder = b.Placeholders[task.Location.Taskfile]
+		}
 	}
 
 	// Create edges for task dependencies and calls
@@ -101,6 +127,52 @@ func (*Builder) addEdgesForCalls(
 	}
 }
 
+// relativePath returns the path of a Taskfile relative to BaseDir, using forward slashes, or
+// unchanged if it is remote or outside BaseDir.
+func (b *Builder) relativePath(path string) string {
+	if b.BaseDir == "" || !filepath.IsAbs(path) {
+		return path
+	}
+
+	rel, err := filepath.Rel(b.BaseDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// commandSummary returns the first line of each command of the task, or the task called.
+func commandSummary(task *ast.Task) []string {
+	result := make([]string, 0, len(task.Cmds))
+
+	for _, cmd := range task.Cmds {
+		if cmd.Task != "" {
+			result = append(result, "task: "+cmd.Task)
+
+			continue
+		}
+
+		line, _, _ := strings.Cut(strings.TrimSpace(cmd.Cmd), "\n")
+		if line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// runsOnce returns true if the task runs only once however many tasks need it, either
+// because the task says so or because it inherits the default for the Taskfile.
+func (b *Builder) runsOnce(task *ast.Task) bool {
+	run := task.Run
+	if run == "" {
+		run = b.taskfile.Run
+	}
+
+	return run == runOnce
+}
+
 // alphaNumeric sorts the slice into alphanumeric order.
 // Copied from an internal function in the tasks package.
 func alphaNumeric(items []string, _ []string) []string {
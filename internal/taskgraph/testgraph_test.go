@@ -43,7 +43,7 @@ func TestTaskGraphBuilder_Graphviz(t *testing.T) {
 
 			taskfilePath := filepath.Join("..", "..", "samples", c.taskfile)
 
-			tf, err := loader.Load(t.Context(), taskfilePath)
+			tf, _, err := loader.Load(t.Context(), taskfilePath, loader.Options{})
 			g.Expect(err).NotTo(HaveOccurred())
 
 			gr := New(tf).Build()
@@ -68,7 +68,7 @@ func TestTaskGraphBuilder_WithGlobalVars_Graphviz(t *testing.T) {
 
 	taskfilePath := filepath.Join("testdata", "global-vars-taskfile.yml")
 
-	tf, err := loader.Load(t.Context(), taskfilePath)
+	tf, _, err := loader.Load(t.Context(), taskfilePath, loader.Options{})
 	g.Expect(err).NotTo(HaveOccurred())
 
 	builder := New(tf)
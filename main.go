@@ -1,43 +1,75 @@
 package main
 
 import (
+	"os"
+
 	"github.com/alecthomas/kong"
 
 	"github.com/theunrepentantgeek/task-graph/internal/cmd"
+	"github.com/theunrepentantgeek/task-graph/internal/diagnostic"
 )
 
 func main() {
 	// Entry point for the application.
-	var cli cmd.CLI
+	var root cmd.Root
 
-	ctx := kong.Parse(&cli,
+	ctx := kong.Parse(&root,
 		kong.UsageOnError())
 
-	log := cli.CreateLogger()
-
-	cfg, err := cli.CreateConfig()
-	if err != nil {
-		log.Error("Error loading config", "error", err)
-		ctx.Exit(1)
-	}
-
-	err = cli.ExportConfigToFile(cfg)
-	if err != nil {
-		log.Error("Error exporting config", "error", err)
-		ctx.Exit(1)
-	}
+	cli := &root.Graph
+	log := cli.CreateLogger(os.Stderr)
 
 	flags := &cmd.Flags{
 		Verbose: cli.Verbose,
 		Log:     log,
-		Config:  cfg,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
 	}
 
-	err = ctx.Run(flags)
+	// Only drawing a graph needs the config; init and schema work without one
+	if ctx.Selected().Name == "graph" {
+		cfg, err := cli.CreateConfig(flags.Stdin)
+		if err != nil {
+			reportError(flags, "Error loading config", err)
+			ctx.Exit(1)
+		}
+
+		err = cli.ExportConfigToFile(cfg)
+		if err != nil {
+			log.Error("Error exporting config", "error", err)
+			ctx.Exit(1)
+		}
+
+		flags.Config = cfg
+	}
+
+	err := ctx.Run(flags)
 	if err != nil {
-		flags.Log.Error("Error executing command", "error", err)
+		reportError(flags, "Error executing command", err)
 		ctx.Exit(1)
 	}
 
 	ctx.Exit(0)
 }
+
+// reportError logs the given error. Problems located within a file, such as an invalid config
+// file or Taskfile, are first written together to flags.Stderr, each with the line on which it
+// was found.
+func reportError(flags *cmd.Flags, message string, err error) {
+	diags := diagnostic.All(err)
+	if len(diags) == 0 {
+		flags.Log.Error(message, "error", err)
+
+		return
+	}
+
+	problems := 0
+
+	for _, diag := range diags {
+		_ = diag.Write(flags.Stderr)
+		problems += len(diag.Diagnostics)
+	}
+
+	flags.Log.Error(message, "problems", problems)
+}